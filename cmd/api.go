@@ -1,20 +1,37 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"time"
 
 	"github.com/VishalHilal/e-commerce-api/internal/adapters/postgresql"
 	"github.com/VishalHilal/e-commerce-api/internal/auth"
+	"github.com/VishalHilal/e-commerce-api/internal/cache"
 	"github.com/VishalHilal/e-commerce-api/internal/cart"
+	"github.com/VishalHilal/e-commerce-api/internal/checkout"
+	"github.com/VishalHilal/e-commerce-api/internal/email"
+	"github.com/VishalHilal/e-commerce-api/internal/fulfilment"
+	"github.com/VishalHilal/e-commerce-api/internal/invoices"
 	"github.com/VishalHilal/e-commerce-api/internal/orders"
+	"github.com/VishalHilal/e-commerce-api/internal/payments"
+	"github.com/VishalHilal/e-commerce-api/internal/pricing"
 	"github.com/VishalHilal/e-commerce-api/internal/products"
+	"github.com/VishalHilal/e-commerce-api/internal/promotions"
+	"github.com/VishalHilal/e-commerce-api/internal/questions"
+	"github.com/VishalHilal/e-commerce-api/internal/recommendations"
+	"github.com/VishalHilal/e-commerce-api/internal/recovery"
+	"github.com/VishalHilal/e-commerce-api/internal/returns"
 	"github.com/VishalHilal/e-commerce-api/internal/reviews"
+	"github.com/VishalHilal/e-commerce-api/internal/shipping"
+	"github.com/VishalHilal/e-commerce-api/internal/tax"
 	"github.com/VishalHilal/e-commerce-api/internal/users"
+	"github.com/VishalHilal/e-commerce-api/internal/watches"
+	"github.com/VishalHilal/e-commerce-api/internal/wishlists"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
-	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 func (app *application) mount() http.Handler {
@@ -33,8 +50,15 @@ func (app *application) mount() http.Handler {
 
 	repo := postgresql.New(app.db)
 	jwtSvc := auth.NewJWTService("your-secret-key-change-in-production")
+	emailSvc := email.NewEmailService(app.config.email)
 
-	userService := users.NewService(repo, jwtSvc)
+	pricingService := pricing.NewService(repo)
+	promotionService := promotions.NewService(repo, pricingService)
+	taxService := tax.NewService(repo)
+	shippingService := shipping.NewService(repo)
+	cartService := cart.NewService(repo, pricingService, taxService, shippingService, promotionService)
+
+	userService := users.NewService(repo, jwtSvc, cartService)
 	userHandler := users.NewHandler(userService)
 	r.Post("/auth/register", userHandler.Register)
 	r.Post("/auth/login", userHandler.Login)
@@ -44,43 +68,224 @@ func (app *application) mount() http.Handler {
 		r.Put("/auth/profile", userHandler.UpdateProfile)
 	})
 
-	productService := products.NewService(repo)
+	watchService := watches.NewService(repo, emailSvc)
+	watchHandler := watches.NewHandler(watchService)
+	r.Get("/watches/unsubscribe/{token}", watchHandler.Unsubscribe)
+	r.Group(func(r chi.Router) {
+		r.Use(jwtSvc.AuthMiddleware)
+		r.Get("/watches", watchHandler.GetWatches)
+		r.Post("/watches", watchHandler.CreateWatch)
+		r.Delete("/watches/{id}", watchHandler.DeleteWatch)
+	})
+
+	productService := products.NewService(repo, watchService)
 	productHandler := products.NewHandler(productService)
 	r.Get("/products", productHandler.ListProducts)
-	r.Get("/products/{id}", productHandler.GetProduct)
+	r.Get("/products/compare", productHandler.CompareProducts)
+	r.With(jwtSvc.OptionalAuthMiddleware).Get("/products/{id}", productHandler.GetProduct)
 
 	r.Group(func(r chi.Router) {
 		r.Use(jwtSvc.AuthMiddleware, auth.RequireRole("admin"))
 		r.Post("/products", productHandler.CreateProduct)
 		r.Put("/products/{id}", productHandler.UpdateProduct)
+		r.Put("/products/{id}/attributes", productHandler.UpdateProductAttributes)
 		r.Delete("/products/{id}", productHandler.DeleteProduct)
 	})
 
-	cartService := cart.NewService(repo)
-	cartHandler := cart.NewHandler(cartService)
+	recommendationService := recommendations.NewService(repo, app.cache)
+	app.jobs = append(app.jobs, func(ctx context.Context) {
+		recommendationService.StartCoPurchaseJob(ctx, time.Hour)
+	})
+	recommendationHandler := recommendations.NewHandler(recommendationService)
+	r.Get("/products/{id}/related", recommendationHandler.GetRelated)
+	r.Get("/products/{id}/bought-together", recommendationHandler.GetBoughtTogether)
 	r.Group(func(r chi.Router) {
 		r.Use(jwtSvc.AuthMiddleware)
+		r.Get("/me/recently-viewed", recommendationHandler.GetRecentlyViewed)
+		r.Get("/me/recommendations", recommendationHandler.GetRecommendations)
+	})
+
+	cartHandler := cart.NewHandler(cartService)
+	promotionHandler := promotions.NewHandler(promotionService)
+	r.Group(func(r chi.Router) {
+		r.Use(jwtSvc.CartSessionMiddleware)
 		r.Get("/cart", cartHandler.GetCart)
+		r.Get("/cart/shipping-options", cartHandler.GetShippingOptions)
+		r.Post("/cart/acknowledge", cartHandler.AcknowledgeChanges)
 		r.Post("/cart", cartHandler.AddToCart)
 		r.Put("/cart/{product_id}", cartHandler.UpdateCartItem)
 		r.Delete("/cart/{product_id}", cartHandler.RemoveFromCart)
 		r.Delete("/cart", cartHandler.ClearCart)
+		r.Post("/cart/shares/{token}/copy", cartHandler.CopySharedCart)
+	})
+
+	r.Get("/cart/shares/{token}", cartHandler.GetSharedCart)
+	r.Group(func(r chi.Router) {
+		r.Use(jwtSvc.AuthMiddleware)
+		r.Post("/cart/coupon", promotionHandler.ApplyCoupon)
+		r.Delete("/cart/coupon", promotionHandler.RemoveCoupon)
+		r.Get("/cart/saved", cartHandler.GetSavedItems)
+		r.Post("/cart/{product_id}/save-for-later", cartHandler.SaveForLater)
+		r.Post("/cart/saved/{product_id}/move-to-cart", cartHandler.MoveSavedItemToCart)
+		r.Delete("/cart/saved/{product_id}", cartHandler.RemoveSavedItem)
+		r.Post("/cart/share", cartHandler.ShareCart)
+		r.Delete("/cart/shares/{token}", cartHandler.RevokeCartShare)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(jwtSvc.AuthMiddleware, auth.RequireRole("admin"))
+		r.Get("/admin/coupons", promotionHandler.GetCoupons)
+		r.Post("/admin/coupons", promotionHandler.CreateCoupon)
+		r.Delete("/admin/coupons/{id}", promotionHandler.DeactivateCoupon)
+		r.Get("/admin/promotions", promotionHandler.GetPromotionRules)
+		r.Post("/admin/promotions", promotionHandler.CreatePromotionRule)
+		r.Delete("/admin/promotions/{id}", promotionHandler.DeactivatePromotionRule)
+	})
+
+	recoveryService := recovery.NewService(repo, emailSvc, promotionService, app.config.recovery)
+	app.jobs = append(app.jobs, func(ctx context.Context) {
+		recoveryService.StartAbandonedCartJob(ctx, app.config.recovery.JobInterval)
+	})
+	recoveryHandler := recovery.NewHandler(recoveryService)
+	r.Group(func(r chi.Router) {
+		r.Use(jwtSvc.AuthMiddleware, auth.RequireRole("admin"))
+		r.Get("/admin/reports/abandoned-carts", recoveryHandler.GetReport)
+	})
+
+	pricingHandler := pricing.NewHandler(pricingService)
+	r.With(jwtSvc.OptionalAuthMiddleware).Get("/products/{id}/price-breaks", pricingHandler.GetPriceBreaks)
+	r.Group(func(r chi.Router) {
+		r.Use(jwtSvc.AuthMiddleware, auth.RequireRole("admin"))
+		r.Get("/admin/customer-groups", pricingHandler.GetCustomerGroups)
+		r.Post("/admin/customer-groups", pricingHandler.CreateCustomerGroup)
+		r.Get("/admin/customer-groups/{id}/prices", pricingHandler.GetPriceList)
+		r.Put("/admin/customer-groups/{id}/prices", pricingHandler.SetPriceList)
+		r.Delete("/admin/customer-groups/{id}/prices/{product_id}", pricingHandler.RemovePriceListItem)
+		r.Put("/admin/users/{id}/customer-group", pricingHandler.AssignCustomerGroup)
+		r.Put("/admin/products/{id}/price-breaks", pricingHandler.SetPriceBreaks)
+	})
+
+	taxHandler := tax.NewHandler(taxService)
+	r.Group(func(r chi.Router) {
+		r.Use(jwtSvc.AuthMiddleware, auth.RequireRole("admin"))
+		r.Get("/admin/tax/classes", taxHandler.GetTaxClasses)
+		r.Post("/admin/tax/classes", taxHandler.CreateTaxClass)
+		r.Put("/admin/categories/{id}/tax-class", taxHandler.SetCategoryTaxClass)
+		r.Get("/admin/tax/jurisdictions", taxHandler.GetJurisdictions)
+		r.Post("/admin/tax/jurisdictions", taxHandler.CreateJurisdiction)
+		r.Get("/admin/tax/rates", taxHandler.GetRates)
+		r.Post("/admin/tax/rates", taxHandler.CreateRate)
+	})
+
+	shippingHandler := shipping.NewHandler(shippingService)
+	r.Group(func(r chi.Router) {
+		r.Use(jwtSvc.AuthMiddleware, auth.RequireRole("admin"))
+		r.Get("/admin/shipping/zones", shippingHandler.GetZones)
+		r.Post("/admin/shipping/zones", shippingHandler.CreateZone)
+		r.Delete("/admin/shipping/zones/{id}", shippingHandler.DeleteZone)
+		r.Get("/admin/shipping/methods", shippingHandler.GetMethods)
+		r.Post("/admin/shipping/methods", shippingHandler.CreateMethod)
+		r.Delete("/admin/shipping/methods/{id}", shippingHandler.DeactivateMethod)
+	})
+
+	wishlistService := wishlists.NewService(repo, cartService)
+	wishlistHandler := wishlists.NewHandler(wishlistService)
+	r.Get("/wishlists/shared/{token}", wishlistHandler.GetSharedWishlist)
+	r.Group(func(r chi.Router) {
+		r.Use(jwtSvc.AuthMiddleware)
+		r.Get("/wishlists", wishlistHandler.GetWishlists)
+		r.Post("/wishlists", wishlistHandler.CreateWishlist)
+		r.Get("/wishlists/{id}", wishlistHandler.GetWishlist)
+		r.Put("/wishlists/{id}", wishlistHandler.RenameWishlist)
+		r.Delete("/wishlists/{id}", wishlistHandler.DeleteWishlist)
+		r.Post("/wishlists/{id}/items", wishlistHandler.AddItem)
+		r.Delete("/wishlists/{id}/items/{product_id}", wishlistHandler.RemoveItem)
+		r.Post("/wishlists/{id}/items/{product_id}/move-to-cart", wishlistHandler.MoveToCart)
 	})
 
-	orderService := orders.NewService(repo)
+	invoiceService := invoices.NewService(repo, app.config.invoices)
+	invoiceHandler := invoices.NewHandler(invoiceService)
+
+	paymentService := payments.NewService(repo, payments.NewManualProvider(), invoiceService)
+	app.jobs = append(app.jobs, func(ctx context.Context) {
+		paymentService.StartRefundReconcileJob(ctx, 15*time.Minute)
+	})
+	paymentHandler := payments.NewHandler(paymentService)
+
+	orderService := orders.NewService(repo, cartService, pricingService, promotionService, taxService, shippingService, emailSvc, paymentService, invoiceService, productService)
 	orderHandler := orders.NewHandler(orderService)
 	r.Group(func(r chi.Router) {
 		r.Use(jwtSvc.AuthMiddleware)
+		r.Post("/cart/checkout", orderHandler.Checkout)
 		r.Post("/orders", orderHandler.CreateOrder)
 		r.Get("/orders", orderHandler.GetUserOrders)
 		r.Get("/orders/{id}", orderHandler.GetOrder)
+		r.Post("/orders/{id}/cancel", orderHandler.CancelOrder)
+		r.Get("/orders/{id}/invoice.pdf", invoiceHandler.GetInvoicePDF)
+		r.Get("/orders/{id}/credit-notes/{credit_note_id}.pdf", invoiceHandler.GetCreditNotePDF)
 		r.Post("/payments", orderHandler.ProcessPayment)
 	})
 
+	checkoutService := checkout.NewService(repo, cartService, orderService, promotionService, taxService, shippingService)
+	checkoutHandler := checkout.NewHandler(checkoutService)
+	r.Group(func(r chi.Router) {
+		r.Use(jwtSvc.AuthMiddleware)
+		r.Post("/checkout/sessions", checkoutHandler.CreateSession)
+		r.Get("/checkout/sessions/{id}", checkoutHandler.GetSession)
+		r.Put("/checkout/sessions/{id}/addresses", checkoutHandler.SetAddresses)
+		r.Put("/checkout/sessions/{id}/shipping", checkoutHandler.SetShippingMethod)
+		r.Put("/checkout/sessions/{id}/discount", checkoutHandler.ApplyDiscount)
+		r.Delete("/checkout/sessions/{id}/discount", checkoutHandler.RemoveDiscount)
+		r.Post("/checkout/sessions/{id}/confirm", checkoutHandler.Confirm)
+	})
+
 	r.Group(func(r chi.Router) {
 		r.Use(jwtSvc.AuthMiddleware, auth.RequireRole("admin"))
 		r.Get("/admin/orders", orderHandler.GetAllOrders)
+		r.Get("/admin/orders/{id}", orderHandler.GetOrderForAdmin)
 		r.Put("/admin/orders/{id}", orderHandler.UpdateOrderStatus)
+		r.Post("/admin/orders/{id}/notes", orderHandler.AddOrderNote)
+		r.Get("/admin/orders/{id}/refunds", paymentHandler.GetRefunds)
+		r.Post("/admin/orders/{id}/refunds", paymentHandler.CreateRefund)
+		r.Get("/admin/orders/{id}/invoice", invoiceHandler.GetInvoice)
+		r.Post("/admin/orders/{id}/invoice/render", invoiceHandler.RenderInvoice)
+	})
+
+	carriers := []fulfilment.Carrier{
+		fulfilment.NewFakeCarrier(app.config.carriers.fakeWebhookSecret),
+	}
+	fulfilmentService := fulfilment.NewService(repo, emailSvc, carriers)
+	fulfilmentHandler := fulfilment.NewHandler(fulfilmentService)
+	r.Post("/webhooks/carriers/{carrier}", fulfilmentHandler.CarrierWebhook)
+	r.Group(func(r chi.Router) {
+		r.Use(jwtSvc.AuthMiddleware, auth.RequireRole("admin"))
+		r.Get("/admin/orders/{id}/shipments", fulfilmentHandler.GetOrderShipments)
+		r.Post("/admin/orders/{id}/shipments", fulfilmentHandler.CreateShipment)
+		r.Post("/admin/shipments/{id}/ship", fulfilmentHandler.ShipShipment)
+		r.Post("/admin/shipments/{id}/deliver", fulfilmentHandler.DeliverShipment)
+		r.Get("/admin/shipments/{id}/rates", fulfilmentHandler.GetRates)
+		r.Post("/admin/shipments/{id}/label", fulfilmentHandler.BuyLabel)
+		r.Get("/admin/shipments/{id}/label", fulfilmentHandler.GetLabel)
+		r.Get("/admin/shipments/{id}/tracking", fulfilmentHandler.Track)
+	})
+
+	returnService := returns.NewService(repo, paymentService, orderService, productService)
+	returnHandler := returns.NewHandler(returnService)
+	r.Group(func(r chi.Router) {
+		r.Use(jwtSvc.AuthMiddleware)
+		r.Post("/orders/{id}/returns", returnHandler.RequestReturn)
+		r.Get("/returns", returnHandler.GetUserReturns)
+		r.Get("/returns/{id}", returnHandler.GetUserReturn)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(jwtSvc.AuthMiddleware, auth.RequireRole("admin"))
+		r.Get("/admin/returns", returnHandler.GetReturns)
+		r.Get("/admin/returns/{id}", returnHandler.GetReturn)
+		r.Post("/admin/returns/{id}/approve", returnHandler.ApproveReturn)
+		r.Post("/admin/returns/{id}/reject", returnHandler.RejectReturn)
+		r.Post("/admin/returns/{id}/receive", returnHandler.ReceiveReturn)
+		r.Post("/admin/returns/{id}/resolve", returnHandler.ResolveReturn)
 	})
 
 	reviewService := reviews.NewService(repo)
@@ -97,10 +302,35 @@ func (app *application) mount() http.Handler {
 		r.Delete("/reviews/{id}", reviewHandler.DeleteReview)
 	})
 
+	questionService := questions.NewService(repo)
+	questionHandler := questions.NewHandler(questionService)
+	r.Get("/products/{product_id}/questions", questionHandler.GetProductQuestions)
+	r.Group(func(r chi.Router) {
+		r.Use(jwtSvc.AuthMiddleware)
+		r.Post("/products/{product_id}/questions", questionHandler.AskQuestion)
+		r.Post("/questions/{id}/answers", questionHandler.AnswerQuestion)
+		r.Post("/answers/{id}/upvote", questionHandler.UpvoteAnswer)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(jwtSvc.AuthMiddleware, auth.RequireRole("admin"))
+		r.Get("/admin/questions", questionHandler.GetModerationQueue)
+		r.Put("/admin/questions/{id}", questionHandler.ModerateQuestion)
+	})
+
 	return r
 }
 
-func (app *application) run(h http.Handler) error {
+// startJobs starts the background jobs registered by mount. They stop when
+// ctx is cancelled.
+func (app *application) startJobs(ctx context.Context) {
+	for _, job := range app.jobs {
+		job(ctx)
+	}
+}
+
+// run serves h until ctx is cancelled, then lets requests in flight finish.
+func (app *application) run(ctx context.Context, h http.Handler) error {
 	srv := &http.Server{
 		Addr:         app.config.addr,
 		Handler:      h,
@@ -109,22 +339,53 @@ func (app *application) run(h http.Handler) error {
 		IdleTimeout:  time.Minute,
 	}
 
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.ListenAndServe()
+	}()
+
 	log.Printf("server has started at addr %s", app.config.addr)
 
-	return srv.ListenAndServe()
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
 }
 
 type application struct {
 	config config
 	// logger
-	db *pgx.Conn
+	db    *pgxpool.Pool
+	cache cache.Cache
+	// jobs start the background work of the services mounted.
+	jobs []func(ctx context.Context)
 }
 
 type config struct {
-	addr string
-	db   dbConfig
+	addr     string
+	db       dbConfig
+	redis    redisConfig
+	email    email.EmailConfig
+	recovery recovery.Config
+	carriers carrierConfig
+	invoices invoices.Config
 }
 
 type dbConfig struct {
 	dsn string
 }
+
+type redisConfig struct {
+	addr     string
+	password string
+	db       int
+}
+
+type carrierConfig struct {
+	fakeWebhookSecret string
+}
@@ -4,19 +4,67 @@ import (
 	"context"
 	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
-	"github.com/jackc/pgx/v5"
+	"github.com/VishalHilal/e-commerce-api/internal/cache"
+	"github.com/VishalHilal/e-commerce-api/internal/email"
 	"github.com/VishalHilal/e-commerce-api/internal/env"
+	"github.com/VishalHilal/e-commerce-api/internal/invoices"
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+	"github.com/VishalHilal/e-commerce-api/internal/recovery"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 func main() {
-	ctx := context.Background()
+	// Cancelled on shutdown, which stops the background jobs and the server.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	cfg := config{
 		addr: ":8080",
 		db: dbConfig{
 			dsn: env.GetString("GOOSE_DBSTRING", "host=localhost user=postgres password=postgres dbname=ecom sslmode=disable"),
 		},
+		redis: redisConfig{
+			addr:     env.GetString("REDIS_ADDR", "localhost:6379"),
+			password: env.GetString("REDIS_PASSWORD", ""),
+			db:       env.GetInt("REDIS_DB", 0),
+		},
+		email: email.EmailConfig{
+			SMTPHost: env.GetString("SMTP_HOST", "localhost"),
+			SMTPPort: env.GetInt("SMTP_PORT", 587),
+			Username: env.GetString("SMTP_USERNAME", ""),
+			Password: env.GetString("SMTP_PASSWORD", ""),
+			From:     env.GetString("SMTP_FROM", "no-reply@yourstore.com"),
+		},
+		recovery: recovery.Config{
+			AbandonAfter:   time.Duration(env.GetInt("ABANDONED_CART_HOURS", 24)) * time.Hour,
+			JobInterval:    time.Duration(env.GetInt("ABANDONED_CART_JOB_MINUTES", 15)) * time.Minute,
+			CouponPercent:  float64(env.GetInt("ABANDONED_CART_COUPON_PERCENT", 0)),
+			CouponValidFor: time.Duration(env.GetInt("ABANDONED_CART_COUPON_DAYS", 7)) * 24 * time.Hour,
+			MaxReminders:   env.GetInt("ABANDONED_CART_MAX_REMINDERS", 2),
+			ReminderWindow: time.Duration(env.GetInt("ABANDONED_CART_REMINDER_WINDOW_DAYS", 30)) * 24 * time.Hour,
+		},
+		carriers: carrierConfig{
+			fakeWebhookSecret: env.GetString("FAKE_CARRIER_WEBHOOK_SECRET", ""),
+		},
+		invoices: invoices.Config{
+			Seller: models.InvoiceParty{
+				Name:  env.GetString("SELLER_NAME", "Your Store"),
+				Email: env.GetString("SELLER_EMAIL", ""),
+				TaxID: env.GetString("SELLER_TAX_ID", ""),
+				Address: models.Address{
+					Line1:      env.GetString("SELLER_ADDRESS_LINE1", ""),
+					Line2:      env.GetString("SELLER_ADDRESS_LINE2", ""),
+					City:       env.GetString("SELLER_CITY", ""),
+					Region:     env.GetString("SELLER_REGION", ""),
+					PostalCode: env.GetString("SELLER_POSTAL_CODE", ""),
+					Country:    env.GetString("SELLER_COUNTRY", ""),
+				},
+			},
+		},
 	}
 
 	// Logger
@@ -24,19 +72,33 @@ func main() {
 	slog.SetDefault(logger)
 
 	// Database
-	conn, err := pgx.Connect(ctx, cfg.db.dsn)
+	pool, err := pgxpool.New(ctx, cfg.db.dsn)
 	if err != nil {
 		panic(err)
 	}
-	defer conn.Close(ctx)
+	defer pool.Close()
 
 	logger.Info("connected to database", "dsn", cfg.db.dsn)
 
+	// Cache
+	var appCache cache.Cache
+	redisCache, err := cache.NewRedisCache(cfg.redis.addr, cfg.redis.password, cfg.redis.db)
+	if err != nil {
+		logger.Warn("redis unavailable, falling back to in-memory cache", "error", err)
+		appCache = cache.NewMemoryCache()
+	} else {
+		appCache = redisCache
+	}
+	defer appCache.Close()
+
 	api := application{
 		config: cfg,
-		db: conn,
+		db:     pool,
+		cache:  appCache,
 	}
-	if err := api.run(api.mount()); err != nil {
+	h := api.mount()
+	api.startJobs(ctx)
+	if err := api.run(ctx, h); err != nil {
 		slog.Error("server failed to start", "error", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,195 @@
+package postgresql
+
+import (
+	"context"
+	"time"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// abandonedCartRecoveryWindow is how long after an abandonment an order by
+// the same user still counts as recovering it.
+const abandonedCartRecoveryWindow = 14 * 24 * time.Hour
+
+const abandonedCartColumns = `id, user_id, last_activity_at, item_count, cart_value, status, skip_reason, reminder_sent_at,
+	coupon_id, recovered_order_id, recovered_at, detected_at`
+
+// FlagAbandonedCarts records every user cart last updated between
+// activeAfter and inactiveSince that has not been recorded yet. It returns
+// the number of carts flagged.
+func (r *Repository) FlagAbandonedCarts(ctx context.Context, inactiveSince, activeAfter time.Time) (int, error) {
+	query := `
+		INSERT INTO abandoned_carts (user_id, last_activity_at, item_count, cart_value)
+		SELECT user_id, MAX(updated_at), SUM(quantity), SUM(quantity * added_price)
+		FROM cart_items
+		WHERE user_id IS NOT NULL
+		GROUP BY user_id
+		HAVING MAX(updated_at) < $1 AND MAX(updated_at) >= $2
+		ON CONFLICT (user_id, last_activity_at) DO NOTHING
+	`
+
+	tag, err := r.db.Exec(ctx, query, inactiveSince, activeAfter)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// GetPendingAbandonedCarts returns the abandonments last active after
+// activeAfter that still wait for a reminder and have not been recovered.
+func (r *Repository) GetPendingAbandonedCarts(ctx context.Context, activeAfter time.Time) ([]models.AbandonedCart, error) {
+	query := `
+		SELECT ` + abandonedCartColumns + `
+		FROM abandoned_carts
+		WHERE status = 'pending' AND recovered_order_id IS NULL AND last_activity_at >= $1
+		ORDER BY id
+	`
+
+	rows, err := r.db.Query(ctx, query, activeAfter)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var carts []models.AbandonedCart
+	for rows.Next() {
+		cart, err := scanAbandonedCart(rows)
+		if err != nil {
+			return nil, err
+		}
+		carts = append(carts, *cart)
+	}
+
+	return carts, nil
+}
+
+// CountCartReminders counts the abandoned cart reminders sent to the user
+// since the given time.
+func (r *Repository) CountCartReminders(ctx context.Context, userID int, since time.Time) (int, error) {
+	query := `SELECT COUNT(*) FROM abandoned_carts WHERE user_id = $1 AND reminder_sent_at >= $2`
+
+	var count int
+	if err := r.db.QueryRow(ctx, query, userID, since).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// ClaimAbandonedCart marks a pending abandonment as reminded. It returns
+// false if another run got to it first.
+func (r *Repository) ClaimAbandonedCart(ctx context.Context, id int) (bool, error) {
+	query := `
+		UPDATE abandoned_carts
+		SET status = 'sent', reminder_sent_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'pending'
+	`
+
+	tag, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// ReleaseAbandonedCart puts a claimed abandonment back to pending after its
+// reminder failed to send.
+func (r *Repository) ReleaseAbandonedCart(ctx context.Context, id int) error {
+	query := `
+		UPDATE abandoned_carts
+		SET status = 'pending', reminder_sent_at = NULL, coupon_id = NULL
+		WHERE id = $1 AND status = 'sent'
+	`
+	_, err := r.db.Exec(ctx, query, id)
+	return err
+}
+
+func (r *Repository) SkipAbandonedCart(ctx context.Context, id int, reason string) error {
+	query := `UPDATE abandoned_carts SET status = 'skipped', skip_reason = $2 WHERE id = $1 AND status = 'pending'`
+	_, err := r.db.Exec(ctx, query, id, reason)
+	return err
+}
+
+func (r *Repository) SetAbandonedCartCoupon(ctx context.Context, id, couponID int) error {
+	query := `UPDATE abandoned_carts SET coupon_id = $2 WHERE id = $1`
+	_, err := r.db.Exec(ctx, query, id, couponID)
+	return err
+}
+
+// GetAbandonedCartReport sums up the carts flagged in [from, to). Rates are
+// left to the caller.
+func (r *Repository) GetAbandonedCartReport(ctx context.Context, from, to time.Time) (*models.AbandonedCartReport, error) {
+	query := `
+		SELECT COUNT(*),
+		       COALESCE(SUM(ac.cart_value), 0),
+		       COUNT(*) FILTER (WHERE ac.reminder_sent_at IS NOT NULL),
+		       COUNT(*) FILTER (WHERE ac.status = 'skipped'),
+		       COUNT(*) FILTER (WHERE ac.coupon_id IS NOT NULL),
+		       COUNT(*) FILTER (WHERE ac.recovered_order_id IS NOT NULL),
+		       COALESCE(SUM(o.total_amount), 0),
+		       COUNT(*) FILTER (WHERE ac.recovered_at >= ac.reminder_sent_at)
+		FROM abandoned_carts ac
+		LEFT JOIN orders o ON o.id = ac.recovered_order_id
+		WHERE ac.detected_at >= $1 AND ac.detected_at < $2
+	`
+
+	report := models.AbandonedCartReport{From: from, To: to}
+	err := r.db.QueryRow(ctx, query, from, to).Scan(
+		&report.Abandoned,
+		&report.AbandonedValue,
+		&report.Reminded,
+		&report.Skipped,
+		&report.CouponsIssued,
+		&report.Recovered,
+		&report.RecoveredValue,
+		&report.RecoveredAfterReminder,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+// markCartRecoveredTx attributes an order to the user's latest abandonment
+// that is not recovered yet, if it is recent enough.
+func markCartRecoveredTx(ctx context.Context, tx pgx.Tx, userID, orderID int) error {
+	query := `
+		UPDATE abandoned_carts
+		SET recovered_order_id = $2, recovered_at = CURRENT_TIMESTAMP
+		WHERE id = (
+			SELECT id FROM abandoned_carts
+			WHERE user_id = $1 AND recovered_order_id IS NULL AND detected_at >= $3
+			ORDER BY detected_at DESC
+			LIMIT 1
+		)
+	`
+
+	_, err := tx.Exec(ctx, query, userID, orderID, time.Now().Add(-abandonedCartRecoveryWindow))
+	return err
+}
+
+func scanAbandonedCart(row pgx.Row) (*models.AbandonedCart, error) {
+	var cart models.AbandonedCart
+	err := row.Scan(
+		&cart.ID,
+		&cart.UserID,
+		&cart.LastActivityAt,
+		&cart.ItemCount,
+		&cart.CartValue,
+		&cart.Status,
+		&cart.SkipReason,
+		&cart.ReminderSentAt,
+		&cart.CouponID,
+		&cart.RecoveredOrderID,
+		&cart.RecoveredAt,
+		&cart.DetectedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &cart, nil
+}
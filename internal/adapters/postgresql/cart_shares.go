@@ -0,0 +1,106 @@
+package postgresql
+
+import (
+	"context"
+	"time"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+func (r *Repository) CreateCartShare(ctx context.Context, userID int, token string, items []models.CartShareItem, expiresAt time.Time) (*models.CartShare, error) {
+	query := `
+		INSERT INTO cart_shares (token, user_id, items, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, token, user_id, items, expires_at, created_at
+	`
+
+	return scanCartShare(r.db.QueryRow(ctx, query, token, userID, items, expiresAt))
+}
+
+func (r *Repository) GetCartShareByToken(ctx context.Context, token string) (*models.CartShare, error) {
+	query := `
+		SELECT id, token, user_id, items, expires_at, created_at
+		FROM cart_shares
+		WHERE token = $1
+	`
+
+	return scanCartShare(r.db.QueryRow(ctx, query, token))
+}
+
+// DeleteCartShare deletes one of the user's share links. It returns false
+// when the user has no link with that token.
+func (r *Repository) DeleteCartShare(ctx context.Context, token string, userID int) (bool, error) {
+	query := `DELETE FROM cart_shares WHERE token = $1 AND user_id = $2`
+
+	tag, err := r.db.Exec(ctx, query, token, userID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// AddItemsToCart puts the items in the user's cart in one transaction,
+// replacing the quantity and price of products already in it.
+func (r *Repository) AddItemsToCart(ctx context.Context, userID int, items []models.CartItem) error {
+	return r.addItems(ctx, "user_id", userID, items)
+}
+
+// AddItemsToGuestCart is AddItemsToCart for a guest cart.
+func (r *Repository) AddItemsToGuestCart(ctx context.Context, guestToken string, items []models.CartItem) error {
+	return r.addItems(ctx, "guest_token", guestToken, items)
+}
+
+func (r *Repository) addItems(ctx context.Context, column string, owner interface{}, items []models.CartItem) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := upsertCartItems(ctx, tx, column, owner, items); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// upsertCartItems puts the items in the cart whose owner column is column,
+// replacing the quantity and price of products already in it.
+func upsertCartItems(ctx context.Context, tx pgx.Tx, column string, owner interface{}, items []models.CartItem) error {
+	query := `
+		INSERT INTO cart_items (` + column + `, product_id, quantity, added_price)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (` + column + `, product_id)
+		DO UPDATE SET
+			quantity = EXCLUDED.quantity,
+			added_price = EXCLUDED.added_price,
+			updated_at = CURRENT_TIMESTAMP
+	`
+
+	for _, item := range items {
+		if _, err := tx.Exec(ctx, query, owner, item.ProductID, item.Quantity, item.AddedPrice); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func scanCartShare(row pgx.Row) (*models.CartShare, error) {
+	var share models.CartShare
+	err := row.Scan(
+		&share.ID,
+		&share.Token,
+		&share.UserID,
+		&share.Items,
+		&share.ExpiresAt,
+		&share.CreatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &share, nil
+}
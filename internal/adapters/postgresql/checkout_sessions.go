@@ -0,0 +1,98 @@
+package postgresql
+
+import (
+	"context"
+	"time"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+const checkoutSessionColumns = `id, user_id, status, shipping_address, billing_address, shipping_method, discount_code,
+	subtotal, shipping_cost, discount_amount, tax_amount, total_amount, order_id, expires_at, created_at, updated_at`
+
+func (r *Repository) CreateCheckoutSession(ctx context.Context, userID int, expiresAt time.Time) (*models.CheckoutSession, error) {
+	query := `
+		INSERT INTO checkout_sessions (user_id, expires_at)
+		VALUES ($1, $2)
+		RETURNING ` + checkoutSessionColumns
+
+	return scanCheckoutSession(r.db.QueryRow(ctx, query, userID, expiresAt))
+}
+
+func (r *Repository) GetCheckoutSession(ctx context.Context, id int) (*models.CheckoutSession, error) {
+	query := `SELECT ` + checkoutSessionColumns + ` FROM checkout_sessions WHERE id = $1`
+	return scanCheckoutSession(r.db.QueryRow(ctx, query, id))
+}
+
+// UpdateCheckoutSession saves the addresses, selections and totals of a
+// session. Status changes go through TransitionCheckoutSession instead.
+func (r *Repository) UpdateCheckoutSession(ctx context.Context, session *models.CheckoutSession) error {
+	query := `
+		UPDATE checkout_sessions
+		SET shipping_address = $2, billing_address = $3, shipping_method = $4, discount_code = $5,
+		    subtotal = $6, shipping_cost = $7, discount_amount = $8, tax_amount = $9, total_amount = $10
+		WHERE id = $1
+	`
+
+	_, err := r.db.Exec(ctx, query,
+		session.ID,
+		session.ShippingAddress,
+		session.BillingAddress,
+		session.ShippingMethod,
+		session.DiscountCode,
+		session.Subtotal,
+		session.ShippingCost,
+		session.DiscountAmount,
+		session.TaxAmount,
+		session.TotalAmount,
+	)
+	return err
+}
+
+// TransitionCheckoutSession moves a session from one status to another. It
+// returns false when the session is no longer in the expected status, so two
+// concurrent requests cannot both act on the same step.
+func (r *Repository) TransitionCheckoutSession(ctx context.Context, id int, from, to string) (bool, error) {
+	query := `UPDATE checkout_sessions SET status = $3 WHERE id = $1 AND status = $2`
+
+	tag, err := r.db.Exec(ctx, query, id, from, to)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *Repository) CompleteCheckoutSession(ctx context.Context, id, orderID int) error {
+	query := `UPDATE checkout_sessions SET status = 'completed', order_id = $2 WHERE id = $1`
+	_, err := r.db.Exec(ctx, query, id, orderID)
+	return err
+}
+
+func scanCheckoutSession(row pgx.Row) (*models.CheckoutSession, error) {
+	var session models.CheckoutSession
+	err := row.Scan(
+		&session.ID,
+		&session.UserID,
+		&session.Status,
+		&session.ShippingAddress,
+		&session.BillingAddress,
+		&session.ShippingMethod,
+		&session.DiscountCode,
+		&session.Subtotal,
+		&session.ShippingCost,
+		&session.DiscountAmount,
+		&session.TaxAmount,
+		&session.TotalAmount,
+		&session.OrderID,
+		&session.ExpiresAt,
+		&session.CreatedAt,
+		&session.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
@@ -0,0 +1,146 @@
+package postgresql
+
+import (
+	"context"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+)
+
+func (r *Repository) AddToGuestCart(ctx context.Context, guestToken string, req models.AddToCartRequest, addedPrice float64) (*models.CartItem, error) {
+	query := `
+		INSERT INTO cart_items (guest_token, product_id, quantity, added_price)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (guest_token, product_id)
+		DO UPDATE SET
+			quantity = cart_items.quantity + $3,
+			added_price = $4,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING id, product_id, quantity, added_price, created_at, updated_at
+	`
+
+	var cartItem models.CartItem
+	err := r.db.QueryRow(ctx, query, guestToken, req.ProductID, req.Quantity, addedPrice).Scan(
+		&cartItem.ID,
+		&cartItem.ProductID,
+		&cartItem.Quantity,
+		&cartItem.AddedPrice,
+		&cartItem.CreatedAt,
+		&cartItem.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cartItem.UnitPrice = cartItem.AddedPrice
+	product, err := r.GetProductByID(ctx, req.ProductID)
+	if err == nil {
+		cartItem.Product = product
+		cartItem.ListPrice = product.Price
+	}
+
+	return &cartItem, nil
+}
+
+func (r *Repository) GetGuestCartItems(ctx context.Context, guestToken string) ([]models.CartItem, error) {
+	query := `
+		SELECT ci.id, ci.product_id, ci.quantity, ci.added_price, ci.created_at, ci.updated_at,
+		       p.id, p.name, p.description, p.price, p.stock_quantity, p.category_id, p.sku, p.image_url, p.weight_kg, p.length_cm, p.width_cm, p.height_cm, p.is_active, p.created_at, p.updated_at
+		FROM cart_items ci
+		JOIN products p ON ci.product_id = p.id
+		WHERE ci.guest_token = $1
+		ORDER BY ci.created_at DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, guestToken)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cartItems []models.CartItem
+	for rows.Next() {
+		var cartItem models.CartItem
+		var product models.Product
+		err := rows.Scan(
+			&cartItem.ID,
+			&cartItem.ProductID,
+			&cartItem.Quantity,
+			&cartItem.AddedPrice,
+			&cartItem.CreatedAt,
+			&cartItem.UpdatedAt,
+			&product.ID,
+			&product.Name,
+			&product.Description,
+			&product.Price,
+			&product.StockQuantity,
+			&product.CategoryID,
+			&product.SKU,
+			&product.ImageURL,
+			&product.WeightKg,
+			&product.LengthCm,
+			&product.WidthCm,
+			&product.HeightCm,
+			&product.IsActive,
+			&product.CreatedAt,
+			&product.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		cartItem.ListPrice = product.Price
+		cartItem.Product = &product
+		cartItems = append(cartItems, cartItem)
+	}
+
+	return cartItems, nil
+}
+
+func (r *Repository) UpdateGuestCartItem(ctx context.Context, guestToken string, productID int, quantity int, addedPrice float64) error {
+	query := `
+		UPDATE cart_items
+		SET quantity = $3, added_price = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE guest_token = $1 AND product_id = $2
+	`
+
+	_, err := r.db.Exec(ctx, query, guestToken, productID, quantity, addedPrice)
+	return err
+}
+
+func (r *Repository) RemoveFromGuestCart(ctx context.Context, guestToken string, productID int) error {
+	query := `DELETE FROM cart_items WHERE guest_token = $1 AND product_id = $2`
+	_, err := r.db.Exec(ctx, query, guestToken, productID)
+	return err
+}
+
+func (r *Repository) ClearGuestCart(ctx context.Context, guestToken string) error {
+	query := `DELETE FROM cart_items WHERE guest_token = $1`
+	_, err := r.db.Exec(ctx, query, guestToken)
+	return err
+}
+
+// AcknowledgeGuestCartChanges is AcknowledgeCartChanges for a guest cart.
+func (r *Repository) AcknowledgeGuestCartChanges(ctx context.Context, guestToken string, items []models.CartItem) error {
+	return r.acknowledgeCartChanges(ctx, "guest_token", guestToken, items)
+}
+
+// MergeGuestCart moves a guest cart into the user's cart in one transaction:
+// the items, worked out from both carts, are put in the user's cart and the
+// guest cart is deleted.
+func (r *Repository) MergeGuestCart(ctx context.Context, guestToken string, userID int, items []models.CartItem) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := upsertCartItems(ctx, tx, "user_id", userID, items); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM cart_items WHERE guest_token = $1`, guestToken); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,263 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+const invoiceColumns = `id, order_id, invoice_number, order_number, seller, buyer, lines, tax_lines, subtotal, discount_amount,
+	shipping_amount, tax_amount, total_amount, pdf, rendered_at, issued_at`
+
+const creditNoteColumns = `cn.id, cn.invoice_id, i.invoice_number, cn.refund_id, cn.credit_note_number, cn.lines, cn.amount, cn.reason,
+	cn.pdf, cn.rendered_at, cn.issued_at`
+
+// CreateInvoice issues the order's invoice with the next number of the year.
+// An order has one invoice: if it already has one, that one is returned.
+func (r *Repository) CreateInvoice(ctx context.Context, invoice models.Invoice) (*models.Invoice, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	lockQuery := `SELECT id FROM orders WHERE id = $1 FOR UPDATE`
+	if _, err := tx.Exec(ctx, lockQuery, invoice.OrderID); err != nil {
+		return nil, err
+	}
+
+	existingQuery := `SELECT ` + invoiceColumns + ` FROM invoices WHERE order_id = $1`
+	existing, err := scanInvoice(tx.QueryRow(ctx, existingQuery, invoice.OrderID))
+	if err == nil {
+		return existing, nil
+	}
+	if err != pgx.ErrNoRows {
+		return nil, err
+	}
+
+	number, err := nextDocumentNumber(ctx, tx, "invoice", "INV")
+	if err != nil {
+		return nil, err
+	}
+
+	taxLines := invoice.TaxLines
+	if taxLines == nil {
+		taxLines = []models.TaxLine{}
+	}
+
+	query := `
+		INSERT INTO invoices (order_id, invoice_number, order_number, seller, buyer, lines, tax_lines, subtotal,
+		                      discount_amount, shipping_amount, tax_amount, total_amount)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING ` + invoiceColumns
+
+	created, err := scanInvoice(tx.QueryRow(ctx, query,
+		invoice.OrderID,
+		number,
+		invoice.OrderNumber,
+		invoice.Seller,
+		invoice.Buyer,
+		invoice.Lines,
+		taxLines,
+		invoice.Subtotal,
+		invoice.DiscountAmount,
+		invoice.ShippingAmount,
+		invoice.TaxAmount,
+		invoice.TotalAmount,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (r *Repository) GetInvoiceByOrderID(ctx context.Context, orderID int) (*models.Invoice, error) {
+	query := `
+		SELECT ` + invoiceColumns + `
+		FROM invoices
+		WHERE order_id = $1
+	`
+
+	return scanInvoice(r.db.QueryRow(ctx, query, orderID))
+}
+
+func (r *Repository) SaveInvoicePDF(ctx context.Context, id int, pdf []byte) error {
+	query := `UPDATE invoices SET pdf = $2, rendered_at = CURRENT_TIMESTAMP WHERE id = $1`
+	_, err := r.db.Exec(ctx, query, id, pdf)
+	return err
+}
+
+// CreateCreditNote issues a credit note for a refund against its invoice,
+// with the next credit note number of the year. A refund has one credit
+// note: if it already has one, that one is returned.
+func (r *Repository) CreateCreditNote(ctx context.Context, note models.CreditNote) (*models.CreditNote, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	lockQuery := `SELECT id FROM invoices WHERE id = $1 FOR UPDATE`
+	if _, err := tx.Exec(ctx, lockQuery, note.InvoiceID); err != nil {
+		return nil, err
+	}
+
+	existingQuery := `
+		SELECT ` + creditNoteColumns + `
+		FROM credit_notes cn
+		JOIN invoices i ON cn.invoice_id = i.id
+		WHERE cn.refund_id = $1
+	`
+	existing, err := scanCreditNote(tx.QueryRow(ctx, existingQuery, note.RefundID))
+	if err == nil {
+		return existing, nil
+	}
+	if err != pgx.ErrNoRows {
+		return nil, err
+	}
+
+	number, err := nextDocumentNumber(ctx, tx, "credit_note", "CN")
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		WITH cn AS (
+			INSERT INTO credit_notes (invoice_id, refund_id, credit_note_number, lines, amount, reason)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING *
+		)
+		SELECT ` + creditNoteColumns + `
+		FROM cn
+		JOIN invoices i ON cn.invoice_id = i.id
+	`
+
+	created, err := scanCreditNote(tx.QueryRow(ctx, query,
+		note.InvoiceID,
+		note.RefundID,
+		number,
+		note.Lines,
+		note.Amount,
+		note.Reason,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (r *Repository) GetCreditNotesByInvoiceID(ctx context.Context, invoiceID int) ([]models.CreditNote, error) {
+	query := `
+		SELECT ` + creditNoteColumns + `
+		FROM credit_notes cn
+		JOIN invoices i ON cn.invoice_id = i.id
+		WHERE cn.invoice_id = $1
+		ORDER BY cn.issued_at, cn.id
+	`
+
+	rows, err := r.db.Query(ctx, query, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notes []models.CreditNote
+	for rows.Next() {
+		note, err := scanCreditNote(rows)
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, *note)
+	}
+
+	return notes, nil
+}
+
+func (r *Repository) SaveCreditNotePDF(ctx context.Context, id int, pdf []byte) error {
+	query := `UPDATE credit_notes SET pdf = $2, rendered_at = CURRENT_TIMESTAMP WHERE id = $1`
+	_, err := r.db.Exec(ctx, query, id, pdf)
+	return err
+}
+
+// nextDocumentNumber takes the next number of the series for the current
+// year, such as INV-2024-000042. The series' counter stays locked until tx
+// ends, so numbers are handed out in order and a rolled back document gives
+// its number back.
+func nextDocumentNumber(ctx context.Context, tx pgx.Tx, series, prefix string) (string, error) {
+	query := `
+		INSERT INTO document_sequences (series, year, last_number)
+		VALUES ($1, EXTRACT(YEAR FROM CURRENT_TIMESTAMP AT TIME ZONE 'UTC')::int, 1)
+		ON CONFLICT (series, year) DO UPDATE SET last_number = document_sequences.last_number + 1
+		RETURNING year, last_number
+	`
+
+	var year, number int
+	if err := tx.QueryRow(ctx, query, series).Scan(&year, &number); err != nil {
+		return "", fmt.Errorf("failed to number %s: %w", series, err)
+	}
+
+	return fmt.Sprintf("%s-%d-%06d", prefix, year, number), nil
+}
+
+func scanInvoice(row pgx.Row) (*models.Invoice, error) {
+	var invoice models.Invoice
+	err := row.Scan(
+		&invoice.ID,
+		&invoice.OrderID,
+		&invoice.Number,
+		&invoice.OrderNumber,
+		&invoice.Seller,
+		&invoice.Buyer,
+		&invoice.Lines,
+		&invoice.TaxLines,
+		&invoice.Subtotal,
+		&invoice.DiscountAmount,
+		&invoice.ShippingAmount,
+		&invoice.TaxAmount,
+		&invoice.TotalAmount,
+		&invoice.PDF,
+		&invoice.RenderedAt,
+		&invoice.IssuedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &invoice, nil
+}
+
+func scanCreditNote(row pgx.Row) (*models.CreditNote, error) {
+	var note models.CreditNote
+	err := row.Scan(
+		&note.ID,
+		&note.InvoiceID,
+		&note.InvoiceNumber,
+		&note.RefundID,
+		&note.Number,
+		&note.Lines,
+		&note.Amount,
+		&note.Reason,
+		&note.PDF,
+		&note.RenderedAt,
+		&note.IssuedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &note, nil
+}
@@ -0,0 +1,105 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+const orderEventColumns = `id, order_id, event_type, actor_id, actor_role, message, metadata, internal, created_at`
+
+func (r *Repository) AddOrderEvent(ctx context.Context, event models.OrderEvent) (*models.OrderEvent, error) {
+	return insertOrderEvent(ctx, r.db, event)
+}
+
+// GetOrderEvents returns the order's timeline, oldest first. Internal events
+// are left out unless includeInternal is set.
+func (r *Repository) GetOrderEvents(ctx context.Context, orderID int, includeInternal bool) ([]models.OrderEvent, error) {
+	query := `
+		SELECT ` + orderEventColumns + `
+		FROM order_events
+		WHERE order_id = $1 AND ($2 OR internal = false)
+		ORDER BY created_at, id
+	`
+
+	rows, err := r.db.Query(ctx, query, orderID, includeInternal)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []models.OrderEvent
+	for rows.Next() {
+		event, err := scanOrderEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, *event)
+	}
+
+	return events, nil
+}
+
+// insertOrderEvent records an event on an order, inside a transaction when q
+// is one.
+func insertOrderEvent(ctx context.Context, q rowQuerier, event models.OrderEvent) (*models.OrderEvent, error) {
+	query := `
+		INSERT INTO order_events (order_id, event_type, actor_id, actor_role, message, metadata, internal)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + orderEventColumns
+
+	metadata := event.Metadata
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+
+	return scanOrderEvent(q.QueryRow(ctx, query,
+		event.OrderID,
+		event.Type,
+		event.Actor.ID,
+		event.Actor.Role,
+		event.Message,
+		metadata,
+		event.Internal,
+	))
+}
+
+// paymentEvent is the timeline entry for a payment reaching its current
+// status.
+func paymentEvent(payment *models.Payment, actor models.OrderActor) models.OrderEvent {
+	return models.OrderEvent{
+		OrderID: payment.OrderID,
+		Type:    models.OrderEventPayment,
+		Actor:   actor,
+		Message: fmt.Sprintf("Payment %s", payment.PaymentStatus),
+		Metadata: map[string]interface{}{
+			"payment_id":     payment.ID,
+			"payment_method": payment.PaymentMethod,
+			"status":         payment.PaymentStatus,
+			"amount":         payment.Amount,
+		},
+	}
+}
+
+func scanOrderEvent(row pgx.Row) (*models.OrderEvent, error) {
+	var event models.OrderEvent
+	err := row.Scan(
+		&event.ID,
+		&event.OrderID,
+		&event.Type,
+		&event.Actor.ID,
+		&event.Actor.Role,
+		&event.Message,
+		&event.Metadata,
+		&event.Internal,
+		&event.CreatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
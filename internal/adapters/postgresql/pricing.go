@@ -0,0 +1,275 @@
+package postgresql
+
+import (
+	"context"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+)
+
+// GetProductPricing returns the pricing inputs of the products for a member
+// of the customer group; groupID nil leaves out group prices and breaks.
+// Products that do not exist are left out.
+func (r *Repository) GetProductPricing(ctx context.Context, productIDs []int, groupID *int) ([]models.ProductPricing, error) {
+	productQuery := `
+		SELECT p.id, p.price, pli.price
+		FROM products p
+		LEFT JOIN price_list_items pli ON pli.product_id = p.id AND pli.customer_group_id = $2
+		WHERE p.id = ANY($1)
+	`
+
+	rows, err := r.db.Query(ctx, productQuery, productIDs, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pricing []models.ProductPricing
+	index := make(map[int]int)
+	for rows.Next() {
+		var product models.ProductPricing
+		if err := rows.Scan(&product.ProductID, &product.ListPrice, &product.GroupPrice); err != nil {
+			return nil, err
+		}
+		index[product.ProductID] = len(pricing)
+		pricing = append(pricing, product)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	breaks, err := r.queryPriceBreaks(ctx, `
+		SELECT id, product_id, customer_group_id, min_quantity, discount_percent
+		FROM product_price_breaks
+		WHERE product_id = ANY($1) AND (customer_group_id IS NULL OR customer_group_id = $2)
+		ORDER BY customer_group_id NULLS FIRST, min_quantity
+	`, productIDs, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, priceBreak := range breaks {
+		if i, ok := index[priceBreak.ProductID]; ok {
+			pricing[i].Breaks = append(pricing[i].Breaks, priceBreak)
+		}
+	}
+
+	return pricing, nil
+}
+
+func (r *Repository) CreateCustomerGroup(ctx context.Context, req models.CreateCustomerGroupRequest) (*models.CustomerGroup, error) {
+	query := `
+		INSERT INTO customer_groups (name, description)
+		VALUES ($1, $2)
+		RETURNING id, name, description, created_at, updated_at
+	`
+
+	var group models.CustomerGroup
+	err := r.db.QueryRow(ctx, query, req.Name, req.Description).Scan(
+		&group.ID,
+		&group.Name,
+		&group.Description,
+		&group.CreatedAt,
+		&group.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &group, nil
+}
+
+func (r *Repository) GetCustomerGroups(ctx context.Context) ([]models.CustomerGroup, error) {
+	query := `
+		SELECT id, name, description, created_at, updated_at
+		FROM customer_groups
+		ORDER BY name
+	`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []models.CustomerGroup
+	for rows.Next() {
+		var group models.CustomerGroup
+		err := rows.Scan(
+			&group.ID,
+			&group.Name,
+			&group.Description,
+			&group.CreatedAt,
+			&group.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+
+	return groups, nil
+}
+
+func (r *Repository) GetCustomerGroupByID(ctx context.Context, id int) (*models.CustomerGroup, error) {
+	query := `
+		SELECT id, name, description, created_at, updated_at
+		FROM customer_groups
+		WHERE id = $1
+	`
+
+	var group models.CustomerGroup
+	err := r.db.QueryRow(ctx, query, id).Scan(
+		&group.ID,
+		&group.Name,
+		&group.Description,
+		&group.CreatedAt,
+		&group.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &group, nil
+}
+
+func (r *Repository) SetUserCustomerGroup(ctx context.Context, userID int, groupID *int) error {
+	query := `UPDATE users SET customer_group_id = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`
+	_, err := r.db.Exec(ctx, query, userID, groupID)
+	return err
+}
+
+func (r *Repository) GetPriceList(ctx context.Context, groupID int) ([]models.PriceListItem, error) {
+	query := `
+		SELECT customer_group_id, product_id, price
+		FROM price_list_items
+		WHERE customer_group_id = $1
+		ORDER BY product_id
+	`
+
+	rows, err := r.db.Query(ctx, query, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.PriceListItem
+	for rows.Next() {
+		var item models.PriceListItem
+		if err := rows.Scan(&item.CustomerGroupID, &item.ProductID, &item.Price); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// SetPriceListItems adds or updates entries on a group's price list. Products
+// not mentioned keep their current entry.
+func (r *Repository) SetPriceListItems(ctx context.Context, groupID int, entries []models.PriceListEntry) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO price_list_items (customer_group_id, product_id, price)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (customer_group_id, product_id)
+		DO UPDATE SET price = EXCLUDED.price
+	`
+
+	for _, entry := range entries {
+		if _, err := tx.Exec(ctx, query, groupID, entry.ProductID, entry.Price); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *Repository) RemovePriceListItem(ctx context.Context, groupID, productID int) error {
+	query := `DELETE FROM price_list_items WHERE customer_group_id = $1 AND product_id = $2`
+	_, err := r.db.Exec(ctx, query, groupID, productID)
+	return err
+}
+
+func (r *Repository) GetPriceBreaks(ctx context.Context, productID int) ([]models.PriceBreak, error) {
+	query := `
+		SELECT id, product_id, customer_group_id, min_quantity, discount_percent
+		FROM product_price_breaks
+		WHERE product_id = $1
+		ORDER BY customer_group_id NULLS FIRST, min_quantity
+	`
+
+	return r.queryPriceBreaks(ctx, query, productID)
+}
+
+// GetPublicPriceBreaks returns the product's price breaks open to a member of
+// the customer group: those tied to no group and those of the group. groupID
+// nil returns only the former.
+func (r *Repository) GetPublicPriceBreaks(ctx context.Context, productID int, groupID *int) ([]models.PriceBreak, error) {
+	query := `
+		SELECT id, product_id, customer_group_id, min_quantity, discount_percent
+		FROM product_price_breaks
+		WHERE product_id = $1 AND (customer_group_id IS NULL OR customer_group_id = $2)
+		ORDER BY customer_group_id NULLS FIRST, min_quantity
+	`
+
+	return r.queryPriceBreaks(ctx, query, productID, groupID)
+}
+
+func (r *Repository) queryPriceBreaks(ctx context.Context, query string, args ...interface{}) ([]models.PriceBreak, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var breaks []models.PriceBreak
+	for rows.Next() {
+		var priceBreak models.PriceBreak
+		err := rows.Scan(
+			&priceBreak.ID,
+			&priceBreak.ProductID,
+			&priceBreak.CustomerGroupID,
+			&priceBreak.MinQuantity,
+			&priceBreak.DiscountPercent,
+		)
+		if err != nil {
+			return nil, err
+		}
+		breaks = append(breaks, priceBreak)
+	}
+
+	return breaks, nil
+}
+
+// SetPriceBreaks replaces all quantity price breaks of a product.
+func (r *Repository) SetPriceBreaks(ctx context.Context, productID int, breaks []models.PriceBreakRequest) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM product_price_breaks WHERE product_id = $1`, productID); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO product_price_breaks (product_id, customer_group_id, min_quantity, discount_percent)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	for _, priceBreak := range breaks {
+		if _, err := tx.Exec(ctx, query, productID, priceBreak.CustomerGroupID, priceBreak.MinQuantity, priceBreak.DiscountPercent); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
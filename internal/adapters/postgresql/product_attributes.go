@@ -0,0 +1,52 @@
+package postgresql
+
+import (
+	"context"
+)
+
+func (r *Repository) GetProductAttributes(ctx context.Context, productID int) (map[string]string, error) {
+	query := `
+		SELECT name, value
+		FROM product_attributes
+		WHERE product_id = $1
+		ORDER BY name
+	`
+
+	rows, err := r.db.Query(ctx, query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attributes := make(map[string]string)
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, err
+		}
+		attributes[name] = value
+	}
+
+	return attributes, nil
+}
+
+func (r *Repository) SetProductAttributes(ctx context.Context, productID int, attributes map[string]string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM product_attributes WHERE product_id = $1`, productID); err != nil {
+		return err
+	}
+
+	for name, value := range attributes {
+		query := `INSERT INTO product_attributes (product_id, name, value) VALUES ($1, $2, $3)`
+		if _, err := tx.Exec(ctx, query, productID, name, value); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
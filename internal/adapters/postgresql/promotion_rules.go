@@ -0,0 +1,180 @@
+package postgresql
+
+import (
+	"context"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+const promotionRuleColumns = `id, name, description, rule_type, priority, stackable, product_ids, category_ids, min_subtotal,
+	buy_quantity, get_quantity, discount_value, tiers, starts_at, ends_at, is_active, created_at, updated_at`
+
+func (r *Repository) CreatePromotionRule(ctx context.Context, rule models.PromotionRule) (*models.PromotionRule, error) {
+	if rule.ProductIDs == nil {
+		rule.ProductIDs = []int{}
+	}
+	if rule.CategoryIDs == nil {
+		rule.CategoryIDs = []int{}
+	}
+	if rule.Tiers == nil {
+		rule.Tiers = []models.PromotionTier{}
+	}
+
+	query := `
+		INSERT INTO promotion_rules (name, description, rule_type, priority, stackable, product_ids, category_ids, min_subtotal,
+		                             buy_quantity, get_quantity, discount_value, tiers, starts_at, ends_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING ` + promotionRuleColumns
+
+	return scanPromotionRule(r.db.QueryRow(ctx, query,
+		rule.Name,
+		rule.Description,
+		rule.RuleType,
+		rule.Priority,
+		rule.Stackable,
+		rule.ProductIDs,
+		rule.CategoryIDs,
+		rule.MinSubtotal,
+		rule.BuyQuantity,
+		rule.GetQuantity,
+		rule.DiscountValue,
+		rule.Tiers,
+		rule.StartsAt,
+		rule.EndsAt,
+	))
+}
+
+func (r *Repository) GetPromotionRules(ctx context.Context) ([]models.PromotionRule, error) {
+	query := `SELECT ` + promotionRuleColumns + ` FROM promotion_rules ORDER BY priority DESC, id`
+	return r.queryPromotionRules(ctx, query)
+}
+
+// GetActivePromotionRules returns the rules that are switched on and inside
+// their date window, highest priority first.
+func (r *Repository) GetActivePromotionRules(ctx context.Context) ([]models.PromotionRule, error) {
+	query := `
+		SELECT ` + promotionRuleColumns + `
+		FROM promotion_rules
+		WHERE is_active = true
+		  AND (starts_at IS NULL OR starts_at <= CURRENT_TIMESTAMP)
+		  AND (ends_at IS NULL OR ends_at > CURRENT_TIMESTAMP)
+		ORDER BY priority DESC, id
+	`
+	return r.queryPromotionRules(ctx, query)
+}
+
+func (r *Repository) DeactivatePromotionRule(ctx context.Context, id int) error {
+	query := `UPDATE promotion_rules SET is_active = false WHERE id = $1`
+	_, err := r.db.Exec(ctx, query, id)
+	return err
+}
+
+func (r *Repository) queryPromotionRules(ctx context.Context, query string) ([]models.PromotionRule, error) {
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []models.PromotionRule
+	for rows.Next() {
+		rule, err := scanPromotionRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, *rule)
+	}
+
+	return rules, nil
+}
+
+func scanPromotionRule(row pgx.Row) (*models.PromotionRule, error) {
+	var rule models.PromotionRule
+	err := row.Scan(
+		&rule.ID,
+		&rule.Name,
+		&rule.Description,
+		&rule.RuleType,
+		&rule.Priority,
+		&rule.Stackable,
+		&rule.ProductIDs,
+		&rule.CategoryIDs,
+		&rule.MinSubtotal,
+		&rule.BuyQuantity,
+		&rule.GetQuantity,
+		&rule.DiscountValue,
+		&rule.Tiers,
+		&rule.StartsAt,
+		&rule.EndsAt,
+		&rule.IsActive,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &rule, nil
+}
+
+func insertOrderDiscountsTx(ctx context.Context, tx pgx.Tx, orderID int, discounts []models.DiscountLine) error {
+	query := `
+		INSERT INTO order_discounts (order_id, source, code, coupon_id, rule_id, description, amount, free_shipping)
+		VALUES ($1, $2, $3, NULLIF($4, 0), NULLIF($5, 0), $6, $7, $8)
+	`
+
+	for _, discount := range discounts {
+		_, err := tx.Exec(ctx, query,
+			orderID,
+			discount.Source,
+			discount.Code,
+			discount.CouponID,
+			discount.RuleID,
+			discount.Description,
+			discount.Amount,
+			discount.FreeShipping,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Repository) getOrderDiscounts(ctx context.Context, orderID int) ([]models.DiscountLine, error) {
+	query := `
+		SELECT source, code, COALESCE(coupon_id, 0), COALESCE(rule_id, 0), description, amount, free_shipping
+		FROM order_discounts
+		WHERE order_id = $1
+		ORDER BY id
+	`
+
+	rows, err := r.db.Query(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var discounts []models.DiscountLine
+	for rows.Next() {
+		var discount models.DiscountLine
+		err := rows.Scan(
+			&discount.Source,
+			&discount.Code,
+			&discount.CouponID,
+			&discount.RuleID,
+			&discount.Description,
+			&discount.Amount,
+			&discount.FreeShipping,
+		)
+		if err != nil {
+			return nil, err
+		}
+		discounts = append(discounts, discount)
+	}
+
+	return discounts, nil
+}
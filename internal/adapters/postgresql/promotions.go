@@ -0,0 +1,190 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+const couponColumns = `id, code, description, discount_type, discount_value, min_subtotal, product_ids, category_ids,
+	starts_at, ends_at, usage_limit, per_user_limit, is_active, created_at, updated_at`
+
+func (r *Repository) CreateCoupon(ctx context.Context, req models.CreateCouponRequest) (*models.Coupon, error) {
+	productIDs := req.ProductIDs
+	if productIDs == nil {
+		productIDs = []int{}
+	}
+	categoryIDs := req.CategoryIDs
+	if categoryIDs == nil {
+		categoryIDs = []int{}
+	}
+
+	query := `
+		INSERT INTO coupons (code, description, discount_type, discount_value, min_subtotal, product_ids, category_ids,
+		                     starts_at, ends_at, usage_limit, per_user_limit)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING ` + couponColumns
+
+	return scanCoupon(r.db.QueryRow(ctx, query,
+		req.Code,
+		req.Description,
+		req.DiscountType,
+		req.DiscountValue,
+		req.MinSubtotal,
+		productIDs,
+		categoryIDs,
+		req.StartsAt,
+		req.EndsAt,
+		req.UsageLimit,
+		req.PerUserLimit,
+	))
+}
+
+func (r *Repository) GetCoupons(ctx context.Context) ([]models.Coupon, error) {
+	query := `SELECT ` + couponColumns + ` FROM coupons ORDER BY created_at DESC`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var coupons []models.Coupon
+	for rows.Next() {
+		coupon, err := scanCoupon(rows)
+		if err != nil {
+			return nil, err
+		}
+		coupons = append(coupons, *coupon)
+	}
+
+	return coupons, nil
+}
+
+func (r *Repository) GetCouponByCode(ctx context.Context, code string) (*models.Coupon, error) {
+	query := `SELECT ` + couponColumns + ` FROM coupons WHERE code = $1`
+	return scanCoupon(r.db.QueryRow(ctx, query, code))
+}
+
+func (r *Repository) DeactivateCoupon(ctx context.Context, id int) error {
+	query := `UPDATE coupons SET is_active = false WHERE id = $1`
+	_, err := r.db.Exec(ctx, query, id)
+	return err
+}
+
+// CountCouponRedemptions returns how often a coupon has been redeemed in
+// total and by the given user.
+func (r *Repository) CountCouponRedemptions(ctx context.Context, couponID, userID int) (int, int, error) {
+	return countCouponRedemptions(ctx, r.db, couponID, userID)
+}
+
+// GetCartCoupon returns the coupon applied to the user's cart, or
+// pgx.ErrNoRows when there is none.
+func (r *Repository) GetCartCoupon(ctx context.Context, userID int) (*models.Coupon, error) {
+	query := `
+		SELECT ` + couponColumns + `
+		FROM coupons
+		WHERE id = (SELECT coupon_id FROM cart_coupons WHERE user_id = $1)
+	`
+
+	coupon, err := scanCoupon(r.db.QueryRow(ctx, query, userID))
+	if err == pgx.ErrNoRows {
+		return nil, models.ErrNoCartCoupon
+	}
+	return coupon, err
+}
+
+func (r *Repository) SetCartCoupon(ctx context.Context, userID, couponID int) error {
+	query := `
+		INSERT INTO cart_coupons (user_id, coupon_id)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id)
+		DO UPDATE SET coupon_id = EXCLUDED.coupon_id, applied_at = CURRENT_TIMESTAMP
+	`
+	_, err := r.db.Exec(ctx, query, userID, couponID)
+	return err
+}
+
+func (r *Repository) RemoveCartCoupon(ctx context.Context, userID int) error {
+	query := `DELETE FROM cart_coupons WHERE user_id = $1`
+	_, err := r.db.Exec(ctx, query, userID)
+	return err
+}
+
+// redeemCouponTx records a redemption for an order being created in tx. The
+// coupon row is locked while the usage limits are rechecked, so concurrent
+// orders cannot push a coupon past its limits.
+func redeemCouponTx(ctx context.Context, tx pgx.Tx, couponID, userID, orderID int, amount float64) error {
+	var usageLimit, perUserLimit *int
+	lockQuery := `SELECT usage_limit, per_user_limit FROM coupons WHERE id = $1 AND is_active = true FOR UPDATE`
+	if err := tx.QueryRow(ctx, lockQuery, couponID).Scan(&usageLimit, &perUserLimit); err != nil {
+		return fmt.Errorf("coupon is no longer available: %w", err)
+	}
+
+	total, byUser, err := countCouponRedemptions(ctx, tx, couponID, userID)
+	if err != nil {
+		return err
+	}
+
+	if usageLimit != nil && total >= *usageLimit {
+		return fmt.Errorf("coupon usage limit reached")
+	}
+
+	if perUserLimit != nil && byUser >= *perUserLimit {
+		return fmt.Errorf("coupon already used the maximum number of times")
+	}
+
+	redeemQuery := `
+		INSERT INTO coupon_redemptions (coupon_id, user_id, order_id, discount_amount)
+		VALUES ($1, $2, $3, $4)
+	`
+	if _, err := tx.Exec(ctx, redeemQuery, couponID, userID, orderID, amount); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `DELETE FROM cart_coupons WHERE user_id = $1`, userID)
+	return err
+}
+
+func countCouponRedemptions(ctx context.Context, q rowQuerier, couponID, userID int) (int, int, error) {
+	query := `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE user_id = $2)
+		FROM coupon_redemptions
+		WHERE coupon_id = $1
+	`
+
+	var total, byUser int
+	if err := q.QueryRow(ctx, query, couponID, userID).Scan(&total, &byUser); err != nil {
+		return 0, 0, err
+	}
+	return total, byUser, nil
+}
+
+func scanCoupon(row pgx.Row) (*models.Coupon, error) {
+	var coupon models.Coupon
+	err := row.Scan(
+		&coupon.ID,
+		&coupon.Code,
+		&coupon.Description,
+		&coupon.DiscountType,
+		&coupon.DiscountValue,
+		&coupon.MinSubtotal,
+		&coupon.ProductIDs,
+		&coupon.CategoryIDs,
+		&coupon.StartsAt,
+		&coupon.EndsAt,
+		&coupon.UsageLimit,
+		&coupon.PerUserLimit,
+		&coupon.IsActive,
+		&coupon.CreatedAt,
+		&coupon.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &coupon, nil
+}
@@ -0,0 +1,287 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+func (r *Repository) CreateQuestion(ctx context.Context, productID, userID int, body string) (*models.ProductQuestion, error) {
+	query := `
+		INSERT INTO product_questions (product_id, user_id, body)
+		VALUES ($1, $2, $3)
+		RETURNING id, product_id, user_id, body, status, created_at, updated_at
+	`
+
+	var question models.ProductQuestion
+	err := r.db.QueryRow(ctx, query, productID, userID, body).Scan(
+		&question.ID,
+		&question.ProductID,
+		&question.UserID,
+		&question.Body,
+		&question.Status,
+		&question.CreatedAt,
+		&question.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &question, nil
+}
+
+func (r *Repository) GetQuestionByID(ctx context.Context, id int) (*models.ProductQuestion, error) {
+	query := `
+		SELECT id, product_id, user_id, body, status, created_at, updated_at
+		FROM product_questions
+		WHERE id = $1
+	`
+
+	var question models.ProductQuestion
+	err := r.db.QueryRow(ctx, query, id).Scan(
+		&question.ID,
+		&question.ProductID,
+		&question.UserID,
+		&question.Body,
+		&question.Status,
+		&question.CreatedAt,
+		&question.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &question, nil
+}
+
+func (r *Repository) GetQuestions(ctx context.Context, filter models.QuestionFilter) ([]models.ProductQuestion, error) {
+	query := `
+		SELECT q.id, q.product_id, q.user_id, q.body, q.status, q.created_at, q.updated_at,
+		       u.id, u.first_name, u.last_name
+		FROM product_questions q
+		JOIN users u ON q.user_id = u.id
+		WHERE 1=1
+	`
+	args := []interface{}{}
+	argIndex := 1
+
+	if filter.ProductID != nil {
+		query += fmt.Sprintf(" AND q.product_id = $%d", argIndex)
+		args = append(args, *filter.ProductID)
+		argIndex++
+	}
+
+	if filter.Status != "" {
+		query += fmt.Sprintf(" AND q.status = $%d", argIndex)
+		args = append(args, filter.Status)
+		argIndex++
+	}
+
+	if filter.Search != "" {
+		query += fmt.Sprintf(` AND (q.body ILIKE $%d OR EXISTS (
+			SELECT 1 FROM product_answers a WHERE a.question_id = q.id AND a.body ILIKE $%d
+		))`, argIndex, argIndex)
+		args = append(args, "%"+filter.Search+"%")
+		argIndex++
+	}
+
+	query += " ORDER BY q.created_at DESC"
+
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", argIndex)
+		args = append(args, filter.Limit)
+		argIndex++
+	}
+
+	if filter.Page > 0 && filter.Limit > 0 {
+		offset := (filter.Page - 1) * filter.Limit
+		query += fmt.Sprintf(" OFFSET $%d", argIndex)
+		args = append(args, offset)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var questions []models.ProductQuestion
+	for rows.Next() {
+		var question models.ProductQuestion
+		var author models.QuestionAuthor
+		err := rows.Scan(
+			&question.ID,
+			&question.ProductID,
+			&question.UserID,
+			&question.Body,
+			&question.Status,
+			&question.CreatedAt,
+			&question.UpdatedAt,
+			&author.ID,
+			&author.FirstName,
+			&author.LastName,
+		)
+		if err != nil {
+			return nil, err
+		}
+		question.Author = &author
+		questions = append(questions, question)
+	}
+
+	return questions, nil
+}
+
+func (r *Repository) UpdateQuestionStatus(ctx context.Context, id int, status string) error {
+	query := `
+		UPDATE product_questions
+		SET status = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`
+
+	_, err := r.db.Exec(ctx, query, id, status)
+	return err
+}
+
+func (r *Repository) CreateAnswer(ctx context.Context, answer models.ProductAnswer) (*models.ProductAnswer, error) {
+	query := `
+		INSERT INTO product_answers (question_id, user_id, body, is_admin, is_verified_buyer)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, question_id, user_id, body, is_admin, is_verified_buyer, upvotes, created_at, updated_at
+	`
+
+	var created models.ProductAnswer
+	err := r.db.QueryRow(ctx, query,
+		answer.QuestionID,
+		answer.UserID,
+		answer.Body,
+		answer.IsAdmin,
+		answer.IsVerifiedBuyer,
+	).Scan(
+		&created.ID,
+		&created.QuestionID,
+		&created.UserID,
+		&created.Body,
+		&created.IsAdmin,
+		&created.IsVerifiedBuyer,
+		&created.Upvotes,
+		&created.CreatedAt,
+		&created.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+func (r *Repository) GetAnswersByQuestionIDs(ctx context.Context, questionIDs []int) ([]models.ProductAnswer, error) {
+	query := `
+		SELECT a.id, a.question_id, a.user_id, a.body, a.is_admin, a.is_verified_buyer, a.upvotes, a.created_at, a.updated_at,
+		       u.id, u.first_name, u.last_name
+		FROM product_answers a
+		JOIN users u ON a.user_id = u.id
+		WHERE a.question_id = ANY($1)
+		ORDER BY a.upvotes DESC, a.created_at ASC
+	`
+
+	rows, err := r.db.Query(ctx, query, questionIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var answers []models.ProductAnswer
+	for rows.Next() {
+		var answer models.ProductAnswer
+		var author models.QuestionAuthor
+		err := rows.Scan(
+			&answer.ID,
+			&answer.QuestionID,
+			&answer.UserID,
+			&answer.Body,
+			&answer.IsAdmin,
+			&answer.IsVerifiedBuyer,
+			&answer.Upvotes,
+			&answer.CreatedAt,
+			&answer.UpdatedAt,
+			&author.ID,
+			&author.FirstName,
+			&author.LastName,
+		)
+		if err != nil {
+			return nil, err
+		}
+		answer.Author = &author
+		answers = append(answers, answer)
+	}
+
+	return answers, nil
+}
+
+// UpvoteAnswer records the user's vote and bumps the counter. It returns
+// false if the user had already upvoted the answer.
+func (r *Repository) UpvoteAnswer(ctx context.Context, answerID, userID int) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	answerQuery := `
+		SELECT a.id
+		FROM product_answers a
+		JOIN product_questions q ON a.question_id = q.id
+		WHERE a.id = $1 AND q.status = 'approved'
+	`
+	if err := tx.QueryRow(ctx, answerQuery, answerID).Scan(&answerID); err != nil {
+		if err == pgx.ErrNoRows {
+			return false, models.ErrAnswerNotFound
+		}
+		return false, err
+	}
+
+	voteQuery := `
+		INSERT INTO product_answer_votes (answer_id, user_id)
+		VALUES ($1, $2)
+		ON CONFLICT (answer_id, user_id) DO NOTHING
+	`
+
+	tag, err := tx.Exec(ctx, voteQuery, answerID, userID)
+	if err != nil {
+		return false, err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE product_answers SET upvotes = upvotes + 1 WHERE id = $1`, answerID); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *Repository) HasDeliveredOrderForProduct(ctx context.Context, userID, productID int) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM orders o
+			JOIN order_items oi ON oi.order_id = o.id
+			WHERE o.user_id = $1 AND oi.product_id = $2 AND o.status = 'delivered'
+		)
+	`
+
+	var exists bool
+	err := r.db.QueryRow(ctx, query, userID, productID).Scan(&exists)
+	return exists, err
+}
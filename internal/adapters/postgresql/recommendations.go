@@ -0,0 +1,245 @@
+package postgresql
+
+import (
+	"context"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+)
+
+func (r *Repository) RecordProductView(ctx context.Context, userID, productID int) error {
+	query := `
+		INSERT INTO product_views (user_id, product_id)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id, product_id)
+		DO UPDATE SET view_count = product_views.view_count + 1, last_viewed_at = CURRENT_TIMESTAMP
+	`
+
+	_, err := r.db.Exec(ctx, query, userID, productID)
+	return err
+}
+
+func (r *Repository) GetRecentlyViewedProducts(ctx context.Context, userID, limit int) ([]models.ProductView, error) {
+	query := `
+		SELECT v.product_id, v.view_count, v.last_viewed_at,
+		       p.id, p.name, p.description, p.price, p.stock_quantity, p.category_id, p.sku, p.image_url, p.is_active, p.created_at, p.updated_at
+		FROM product_views v
+		JOIN products p ON v.product_id = p.id
+		WHERE v.user_id = $1 AND p.is_active = true
+		ORDER BY v.last_viewed_at DESC
+		LIMIT $2
+	`
+
+	rows, err := r.db.Query(ctx, query, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var views []models.ProductView
+	for rows.Next() {
+		var view models.ProductView
+		var product models.Product
+		err := rows.Scan(
+			&view.ProductID,
+			&view.ViewCount,
+			&view.LastViewedAt,
+			&product.ID,
+			&product.Name,
+			&product.Description,
+			&product.Price,
+			&product.StockQuantity,
+			&product.CategoryID,
+			&product.SKU,
+			&product.ImageURL,
+			&product.IsActive,
+			&product.CreatedAt,
+			&product.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		view.Product = &product
+		views = append(views, view)
+	}
+
+	return views, nil
+}
+
+// RefreshProductCoPurchases rebuilds the co-purchase table from order_items
+// of paid orders. It runs in a single transaction so readers never see a
+// half-built table.
+func (r *Repository) RefreshProductCoPurchases(ctx context.Context) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM product_co_purchases`); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO product_co_purchases (product_id, related_product_id, order_count)
+		SELECT a.product_id, b.product_id, COUNT(DISTINCT a.order_id)
+		FROM order_items a
+		JOIN order_items b ON a.order_id = b.order_id AND a.product_id <> b.product_id
+		JOIN orders o ON o.id = a.order_id
+		WHERE o.status IN ('confirmed', 'partially_shipped', 'shipped', 'delivered')
+		GROUP BY a.product_id, b.product_id
+	`
+
+	if _, err := tx.Exec(ctx, query); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetRecommendedProducts scores active, in-stock products the user has not
+// viewed or bought. Co-purchases with the user's viewed and bought products
+// weigh twice as much as views in the same category.
+func (r *Repository) GetRecommendedProducts(ctx context.Context, userID, limit int) ([]models.RecommendedProduct, error) {
+	query := `
+		WITH seeds AS (
+			SELECT product_id FROM product_views WHERE user_id = $1
+			UNION
+			SELECT oi.product_id
+			FROM order_items oi
+			JOIN orders o ON o.id = oi.order_id
+			WHERE o.user_id = $1
+		),
+		co_scores AS (
+			SELECT c.related_product_id AS product_id, SUM(c.order_count) AS score
+			FROM product_co_purchases c
+			JOIN seeds s ON s.product_id = c.product_id
+			GROUP BY c.related_product_id
+		),
+		category_scores AS (
+			SELECT p.category_id, SUM(v.view_count) AS score
+			FROM product_views v
+			JOIN products p ON p.id = v.product_id
+			WHERE v.user_id = $1
+			GROUP BY p.category_id
+		)
+		SELECT p.id, p.name, p.description, p.price, p.stock_quantity, p.category_id, p.sku, p.image_url, p.is_active, p.created_at, p.updated_at,
+		       (COALESCE(co.score, 0) * 2 + COALESCE(cs.score, 0))::float8 AS score,
+		       CASE WHEN co.score IS NOT NULL THEN 'bought_together' ELSE 'viewed_category' END AS reason
+		FROM products p
+		LEFT JOIN co_scores co ON co.product_id = p.id
+		LEFT JOIN category_scores cs ON cs.category_id = p.category_id
+		WHERE p.is_active = true
+		  AND p.stock_quantity > 0
+		  AND (co.score IS NOT NULL OR cs.score IS NOT NULL)
+		  AND p.id NOT IN (SELECT product_id FROM seeds)
+		ORDER BY score DESC, p.created_at DESC
+		LIMIT $2
+	`
+
+	rows, err := r.db.Query(ctx, query, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var products []models.RecommendedProduct
+	for rows.Next() {
+		var product models.RecommendedProduct
+		err := rows.Scan(
+			&product.ID,
+			&product.Name,
+			&product.Description,
+			&product.Price,
+			&product.StockQuantity,
+			&product.CategoryID,
+			&product.SKU,
+			&product.ImageURL,
+			&product.IsActive,
+			&product.CreatedAt,
+			&product.UpdatedAt,
+			&product.Score,
+			&product.Reason,
+		)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+
+	return products, nil
+}
+
+// GetRelatedProducts ranks other active products in the same category by the
+// number of attributes they share with the product, then by how close their
+// price is.
+func (r *Repository) GetRelatedProducts(ctx context.Context, productID, limit int) ([]models.RecommendedProduct, error) {
+	query := `
+		WITH base AS (
+			SELECT id, category_id, price FROM products WHERE id = $1
+		),
+		shared AS (
+			SELECT b.product_id, COUNT(*) AS shared_count
+			FROM product_attributes a
+			JOIN product_attributes b ON a.name = b.name AND a.value = b.value
+			WHERE a.product_id = $1 AND b.product_id <> $1
+			GROUP BY b.product_id
+		)
+		SELECT p.id, p.name, p.description, p.price, p.stock_quantity, p.category_id, p.sku, p.image_url, p.is_active, p.created_at, p.updated_at,
+		       (COALESCE(s.shared_count, 0) + GREATEST(0, 1 - ABS(p.price - base.price) / NULLIF(base.price, 0)))::float8 AS score
+		FROM products p
+		JOIN base ON p.category_id = base.category_id
+		LEFT JOIN shared s ON s.product_id = p.id
+		WHERE p.id <> base.id AND p.is_active = true
+		ORDER BY score DESC, p.created_at DESC
+		LIMIT $2
+	`
+
+	return r.queryScoredProducts(ctx, query, "related", productID, limit)
+}
+
+func (r *Repository) GetBoughtTogetherProducts(ctx context.Context, productID, limit int) ([]models.RecommendedProduct, error) {
+	query := `
+		SELECT p.id, p.name, p.description, p.price, p.stock_quantity, p.category_id, p.sku, p.image_url, p.is_active, p.created_at, p.updated_at,
+		       c.order_count::float8 AS score
+		FROM product_co_purchases c
+		JOIN products p ON p.id = c.related_product_id
+		WHERE c.product_id = $1 AND p.is_active = true
+		ORDER BY c.order_count DESC, p.id
+		LIMIT $2
+	`
+
+	return r.queryScoredProducts(ctx, query, "bought_together", productID, limit)
+}
+
+func (r *Repository) queryScoredProducts(ctx context.Context, query, reason string, args ...interface{}) ([]models.RecommendedProduct, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var products []models.RecommendedProduct
+	for rows.Next() {
+		product := models.RecommendedProduct{Reason: reason}
+		err := rows.Scan(
+			&product.ID,
+			&product.Name,
+			&product.Description,
+			&product.Price,
+			&product.StockQuantity,
+			&product.CategoryID,
+			&product.SKU,
+			&product.ImageURL,
+			&product.IsActive,
+			&product.CreatedAt,
+			&product.UpdatedAt,
+			&product.Score,
+		)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+
+	return products, nil
+}
@@ -0,0 +1,399 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+const refundColumns = `id, order_id, payment_id, amount, reason, status, provider_reference, failure_reason, created_by,
+	created_at, updated_at`
+
+// CreatePendingRefund records a refund before it is paid out. The payment is
+// locked while the refund is checked against what is left of it, counting
+// refunds still pending, so the refunds of a payment never add up to more
+// than was captured; the refunded quantity of each item is held to the
+// quantity ordered the same way. A refund with no amount takes whatever is
+// left of the payment.
+func (r *Repository) CreatePendingRefund(ctx context.Context, refund models.Refund) (*models.Refund, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var status string
+	var captured, refunded float64
+	paymentQuery := `
+		SELECT payment_status, amount, COALESCE((
+			SELECT SUM(amount) FROM refunds WHERE payment_id = payments.id AND status <> 'failed'
+		), 0)
+		FROM payments
+		WHERE id = $1 AND order_id = $2
+		FOR UPDATE
+	`
+	if err := tx.QueryRow(ctx, paymentQuery, refund.PaymentID, refund.OrderID).Scan(&status, &captured, &refunded); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("payment %d is not part of the order", refund.PaymentID)
+		}
+		return nil, err
+	}
+
+	if status != "completed" {
+		return nil, fmt.Errorf("payment %d has not been captured or is already refunded", refund.PaymentID)
+	}
+
+	remaining := roundCents(captured - refunded)
+	if refund.Amount == 0 {
+		refund.Amount = remaining
+	}
+	if refund.Amount <= 0 || refund.Amount > remaining {
+		return nil, fmt.Errorf("refund exceeds the %.2f left to refund on payment %d", remaining, refund.PaymentID)
+	}
+
+	for _, item := range refund.Items {
+		var available int
+		availableQuery := `
+			SELECT oi.quantity - COALESCE((
+				SELECT SUM(ri.quantity)
+				FROM refund_items ri
+				JOIN refunds rf ON ri.refund_id = rf.id
+				WHERE ri.order_item_id = oi.id AND rf.status <> 'failed'
+			), 0)
+			FROM order_items oi
+			WHERE oi.id = $1 AND oi.order_id = $2
+		`
+		if err := tx.QueryRow(ctx, availableQuery, item.OrderItemID, refund.OrderID).Scan(&available); err != nil {
+			if err == pgx.ErrNoRows {
+				return nil, fmt.Errorf("order item %d is not part of the order", item.OrderItemID)
+			}
+			return nil, err
+		}
+
+		if item.Quantity > available {
+			return nil, fmt.Errorf("only %d of order item %d can be refunded", available, item.OrderItemID)
+		}
+	}
+
+	refundQuery := `
+		INSERT INTO refunds (order_id, payment_id, amount, reason, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + refundColumns
+
+	created, err := scanRefund(tx.QueryRow(ctx, refundQuery,
+		refund.OrderID,
+		refund.PaymentID,
+		refund.Amount,
+		refund.Reason,
+		refund.CreatedBy,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range refund.Items {
+		itemQuery := `
+			INSERT INTO refund_items (refund_id, order_item_id, quantity, amount)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id, refund_id, order_item_id, quantity, amount
+		`
+
+		var refundItem models.RefundItem
+		err := tx.QueryRow(ctx, itemQuery, created.ID, item.OrderItemID, item.Quantity, item.Amount).Scan(
+			&refundItem.ID,
+			&refundItem.RefundID,
+			&refundItem.OrderItemID,
+			&refundItem.Quantity,
+			&refundItem.Amount,
+		)
+		if err != nil {
+			return nil, err
+		}
+		created.Items = append(created.Items, refundItem)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// CompleteRefund records a pending refund as paid out by the payment
+// provider. The payment is marked refunded once nothing is left of it, the
+// order's net paid amount is worked out again and the refund is added to the
+// order's timeline.
+func (r *Repository) CompleteRefund(ctx context.Context, id int, providerReference string, actor models.OrderActor) (*models.Refund, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE refunds
+		SET status = 'completed', provider_reference = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'pending'
+		RETURNING ` + refundColumns
+
+	refund, err := scanRefund(tx.QueryRow(ctx, query, id, providerReference))
+	if err != nil {
+		return nil, err
+	}
+
+	paymentQuery := `
+		UPDATE payments
+		SET payment_status = 'refunded', updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND amount <= (
+			SELECT SUM(amount) FROM refunds WHERE payment_id = $1 AND status = 'completed'
+		)
+	`
+	if _, err := tx.Exec(ctx, paymentQuery, refund.PaymentID); err != nil {
+		return nil, err
+	}
+
+	if err := recomputeNetPaidTx(ctx, tx, refund.OrderID); err != nil {
+		return nil, err
+	}
+
+	metadata := map[string]interface{}{
+		"refund_id":          refund.ID,
+		"payment_id":         refund.PaymentID,
+		"amount":             refund.Amount,
+		"provider_reference": refund.ProviderReference,
+	}
+	if refund.Reason != "" {
+		metadata["reason"] = refund.Reason
+	}
+	if _, err := insertOrderEvent(ctx, tx, models.OrderEvent{
+		OrderID:  refund.OrderID,
+		Type:     models.OrderEventPayment,
+		Actor:    actor,
+		Message:  fmt.Sprintf("Refunded %.2f", refund.Amount),
+		Metadata: metadata,
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return refund, nil
+}
+
+// RecordRefundReference keeps the provider's reference on a pending refund
+// the provider has paid, so it can still be completed if completing it fails.
+func (r *Repository) RecordRefundReference(ctx context.Context, id int, providerReference string) error {
+	query := `
+		UPDATE refunds
+		SET provider_reference = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'pending'
+	`
+
+	_, err := r.db.Exec(ctx, query, id, providerReference)
+	return err
+}
+
+// GetPaidPendingRefunds lists the pending refunds the provider has already
+// paid, oldest first, with their items.
+func (r *Repository) GetPaidPendingRefunds(ctx context.Context) ([]models.Refund, error) {
+	query := `
+		SELECT ` + refundColumns + `
+		FROM refunds
+		WHERE status = 'pending' AND provider_reference <> ''
+		ORDER BY created_at, id
+	`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var refunds []models.Refund
+	var ids []int
+	index := make(map[int]int)
+	for rows.Next() {
+		refund, err := scanRefund(rows)
+		if err != nil {
+			return nil, err
+		}
+		index[refund.ID] = len(refunds)
+		ids = append(ids, refund.ID)
+		refunds = append(refunds, *refund)
+	}
+	rows.Close()
+
+	if len(refunds) == 0 {
+		return refunds, nil
+	}
+
+	itemsQuery := `
+		SELECT id, refund_id, order_item_id, quantity, amount
+		FROM refund_items
+		WHERE refund_id = ANY($1)
+		ORDER BY id
+	`
+
+	itemRows, err := r.db.Query(ctx, itemsQuery, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		var item models.RefundItem
+		if err := itemRows.Scan(&item.ID, &item.RefundID, &item.OrderItemID, &item.Quantity, &item.Amount); err != nil {
+			return nil, err
+		}
+		refund := &refunds[index[item.RefundID]]
+		refund.Items = append(refund.Items, item)
+	}
+
+	return refunds, nil
+}
+
+// FailRefund records that the payment provider did not pay out a pending
+// refund, releasing its amount to be refunded again.
+func (r *Repository) FailRefund(ctx context.Context, id int, reason string) error {
+	query := `
+		UPDATE refunds
+		SET status = 'failed', failure_reason = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'pending'
+	`
+
+	_, err := r.db.Exec(ctx, query, id, reason)
+	return err
+}
+
+func (r *Repository) GetRefundsByOrderID(ctx context.Context, orderID int) ([]models.Refund, error) {
+	query := `
+		SELECT ` + refundColumns + `
+		FROM refunds
+		WHERE order_id = $1
+		ORDER BY created_at, id
+	`
+
+	rows, err := r.db.Query(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var refunds []models.Refund
+	index := make(map[int]int)
+	for rows.Next() {
+		refund, err := scanRefund(rows)
+		if err != nil {
+			return nil, err
+		}
+		index[refund.ID] = len(refunds)
+		refunds = append(refunds, *refund)
+	}
+	rows.Close()
+
+	itemsQuery := `
+		SELECT ri.id, ri.refund_id, ri.order_item_id, ri.quantity, ri.amount
+		FROM refund_items ri
+		JOIN refunds rf ON ri.refund_id = rf.id
+		WHERE rf.order_id = $1
+		ORDER BY ri.id
+	`
+
+	itemRows, err := r.db.Query(ctx, itemsQuery, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		var item models.RefundItem
+		if err := itemRows.Scan(&item.ID, &item.RefundID, &item.OrderItemID, &item.Quantity, &item.Amount); err != nil {
+			return nil, err
+		}
+		refund := &refunds[index[item.RefundID]]
+		refund.Items = append(refund.Items, item)
+	}
+
+	return refunds, nil
+}
+
+// GetRefundedAmounts sums the refunds of each of the order's payments,
+// counting those still pending.
+func (r *Repository) GetRefundedAmounts(ctx context.Context, orderID int) (map[int]float64, error) {
+	query := `
+		SELECT payment_id, SUM(amount)
+		FROM refunds
+		WHERE order_id = $1 AND status <> 'failed'
+		GROUP BY payment_id
+	`
+
+	rows, err := r.db.Query(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	refunded := make(map[int]float64)
+	for rows.Next() {
+		var paymentID int
+		var amount float64
+		if err := rows.Scan(&paymentID, &amount); err != nil {
+			return nil, err
+		}
+		refunded[paymentID] = amount
+	}
+
+	return refunded, nil
+}
+
+// recomputeNetPaidTx sets the order's net paid amount to what is left of its
+// captured payments after refunds. Fully refunded payments count for nothing.
+func recomputeNetPaidTx(ctx context.Context, tx pgx.Tx, orderID int) error {
+	query := `
+		UPDATE orders
+		SET net_paid_amount = COALESCE((
+		        SELECT SUM(p.amount - COALESCE((
+		            SELECT SUM(rf.amount) FROM refunds rf WHERE rf.payment_id = p.id AND rf.status = 'completed'
+		        ), 0))
+		        FROM payments p
+		        WHERE p.order_id = $1 AND p.payment_status = 'completed'
+		    ), 0),
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`
+
+	_, err := tx.Exec(ctx, query, orderID)
+	return err
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+func scanRefund(row pgx.Row) (*models.Refund, error) {
+	var refund models.Refund
+	err := row.Scan(
+		&refund.ID,
+		&refund.OrderID,
+		&refund.PaymentID,
+		&refund.Amount,
+		&refund.Reason,
+		&refund.Status,
+		&refund.ProviderReference,
+		&refund.FailureReason,
+		&refund.CreatedBy,
+		&refund.CreatedAt,
+		&refund.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &refund, nil
+}
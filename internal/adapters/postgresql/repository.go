@@ -3,16 +3,21 @@ package postgresql
 import (
 	"context"
 	"fmt"
+	"math"
 
 	"github.com/VishalHilal/e-commerce-api/internal/models"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// Repository runs its queries on a connection pool, so requests and
+// background jobs can use the database at the same time.
 type Repository struct {
-	db *pgx.Conn
+	db *pgxpool.Pool
 }
 
-func New(db *pgx.Conn) *Repository {
+func New(db *pgxpool.Pool) *Repository {
 	return &Repository{db: db}
 }
 
@@ -20,7 +25,7 @@ func (r *Repository) CreateUser(ctx context.Context, req models.CreateUserReques
 	query := `
 		INSERT INTO users (email, password_hash, first_name, last_name, phone, role)
 		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, email, first_name, last_name, phone, role, created_at, updated_at
+		RETURNING id, email, first_name, last_name, phone, role, customer_group_id, created_at, updated_at
 	`
 
 	var user models.User
@@ -38,6 +43,7 @@ func (r *Repository) CreateUser(ctx context.Context, req models.CreateUserReques
 		&user.LastName,
 		&user.Phone,
 		&user.Role,
+		&user.CustomerGroupID,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -51,7 +57,7 @@ func (r *Repository) CreateUser(ctx context.Context, req models.CreateUserReques
 
 func (r *Repository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
 	query := `
-		SELECT id, email, password_hash, first_name, last_name, phone, role, created_at, updated_at
+		SELECT id, email, password_hash, first_name, last_name, phone, role, customer_group_id, created_at, updated_at
 		FROM users
 		WHERE email = $1
 	`
@@ -65,6 +71,7 @@ func (r *Repository) GetUserByEmail(ctx context.Context, email string) (*models.
 		&user.LastName,
 		&user.Phone,
 		&user.Role,
+		&user.CustomerGroupID,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -78,7 +85,7 @@ func (r *Repository) GetUserByEmail(ctx context.Context, email string) (*models.
 
 func (r *Repository) GetUserByID(ctx context.Context, id int) (*models.User, error) {
 	query := `
-		SELECT id, email, first_name, last_name, phone, role, created_at, updated_at
+		SELECT id, email, first_name, last_name, phone, role, customer_group_id, created_at, updated_at
 		FROM users
 		WHERE id = $1
 	`
@@ -91,6 +98,7 @@ func (r *Repository) GetUserByID(ctx context.Context, id int) (*models.User, err
 		&user.LastName,
 		&user.Phone,
 		&user.Role,
+		&user.CustomerGroupID,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -121,9 +129,9 @@ func (r *Repository) DeleteUser(ctx context.Context, id int) error {
 
 func (r *Repository) CreateProduct(ctx context.Context, req models.CreateProductRequest) (*models.Product, error) {
 	query := `
-		INSERT INTO products (name, description, price, stock_quantity, category_id, sku, image_url)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		RETURNING id, name, description, price, stock_quantity, category_id, sku, image_url, is_active, created_at, updated_at
+		INSERT INTO products (name, description, price, stock_quantity, category_id, sku, image_url, weight_kg, length_cm, width_cm, height_cm)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, name, description, price, stock_quantity, category_id, sku, image_url, weight_kg, length_cm, width_cm, height_cm, is_active, created_at, updated_at
 	`
 
 	var product models.Product
@@ -135,6 +143,10 @@ func (r *Repository) CreateProduct(ctx context.Context, req models.CreateProduct
 		req.CategoryID,
 		req.SKU,
 		req.ImageURL,
+		req.WeightKg,
+		req.LengthCm,
+		req.WidthCm,
+		req.HeightCm,
 	).Scan(
 		&product.ID,
 		&product.Name,
@@ -144,6 +156,10 @@ func (r *Repository) CreateProduct(ctx context.Context, req models.CreateProduct
 		&product.CategoryID,
 		&product.SKU,
 		&product.ImageURL,
+		&product.WeightKg,
+		&product.LengthCm,
+		&product.WidthCm,
+		&product.HeightCm,
 		&product.IsActive,
 		&product.CreatedAt,
 		&product.UpdatedAt,
@@ -158,7 +174,7 @@ func (r *Repository) CreateProduct(ctx context.Context, req models.CreateProduct
 
 func (r *Repository) GetProducts(ctx context.Context, filter models.ProductFilter) ([]models.Product, error) {
 	query := `
-		SELECT id, name, description, price, stock_quantity, category_id, sku, image_url, is_active, created_at, updated_at
+		SELECT id, name, description, price, stock_quantity, category_id, sku, image_url, weight_kg, length_cm, width_cm, height_cm, is_active, created_at, updated_at
 		FROM products
 		WHERE 1=1
 	`
@@ -227,6 +243,10 @@ func (r *Repository) GetProducts(ctx context.Context, filter models.ProductFilte
 			&product.CategoryID,
 			&product.SKU,
 			&product.ImageURL,
+			&product.WeightKg,
+			&product.LengthCm,
+			&product.WidthCm,
+			&product.HeightCm,
 			&product.IsActive,
 			&product.CreatedAt,
 			&product.UpdatedAt,
@@ -242,7 +262,7 @@ func (r *Repository) GetProducts(ctx context.Context, filter models.ProductFilte
 
 func (r *Repository) GetProductByID(ctx context.Context, id int) (*models.Product, error) {
 	query := `
-		SELECT id, name, description, price, stock_quantity, category_id, sku, image_url, is_active, created_at, updated_at
+		SELECT id, name, description, price, stock_quantity, category_id, sku, image_url, weight_kg, length_cm, width_cm, height_cm, is_active, created_at, updated_at
 		FROM products
 		WHERE id = $1
 	`
@@ -257,6 +277,10 @@ func (r *Repository) GetProductByID(ctx context.Context, id int) (*models.Produc
 		&product.CategoryID,
 		&product.SKU,
 		&product.ImageURL,
+		&product.WeightKg,
+		&product.LengthCm,
+		&product.WidthCm,
+		&product.HeightCm,
 		&product.IsActive,
 		&product.CreatedAt,
 		&product.UpdatedAt,
@@ -280,6 +304,10 @@ func (r *Repository) UpdateProduct(ctx context.Context, id int, req models.Updat
 			category_id = COALESCE($6, category_id),
 			image_url = COALESCE($7, image_url),
 			is_active = COALESCE($8, is_active),
+			weight_kg = COALESCE($9, weight_kg),
+			length_cm = COALESCE($10, length_cm),
+			width_cm = COALESCE($11, width_cm),
+			height_cm = COALESCE($12, height_cm),
 			updated_at = CURRENT_TIMESTAMP
 		WHERE id = $1
 	`
@@ -293,6 +321,10 @@ func (r *Repository) UpdateProduct(ctx context.Context, id int, req models.Updat
 		req.CategoryID,
 		req.ImageURL,
 		req.IsActive,
+		req.WeightKg,
+		req.LengthCm,
+		req.WidthCm,
+		req.HeightCm,
 	)
 
 	return err
@@ -304,21 +336,25 @@ func (r *Repository) DeleteProduct(ctx context.Context, id int) error {
 	return err
 }
 
-func (r *Repository) AddToCart(ctx context.Context, userID int, req models.AddToCartRequest) (*models.CartItem, error) {
+func (r *Repository) AddToCart(ctx context.Context, userID int, req models.AddToCartRequest, addedPrice float64) (*models.CartItem, error) {
 	query := `
-		INSERT INTO cart_items (user_id, product_id, quantity)
-		VALUES ($1, $2, $3)
+		INSERT INTO cart_items (user_id, product_id, quantity, added_price)
+		VALUES ($1, $2, $3, $4)
 		ON CONFLICT (user_id, product_id) 
-		DO UPDATE SET quantity = cart_items.quantity + $3, updated_at = CURRENT_TIMESTAMP
-		RETURNING id, user_id, product_id, quantity, created_at, updated_at
+		DO UPDATE SET
+			quantity = cart_items.quantity + $3,
+			added_price = $4,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING id, user_id, product_id, quantity, added_price, created_at, updated_at
 	`
 
 	var cartItem models.CartItem
-	err := r.db.QueryRow(ctx, query, userID, req.ProductID, req.Quantity).Scan(
+	err := r.db.QueryRow(ctx, query, userID, req.ProductID, req.Quantity, addedPrice).Scan(
 		&cartItem.ID,
 		&cartItem.UserID,
 		&cartItem.ProductID,
 		&cartItem.Quantity,
+		&cartItem.AddedPrice,
 		&cartItem.CreatedAt,
 		&cartItem.UpdatedAt,
 	)
@@ -327,9 +363,11 @@ func (r *Repository) AddToCart(ctx context.Context, userID int, req models.AddTo
 		return nil, err
 	}
 
+	cartItem.UnitPrice = cartItem.AddedPrice
 	product, err := r.GetProductByID(ctx, req.ProductID)
 	if err == nil {
 		cartItem.Product = product
+		cartItem.ListPrice = product.Price
 	}
 
 	return &cartItem, nil
@@ -337,8 +375,8 @@ func (r *Repository) AddToCart(ctx context.Context, userID int, req models.AddTo
 
 func (r *Repository) GetCartItems(ctx context.Context, userID int) ([]models.CartItem, error) {
 	query := `
-		SELECT ci.id, ci.user_id, ci.product_id, ci.quantity, ci.created_at, ci.updated_at,
-		       p.id, p.name, p.description, p.price, p.stock_quantity, p.category_id, p.sku, p.image_url, p.is_active, p.created_at, p.updated_at
+		SELECT ci.id, ci.user_id, ci.product_id, ci.quantity, ci.added_price, ci.created_at, ci.updated_at,
+		       p.id, p.name, p.description, p.price, p.stock_quantity, p.category_id, p.sku, p.image_url, p.weight_kg, p.length_cm, p.width_cm, p.height_cm, p.is_active, p.created_at, p.updated_at
 		FROM cart_items ci
 		JOIN products p ON ci.product_id = p.id
 		WHERE ci.user_id = $1
@@ -360,6 +398,7 @@ func (r *Repository) GetCartItems(ctx context.Context, userID int) ([]models.Car
 			&cartItem.UserID,
 			&cartItem.ProductID,
 			&cartItem.Quantity,
+			&cartItem.AddedPrice,
 			&cartItem.CreatedAt,
 			&cartItem.UpdatedAt,
 			&product.ID,
@@ -370,6 +409,10 @@ func (r *Repository) GetCartItems(ctx context.Context, userID int) ([]models.Car
 			&product.CategoryID,
 			&product.SKU,
 			&product.ImageURL,
+			&product.WeightKg,
+			&product.LengthCm,
+			&product.WidthCm,
+			&product.HeightCm,
 			&product.IsActive,
 			&product.CreatedAt,
 			&product.UpdatedAt,
@@ -377,6 +420,7 @@ func (r *Repository) GetCartItems(ctx context.Context, userID int) ([]models.Car
 		if err != nil {
 			return nil, err
 		}
+		cartItem.ListPrice = product.Price
 		cartItem.Product = &product
 		cartItems = append(cartItems, cartItem)
 	}
@@ -384,17 +428,57 @@ func (r *Repository) GetCartItems(ctx context.Context, userID int) ([]models.Car
 	return cartItems, nil
 }
 
-func (r *Repository) UpdateCartItem(ctx context.Context, userID, productID int, quantity int) error {
+func (r *Repository) UpdateCartItem(ctx context.Context, userID, productID int, quantity int, addedPrice float64) error {
 	query := `
 		UPDATE cart_items
-		SET quantity = $3, updated_at = CURRENT_TIMESTAMP
+		SET quantity = $3, added_price = $4, updated_at = CURRENT_TIMESTAMP
 		WHERE user_id = $1 AND product_id = $2
 	`
 
-	_, err := r.db.Exec(ctx, query, userID, productID, quantity)
+	_, err := r.db.Exec(ctx, query, userID, productID, quantity, addedPrice)
 	return err
 }
 
+// AcknowledgeCartChanges replaces a user's cart with the accepted items:
+// products not among them are removed, and the rest take the given quantity
+// and price as added.
+func (r *Repository) AcknowledgeCartChanges(ctx context.Context, userID int, items []models.CartItem) error {
+	return r.acknowledgeCartChanges(ctx, "user_id", userID, items)
+}
+
+// acknowledgeCartChanges applies accepted items to the cart whose owner
+// column is column.
+func (r *Repository) acknowledgeCartChanges(ctx context.Context, column string, owner interface{}, items []models.CartItem) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	productIDs := make([]int, 0, len(items))
+	for _, item := range items {
+		productIDs = append(productIDs, item.ProductID)
+	}
+
+	deleteQuery := `DELETE FROM cart_items WHERE ` + column + ` = $1 AND NOT (product_id = ANY($2))`
+	if _, err := tx.Exec(ctx, deleteQuery, owner, productIDs); err != nil {
+		return err
+	}
+
+	updateQuery := `
+		UPDATE cart_items
+		SET quantity = $3, added_price = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE ` + column + ` = $1 AND product_id = $2
+	`
+	for _, item := range items {
+		if _, err := tx.Exec(ctx, updateQuery, owner, item.ProductID, item.Quantity, item.AddedPrice); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
 func (r *Repository) RemoveFromCart(ctx context.Context, userID, productID int) error {
 	query := `DELETE FROM cart_items WHERE user_id = $1 AND product_id = $2`
 	_, err := r.db.Exec(ctx, query, userID, productID)
@@ -414,60 +498,284 @@ func (r *Repository) CreateOrder(ctx context.Context, req models.CreateOrderRequ
 	}
 	defer tx.Rollback(ctx)
 
+	order, err := r.createOrderTx(ctx, tx, req, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// CreateOrderFromCart creates the order, reserves its stock and removes the
+// ordered products from the user's cart in the same transaction, so the cart
+// is only cleared when the order was placed.
+func (r *Repository) CreateOrderFromCart(ctx context.Context, req models.CreateOrderRequest, userID int) (*models.Order, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	order, err := r.createOrderTx(ctx, tx, req, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := reserveStockTx(ctx, tx, order.ID, req.Items); err != nil {
+		return nil, err
+	}
+
+	if err := clearOrderedCartItems(ctx, tx, req, userID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// CreateOrderWithPayment places an order from the user's cart together with
+// a pending payment for its total in a single transaction; if any step fails
+// nothing is written. The order stays pending until the payment provider
+// confirms the payment through PayOrder.
+func (r *Repository) CreateOrderWithPayment(ctx context.Context, req models.CreateOrderRequest, userID int, paymentMethod string) (*models.Order, *models.Payment, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	order, err := r.createOrderTx(ctx, tx, req, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := reserveStockTx(ctx, tx, order.ID, req.Items); err != nil {
+		return nil, nil, err
+	}
+
+	if err := clearOrderedCartItems(ctx, tx, req, userID); err != nil {
+		return nil, nil, err
+	}
+
+	payment, err := insertPayment(ctx, tx, models.CreatePaymentRequest{
+		OrderID:       order.ID,
+		PaymentMethod: paymentMethod,
+	}, "pending")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	actor := models.OrderActor{ID: &userID, Role: "customer"}
+	if _, err := insertOrderEvent(ctx, tx, paymentEvent(payment, actor)); err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	return order, payment, nil
+}
+
+// CreateReplacementOrder places a free, pending order for items of an
+// existing order, shipped to the same address, and reserves its stock. Each
+// item keeps the list price it had on the original order.
+func (r *Repository) CreateReplacementOrder(ctx context.Context, orderID int, items []models.OrderItemRequest, actor models.OrderActor) (*models.Order, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	orderQuery := `
+		INSERT INTO orders (user_id, order_number, status, total_amount, shipping_method, shipping_cost, discount_amount, tax_amount,
+		                    shipping_address, billing_address)
+		SELECT user_id, $2, 'pending', 0, shipping_method, 0, 0, 0, shipping_address, billing_address
+		FROM orders
+		WHERE id = $1
+		RETURNING ` + orderColumns
+
+	order, err := scanOrder(tx.QueryRow(ctx, orderQuery, orderID, "ORD-"+uuid.New().String()[:8]))
+	if err != nil {
+		return nil, fmt.Errorf("order %d not found: %w", orderID, err)
+	}
+
+	for _, item := range items {
+		itemQuery := `
+			INSERT INTO order_items (order_id, product_id, quantity, unit_price, list_price, total_price, tax_rate, tax_amount)
+			SELECT $1, product_id, $3, 0, list_price, 0, 0, 0
+			FROM order_items
+			WHERE order_id = $4 AND product_id = $2
+			LIMIT 1
+			RETURNING id, order_id, product_id, quantity, unit_price, list_price, total_price, tax_rate, tax_amount
+		`
+
+		var orderItem models.OrderItem
+		err := tx.QueryRow(ctx, itemQuery, order.ID, item.ProductID, item.Quantity, orderID).Scan(
+			&orderItem.ID,
+			&orderItem.OrderID,
+			&orderItem.ProductID,
+			&orderItem.Quantity,
+			&orderItem.UnitPrice,
+			&orderItem.ListPrice,
+			&orderItem.TotalPrice,
+			&orderItem.TaxRate,
+			&orderItem.TaxAmount,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("product %d is not on order %d: %w", item.ProductID, orderID, err)
+		}
+
+		order.OrderItems = append(order.OrderItems, orderItem)
+	}
+
+	if err := reserveStockTx(ctx, tx, order.ID, items); err != nil {
+		return nil, err
+	}
+
+	if _, err := insertOrderEvent(ctx, tx, models.OrderEvent{
+		OrderID:  order.ID,
+		Type:     models.OrderEventStatusChanged,
+		Actor:    actor,
+		Message:  fmt.Sprintf("Replacement order placed for order %d", orderID),
+		Metadata: map[string]interface{}{"to": order.Status, "replaces_order_id": orderID},
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// reserveStockTx takes an order's items out of stock inside tx and marks the
+// order so that cancelling it puts them back. Product rows are locked while
+// stock is checked, so concurrent orders cannot oversell.
+func reserveStockTx(ctx context.Context, tx pgx.Tx, orderID int, items []models.OrderItemRequest) error {
+	quantities := make(map[int]int)
+	for _, item := range items {
+		quantities[item.ProductID] += item.Quantity
+	}
+
+	for productID, quantity := range quantities {
+		var stock int
+		var isActive bool
+		lockQuery := `SELECT stock_quantity, is_active FROM products WHERE id = $1 FOR UPDATE`
+		if err := tx.QueryRow(ctx, lockQuery, productID).Scan(&stock, &isActive); err != nil {
+			return fmt.Errorf("product %d not found: %w", productID, err)
+		}
+
+		if !isActive {
+			return fmt.Errorf("product %d is not available", productID)
+		}
+
+		if stock < quantity {
+			return fmt.Errorf("insufficient stock for product %d", productID)
+		}
+
+		stockQuery := `UPDATE products SET stock_quantity = stock_quantity - $2 WHERE id = $1`
+		if _, err := tx.Exec(ctx, stockQuery, productID, quantity); err != nil {
+			return err
+		}
+	}
+
+	query := `UPDATE orders SET stock_reserved = TRUE WHERE id = $1`
+	_, err := tx.Exec(ctx, query, orderID)
+	return err
+}
+
+func clearOrderedCartItems(ctx context.Context, tx pgx.Tx, req models.CreateOrderRequest, userID int) error {
+	productIDs := make([]int, len(req.Items))
+	for i, item := range req.Items {
+		productIDs[i] = item.ProductID
+	}
+
+	query := `DELETE FROM cart_items WHERE user_id = $1 AND product_id = ANY($2)`
+	_, err := tx.Exec(ctx, query, userID, productIDs)
+	return err
+}
+
+// createOrderTx inserts an order and its items inside tx. It does not touch
+// stock; orders placed from the cart reserve it with reserveStockTx.
+func (r *Repository) createOrderTx(ctx context.Context, tx pgx.Tx, req models.CreateOrderRequest, userID int) (*models.Order, error) {
 	orderNumber := "ORD-" + uuid.New().String()[:8]
 
-	var totalAmount float64
+	quantities := make(map[int]int)
 	for _, item := range req.Items {
-		product, err := r.GetProductByID(ctx, item.ProductID)
-		if err != nil {
-			return nil, fmt.Errorf("product %d not found: %w", item.ProductID, err)
+		quantities[item.ProductID] += item.Quantity
+	}
+
+	listPrices := make(map[int]float64)
+	var totalAmount float64
+	for productID, quantity := range quantities {
+		var listPrice float64
+		priceQuery := `SELECT price FROM products WHERE id = $1`
+		if err := tx.QueryRow(ctx, priceQuery, productID).Scan(&listPrice); err != nil {
+			return nil, fmt.Errorf("product %d not found: %w", productID, err)
 		}
-		totalAmount += float64(item.Quantity) * product.Price
+
+		price, ok := req.UnitPrices[productID]
+		if !ok {
+			return nil, fmt.Errorf("product %d is not priced", productID)
+		}
+
+		listPrices[productID] = listPrice
+		totalAmount += float64(quantity) * price
 	}
 
+	if req.DiscountAmount > totalAmount {
+		req.DiscountAmount = totalAmount
+	}
+	totalAmount += req.ShippingCost - req.DiscountAmount
+
+	tax := req.Tax
+	if tax == nil {
+		tax = &models.TaxBreakdown{}
+	}
+	totalAmount += tax.Added
+
 	orderQuery := `
-		INSERT INTO orders (user_id, order_number, status, total_amount, shipping_address, billing_address)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, user_id, order_number, status, total_amount, shipping_address, billing_address, created_at, updated_at
-	`
+		INSERT INTO orders (user_id, order_number, status, total_amount, shipping_method, shipping_cost, discount_amount, tax_amount,
+		                    shipping_address, billing_address)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING ` + orderColumns
 
-	var order models.Order
-	err = tx.QueryRow(ctx, orderQuery,
+	order, err := scanOrder(tx.QueryRow(ctx, orderQuery,
 		userID,
 		orderNumber,
 		"pending",
 		totalAmount,
+		req.ShippingMethod,
+		req.ShippingCost,
+		req.DiscountAmount,
+		tax.Total,
 		req.ShippingAddress,
 		req.BillingAddress,
-	).Scan(
-		&order.ID,
-		&order.UserID,
-		&order.OrderNumber,
-		&order.Status,
-		&order.TotalAmount,
-		&order.ShippingAddress,
-		&order.BillingAddress,
-		&order.CreatedAt,
-		&order.UpdatedAt,
-	)
-
+	))
 	if err != nil {
 		return nil, err
 	}
 
 	for _, item := range req.Items {
-		product, err := r.GetProductByID(ctx, item.ProductID)
-		if err != nil {
-			return nil, fmt.Errorf("product %d not found: %w", item.ProductID, err)
-		}
-
-		unitPrice := product.Price
+		unitPrice := req.UnitPrices[item.ProductID]
 		totalPrice := float64(item.Quantity) * unitPrice
+		// Tax is worked out per product; split it over repeated lines.
+		itemTax := tax.Items[item.ProductID]
+		itemTax.Amount = math.Round(itemTax.Amount*float64(item.Quantity)/float64(quantities[item.ProductID])*100) / 100
 
 		itemQuery := `
-			INSERT INTO order_items (order_id, product_id, quantity, unit_price, total_price)
-			VALUES ($1, $2, $3, $4, $5)
-			RETURNING id, order_id, product_id, quantity, unit_price, total_price
+			INSERT INTO order_items (order_id, product_id, quantity, unit_price, list_price, total_price, tax_rate, tax_amount)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING id, order_id, product_id, quantity, unit_price, list_price, total_price, tax_rate, tax_amount
 		`
 
 		var orderItem models.OrderItem
@@ -476,14 +784,20 @@ func (r *Repository) CreateOrder(ctx context.Context, req models.CreateOrderRequ
 			item.ProductID,
 			item.Quantity,
 			unitPrice,
+			listPrices[item.ProductID],
 			totalPrice,
+			itemTax.Rate,
+			itemTax.Amount,
 		).Scan(
 			&orderItem.ID,
 			&orderItem.OrderID,
 			&orderItem.ProductID,
 			&orderItem.Quantity,
 			&orderItem.UnitPrice,
+			&orderItem.ListPrice,
 			&orderItem.TotalPrice,
+			&orderItem.TaxRate,
+			&orderItem.TaxAmount,
 		)
 
 		if err != nil {
@@ -493,16 +807,45 @@ func (r *Repository) CreateOrder(ctx context.Context, req models.CreateOrderRequ
 		order.OrderItems = append(order.OrderItems, orderItem)
 	}
 
-	if err := tx.Commit(ctx); err != nil {
+	if err := insertOrderDiscountsTx(ctx, tx, order.ID, req.Discounts); err != nil {
 		return nil, err
 	}
+	order.Discounts = req.Discounts
 
-	return &order, nil
+	if err := insertOrderTaxesTx(ctx, tx, order.ID, tax.Lines); err != nil {
+		return nil, err
+	}
+	order.TaxLines = tax.Lines
+
+	for _, discount := range req.Discounts {
+		if discount.CouponID == 0 {
+			continue
+		}
+		if err := redeemCouponTx(ctx, tx, discount.CouponID, userID, order.ID, discount.Amount); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := insertOrderEvent(ctx, tx, models.OrderEvent{
+		OrderID:  order.ID,
+		Type:     models.OrderEventStatusChanged,
+		Actor:    models.OrderActor{ID: &userID, Role: "customer"},
+		Message:  "Order placed",
+		Metadata: map[string]interface{}{"to": order.Status},
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := markCartRecoveredTx(ctx, tx, userID, order.ID); err != nil {
+		return nil, err
+	}
+
+	return order, nil
 }
 
 func (r *Repository) GetOrdersByUserID(ctx context.Context, userID int) ([]models.Order, error) {
 	query := `
-		SELECT id, user_id, order_number, status, total_amount, shipping_address, billing_address, created_at, updated_at
+		SELECT ` + orderColumns + `
 		FROM orders
 		WHERE user_id = $1
 		ORDER BY created_at DESC
@@ -516,22 +859,11 @@ func (r *Repository) GetOrdersByUserID(ctx context.Context, userID int) ([]model
 
 	var orders []models.Order
 	for rows.Next() {
-		var order models.Order
-		err := rows.Scan(
-			&order.ID,
-			&order.UserID,
-			&order.OrderNumber,
-			&order.Status,
-			&order.TotalAmount,
-			&order.ShippingAddress,
-			&order.BillingAddress,
-			&order.CreatedAt,
-			&order.UpdatedAt,
-		)
+		order, err := scanOrder(rows)
 		if err != nil {
 			return nil, err
 		}
-		orders = append(orders, order)
+		orders = append(orders, *order)
 	}
 
 	return orders, nil
@@ -539,30 +871,18 @@ func (r *Repository) GetOrdersByUserID(ctx context.Context, userID int) ([]model
 
 func (r *Repository) GetOrderByID(ctx context.Context, id int) (*models.Order, error) {
 	query := `
-		SELECT id, user_id, order_number, status, total_amount, shipping_address, billing_address, created_at, updated_at
+		SELECT ` + orderColumns + `
 		FROM orders
 		WHERE id = $1
 	`
 
-	var order models.Order
-	err := r.db.QueryRow(ctx, query, id).Scan(
-		&order.ID,
-		&order.UserID,
-		&order.OrderNumber,
-		&order.Status,
-		&order.TotalAmount,
-		&order.ShippingAddress,
-		&order.BillingAddress,
-		&order.CreatedAt,
-		&order.UpdatedAt,
-	)
-
+	order, err := scanOrder(r.db.QueryRow(ctx, query, id))
 	if err != nil {
 		return nil, err
 	}
 
 	itemsQuery := `
-		SELECT id, order_id, product_id, quantity, unit_price, total_price
+		SELECT id, order_id, product_id, quantity, unit_price, list_price, total_price, tax_rate, tax_amount
 		FROM order_items
 		WHERE order_id = $1
 	`
@@ -581,31 +901,107 @@ func (r *Repository) GetOrderByID(ctx context.Context, id int) (*models.Order, e
 			&item.ProductID,
 			&item.Quantity,
 			&item.UnitPrice,
+			&item.ListPrice,
 			&item.TotalPrice,
+			&item.TaxRate,
+			&item.TaxAmount,
 		)
 		if err != nil {
 			return nil, err
 		}
 		order.OrderItems = append(order.OrderItems, item)
 	}
+	itemRows.Close()
 
-	return &order, nil
+	order.Discounts, err = r.getOrderDiscounts(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	order.TaxLines, err = r.getOrderTaxes(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return order, nil
 }
 
-func (r *Repository) UpdateOrderStatus(ctx context.Context, id int, status string) error {
+// TransitionOrderStatus moves an order from one status to another and
+// applies the side effects that belong in the same transaction: the reason
+// given on cancelling is stored, the change is added to the order's timeline,
+// and cancelling puts any reserved items back in stock and drops shipments
+// that have not left. It returns false when the order is no longer in the from status.
+func (r *Repository) TransitionOrderStatus(ctx context.Context, id int, from string, req models.UpdateOrderStatusRequest, actor models.OrderActor) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
 	query := `
 		UPDATE orders
-		SET status = $2, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $1
+		SET status = $3,
+		    cancellation_reason = $4,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = $2
 	`
 
-	_, err := r.db.Exec(ctx, query, id, status)
-	return err
+	to := req.Status
+	tag, err := tx.Exec(ctx, query, id, from, to, req.CancellationReason)
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() != 1 {
+		return false, nil
+	}
+
+	metadata := map[string]interface{}{"from": from, "to": to}
+	if req.CancellationReason != "" {
+		metadata["reason"] = req.CancellationReason
+	}
+	if _, err := insertOrderEvent(ctx, tx, models.OrderEvent{
+		OrderID:  id,
+		Type:     models.OrderEventStatusChanged,
+		Actor:    actor,
+		Message:  fmt.Sprintf("Order %s", to),
+		Metadata: metadata,
+	}); err != nil {
+		return false, err
+	}
+
+	if to == "cancelled" {
+		restockQuery := `
+			UPDATE products p
+			SET stock_quantity = p.stock_quantity + oi.quantity
+			FROM (
+				SELECT product_id, SUM(quantity) AS quantity
+				FROM order_items
+				WHERE order_id = $1
+				GROUP BY product_id
+			) oi
+			WHERE p.id = oi.product_id
+			  AND EXISTS (SELECT 1 FROM orders WHERE id = $1 AND stock_reserved)
+		`
+		if _, err := tx.Exec(ctx, restockQuery, id); err != nil {
+			return false, err
+		}
+
+		shipmentsQuery := `DELETE FROM shipments WHERE order_id = $1 AND status = 'pending'`
+		if _, err := tx.Exec(ctx, shipmentsQuery, id); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, err
+	}
+
+	return true, nil
 }
 
 func (r *Repository) GetAllOrders(ctx context.Context) ([]models.Order, error) {
 	query := `
-		SELECT id, user_id, order_number, status, total_amount, shipping_address, billing_address, created_at, updated_at
+		SELECT ` + orderColumns + `
 		FROM orders
 		ORDER BY created_at DESC
 	`
@@ -618,22 +1014,11 @@ func (r *Repository) GetAllOrders(ctx context.Context) ([]models.Order, error) {
 
 	var orders []models.Order
 	for rows.Next() {
-		var order models.Order
-		err := rows.Scan(
-			&order.ID,
-			&order.UserID,
-			&order.OrderNumber,
-			&order.Status,
-			&order.TotalAmount,
-			&order.ShippingAddress,
-			&order.BillingAddress,
-			&order.CreatedAt,
-			&order.UpdatedAt,
-		)
+		order, err := scanOrder(rows)
 		if err != nil {
 			return nil, err
 		}
-		orders = append(orders, order)
+		orders = append(orders, *order)
 	}
 
 	return orders, nil
@@ -670,15 +1055,241 @@ func (r *Repository) CreatePayment(ctx context.Context, payment models.CreatePay
 	return &paymentRecord, nil
 }
 
-func (r *Repository) UpdatePaymentStatus(ctx context.Context, paymentID int, status string) error {
+// PayOrder records a completed payment for a pending order and confirms the
+// order in one transaction, with both on the order's timeline. A pending
+// payment placed with the order is completed; otherwise a new one is
+// recorded. It returns false when the order is no longer pending.
+func (r *Repository) PayOrder(ctx context.Context, req models.CreatePaymentRequest, actor models.OrderActor) (*models.Payment, bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback(ctx)
+
+	statusQuery := `
+		UPDATE orders
+		SET status = 'confirmed', updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'pending'
+	`
+	tag, err := tx.Exec(ctx, statusQuery, req.OrderID)
+	if err != nil {
+		return nil, false, err
+	}
+	if tag.RowsAffected() != 1 {
+		return nil, false, nil
+	}
+
+	payment, err := completePendingPayment(ctx, tx, req)
+	if err == pgx.ErrNoRows {
+		payment, err = insertPayment(ctx, tx, req, "completed")
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	events := []models.OrderEvent{
+		paymentEvent(payment, actor),
+		{
+			OrderID:  req.OrderID,
+			Type:     models.OrderEventStatusChanged,
+			Actor:    actor,
+			Message:  "Order confirmed",
+			Metadata: map[string]interface{}{"from": "pending", "to": "confirmed"},
+		},
+	}
+	for _, event := range events {
+		if _, err := insertOrderEvent(ctx, tx, event); err != nil {
+			return nil, false, err
+		}
+	}
+
+	if err := recomputeNetPaidTx(ctx, tx, req.OrderID); err != nil {
+		return nil, false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, err
+	}
+
+	return payment, true, nil
+}
+
+const orderColumns = `id, user_id, order_number, status, total_amount, shipping_method, shipping_cost, discount_amount, tax_amount,
+	net_paid_amount, cancellation_reason, shipping_address, billing_address, created_at, updated_at`
+
+func scanOrder(row pgx.Row) (*models.Order, error) {
+	var order models.Order
+	err := row.Scan(
+		&order.ID,
+		&order.UserID,
+		&order.OrderNumber,
+		&order.Status,
+		&order.TotalAmount,
+		&order.ShippingMethod,
+		&order.ShippingCost,
+		&order.DiscountAmount,
+		&order.TaxAmount,
+		&order.NetPaidAmount,
+		&order.CancellationReason,
+		&order.ShippingAddress,
+		&order.BillingAddress,
+		&order.CreatedAt,
+		&order.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+// rowQuerier is satisfied by both *pgxpool.Pool and pgx.Tx.
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// completePendingPayment marks the order's pending payment completed under
+// the method it was paid with. It returns pgx.ErrNoRows when the order has
+// no pending payment.
+func completePendingPayment(ctx context.Context, tx pgx.Tx, req models.CreatePaymentRequest) (*models.Payment, error) {
+	query := `
+		UPDATE payments
+		SET payment_status = 'completed', payment_method = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = (
+			SELECT id FROM payments
+			WHERE order_id = $1 AND payment_status = 'pending'
+			ORDER BY created_at
+			LIMIT 1
+		)
+		RETURNING id, order_id, payment_method, payment_status, amount, COALESCE(transaction_id, ''), created_at, updated_at
+	`
+
+	var payment models.Payment
+	err := tx.QueryRow(ctx, query, req.OrderID, req.PaymentMethod).Scan(
+		&payment.ID,
+		&payment.OrderID,
+		&payment.PaymentMethod,
+		&payment.PaymentStatus,
+		&payment.Amount,
+		&payment.TransactionID,
+		&payment.CreatedAt,
+		&payment.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &payment, nil
+}
+
+// insertPayment records a payment for the full order total.
+func insertPayment(ctx context.Context, q rowQuerier, payment models.CreatePaymentRequest, status string) (*models.Payment, error) {
+	query := `
+		INSERT INTO payments (order_id, payment_method, payment_status, amount)
+		SELECT id, $2, $3, total_amount FROM orders WHERE id = $1
+		RETURNING id, order_id, payment_method, payment_status, amount, COALESCE(transaction_id, ''), created_at, updated_at
+	`
+
+	var paymentRecord models.Payment
+	err := q.QueryRow(ctx, query,
+		payment.OrderID,
+		payment.PaymentMethod,
+		status,
+	).Scan(
+		&paymentRecord.ID,
+		&paymentRecord.OrderID,
+		&paymentRecord.PaymentMethod,
+		&paymentRecord.PaymentStatus,
+		&paymentRecord.Amount,
+		&paymentRecord.TransactionID,
+		&paymentRecord.CreatedAt,
+		&paymentRecord.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &paymentRecord, nil
+}
+
+func (r *Repository) GetPaymentsByOrderID(ctx context.Context, orderID int) ([]models.Payment, error) {
+	query := `
+		SELECT id, order_id, payment_method, payment_status, amount, COALESCE(transaction_id, ''), created_at, updated_at
+		FROM payments
+		WHERE order_id = $1
+		ORDER BY created_at
+	`
+
+	rows, err := r.db.Query(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payments []models.Payment
+	for rows.Next() {
+		var payment models.Payment
+		err := rows.Scan(
+			&payment.ID,
+			&payment.OrderID,
+			&payment.PaymentMethod,
+			&payment.PaymentStatus,
+			&payment.Amount,
+			&payment.TransactionID,
+			&payment.CreatedAt,
+			&payment.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+
+	return payments, nil
+}
+
+// UpdatePaymentStatus sets the status of a payment, adds the change to its
+// order's timeline and works out the order's net paid amount again.
+func (r *Repository) UpdatePaymentStatus(ctx context.Context, paymentID int, status string, actor models.OrderActor) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
 	query := `
 		UPDATE payments
 		SET payment_status = $2, updated_at = CURRENT_TIMESTAMP
 		WHERE id = $1
+		RETURNING id, order_id, payment_method, payment_status, amount, COALESCE(transaction_id, ''), created_at, updated_at
 	`
 
-	_, err := r.db.Exec(ctx, query, paymentID, status)
-	return err
+	var payment models.Payment
+	err = tx.QueryRow(ctx, query, paymentID, status).Scan(
+		&payment.ID,
+		&payment.OrderID,
+		&payment.PaymentMethod,
+		&payment.PaymentStatus,
+		&payment.Amount,
+		&payment.TransactionID,
+		&payment.CreatedAt,
+		&payment.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, err := insertOrderEvent(ctx, tx, paymentEvent(&payment, actor)); err != nil {
+		return err
+	}
+
+	if err := recomputeNetPaidTx(ctx, tx, payment.OrderID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
 
 func (r *Repository) CreateReview(ctx context.Context, review models.CreateReviewRequest, userID int) (*models.ProductReview, error) {
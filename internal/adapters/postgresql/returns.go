@@ -0,0 +1,416 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+const returnColumns = `id, rma_number, order_id, user_id, status, resolution, label_reference, admin_note, refund_amount,
+	refund_id, exchange_order_id, created_at, updated_at`
+
+// CreateReturn requests a return of some of the user's order items. The
+// order is locked while quantities are checked, so the same items cannot be
+// returned twice by concurrent requests; items on rejected returns can be
+// requested again.
+func (r *Repository) CreateReturn(ctx context.Context, orderID, userID int, rmaNumber string, req models.CreateReturnRequest) (*models.Return, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	lockQuery := `SELECT id FROM orders WHERE id = $1 AND user_id = $2 FOR UPDATE`
+	if err := tx.QueryRow(ctx, lockQuery, orderID, userID).Scan(&orderID); err != nil {
+		return nil, err
+	}
+
+	items := make([]models.ReturnItem, len(req.Items))
+	for i, item := range req.Items {
+		availableQuery := `
+			SELECT oi.product_id, oi.unit_price, oi.quantity - COALESCE((
+				SELECT SUM(ri.quantity)
+				FROM return_items ri
+				JOIN returns r ON ri.return_id = r.id
+				WHERE ri.order_item_id = oi.id AND r.status <> 'rejected'
+			), 0)
+			FROM order_items oi
+			WHERE oi.id = $1 AND oi.order_id = $2
+		`
+
+		var available int
+		err := tx.QueryRow(ctx, availableQuery, item.OrderItemID, orderID).Scan(&items[i].ProductID, &items[i].UnitPrice, &available)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return nil, fmt.Errorf("order item %d is not part of the order", item.OrderItemID)
+			}
+			return nil, err
+		}
+
+		if item.Quantity > available {
+			return nil, fmt.Errorf("only %d of order item %d can be returned", available, item.OrderItemID)
+		}
+
+		items[i].OrderItemID = item.OrderItemID
+		items[i].Quantity = item.Quantity
+		items[i].Reason = item.Reason
+	}
+
+	returnQuery := `
+		INSERT INTO returns (rma_number, order_id, user_id, resolution)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + returnColumns
+
+	ret, err := scanReturn(tx.QueryRow(ctx, returnQuery, rmaNumber, orderID, userID, req.Resolution))
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range items {
+		itemQuery := `
+			INSERT INTO return_items (return_id, order_item_id, quantity, reason)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id, return_id
+		`
+		if err := tx.QueryRow(ctx, itemQuery, ret.ID, items[i].OrderItemID, items[i].Quantity, items[i].Reason).Scan(&items[i].ID, &items[i].ReturnID); err != nil {
+			return nil, err
+		}
+	}
+	ret.Items = items
+
+	actor := models.OrderActor{ID: &userID, Role: "customer"}
+	if err := insertReturnEvent(ctx, tx, ret, actor, map[string]interface{}{"resolution": ret.Resolution}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (r *Repository) GetReturnByID(ctx context.Context, id int) (*models.Return, error) {
+	query := `
+		SELECT ` + returnColumns + `
+		FROM returns
+		WHERE id = $1
+	`
+
+	ret, err := scanReturn(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		return nil, err
+	}
+
+	itemsQuery := `
+		SELECT ri.id, ri.return_id, ri.order_item_id, oi.product_id, ri.quantity, oi.unit_price, ri.reason, ri.condition, ri.restocked
+		FROM return_items ri
+		JOIN order_items oi ON ri.order_item_id = oi.id
+		WHERE ri.return_id = $1
+		ORDER BY ri.id
+	`
+
+	rows, err := r.db.Query(ctx, itemsQuery, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.ReturnItem
+		err := rows.Scan(
+			&item.ID,
+			&item.ReturnID,
+			&item.OrderItemID,
+			&item.ProductID,
+			&item.Quantity,
+			&item.UnitPrice,
+			&item.Reason,
+			&item.Condition,
+			&item.Restocked,
+		)
+		if err != nil {
+			return nil, err
+		}
+		ret.Items = append(ret.Items, item)
+	}
+
+	return ret, nil
+}
+
+func (r *Repository) GetReturnsByUserID(ctx context.Context, userID int) ([]models.Return, error) {
+	query := `
+		SELECT ` + returnColumns + `
+		FROM returns
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`
+
+	return r.queryReturns(ctx, query, userID)
+}
+
+// GetReturns lists all returns, newest first, optionally only those in one
+// status.
+func (r *Repository) GetReturns(ctx context.Context, status string) ([]models.Return, error) {
+	query := `
+		SELECT ` + returnColumns + `
+		FROM returns
+		WHERE $1::text = '' OR status = $1
+		ORDER BY created_at DESC
+	`
+
+	return r.queryReturns(ctx, query, status)
+}
+
+func (r *Repository) queryReturns(ctx context.Context, query string, args ...any) ([]models.Return, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var returns []models.Return
+	for rows.Next() {
+		ret, err := scanReturn(rows)
+		if err != nil {
+			return nil, err
+		}
+		returns = append(returns, *ret)
+	}
+
+	return returns, nil
+}
+
+// ApproveReturn approves a requested return with the reference of the label
+// the customer ships it back with. It returns false when the return is no
+// longer requested.
+func (r *Repository) ApproveReturn(ctx context.Context, id int, labelReference string, actor models.OrderActor) (bool, error) {
+	query := `
+		UPDATE returns
+		SET status = 'approved', label_reference = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'requested'
+		RETURNING ` + returnColumns
+
+	return r.updateReturn(ctx, actor, map[string]interface{}{"label_reference": labelReference}, query, id, labelReference)
+}
+
+// RejectReturn rejects a requested return. It returns false when the return
+// is no longer requested.
+func (r *Repository) RejectReturn(ctx context.Context, id int, note string, actor models.OrderActor) (bool, error) {
+	query := `
+		UPDATE returns
+		SET status = 'rejected', admin_note = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'requested'
+		RETURNING ` + returnColumns
+
+	return r.updateReturn(ctx, actor, nil, query, id, note)
+}
+
+// ReceiveReturn records the goods of an approved return as received with the
+// condition of each item. Resellable items go back in stock and damaged ones
+// are written off. It returns false when the return is no longer approved.
+func (r *Repository) ReceiveReturn(ctx context.Context, id int, inspections []models.ReturnInspection, actor models.OrderActor) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE returns
+		SET status = 'received', updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'approved'
+		RETURNING ` + returnColumns
+
+	ret, err := scanReturn(tx.QueryRow(ctx, query, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	restocked, writtenOff := 0, 0
+	for _, inspection := range inspections {
+		itemQuery := `
+			UPDATE return_items
+			SET condition = $3, restocked = ($3 = 'resellable')
+			WHERE id = $1 AND return_id = $2
+			RETURNING quantity
+		`
+
+		var quantity int
+		if err := tx.QueryRow(ctx, itemQuery, inspection.ReturnItemID, id, inspection.Condition).Scan(&quantity); err != nil {
+			return false, err
+		}
+
+		if inspection.Condition == "resellable" {
+			restocked += quantity
+		} else {
+			writtenOff += quantity
+		}
+	}
+
+	restockQuery := `
+		UPDATE products p
+		SET stock_quantity = p.stock_quantity + ri.quantity
+		FROM (
+			SELECT oi.product_id, SUM(ri.quantity) AS quantity
+			FROM return_items ri
+			JOIN order_items oi ON ri.order_item_id = oi.id
+			WHERE ri.return_id = $1 AND ri.restocked = true
+			GROUP BY oi.product_id
+		) ri
+		WHERE p.id = ri.product_id
+	`
+	if _, err := tx.Exec(ctx, restockQuery, id); err != nil {
+		return false, err
+	}
+
+	metadata := map[string]interface{}{"restocked": restocked, "written_off": writtenOff}
+	if err := insertReturnEvent(ctx, tx, ret, actor, metadata); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ClaimReturnRefund marks a received return as refunding while its refund
+// is paid out. It returns false when the return is no longer received, so
+// only one caller can refund it.
+func (r *Repository) ClaimReturnRefund(ctx context.Context, id int, actor models.OrderActor) (bool, error) {
+	query := `
+		UPDATE returns
+		SET status = 'refunding', updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'received'
+		RETURNING ` + returnColumns
+
+	return r.updateReturn(ctx, actor, nil, query, id)
+}
+
+// ReleaseReturnRefund puts a refunding return back to received after its
+// refund failed, so it can be resolved again. It returns false when the
+// return is no longer refunding.
+func (r *Repository) ReleaseReturnRefund(ctx context.Context, id int, reason string, actor models.OrderActor) (bool, error) {
+	query := `
+		UPDATE returns
+		SET status = 'received', updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'refunding'
+		RETURNING ` + returnColumns
+
+	return r.updateReturn(ctx, actor, map[string]interface{}{"refund_error": reason}, query, id)
+}
+
+// RefundReturn closes a refunding return with the refund paid for its items.
+// It returns false when the return is no longer refunding.
+func (r *Repository) RefundReturn(ctx context.Context, id, refundID int, actor models.OrderActor) (bool, error) {
+	query := `
+		UPDATE returns
+		SET status = 'refunded',
+		    refund_id = $2,
+		    refund_amount = (SELECT amount FROM refunds WHERE id = $2),
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'refunding'
+		RETURNING ` + returnColumns
+
+	return r.updateReturn(ctx, actor, nil, query, id, refundID)
+}
+
+// ExchangeReturn closes a received return with the replacement order placed
+// for its items. It returns false when the return is no longer received.
+func (r *Repository) ExchangeReturn(ctx context.Context, id, exchangeOrderID int, actor models.OrderActor) (bool, error) {
+	query := `
+		UPDATE returns
+		SET status = 'exchanged', exchange_order_id = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'received'
+		RETURNING ` + returnColumns
+
+	metadata := map[string]interface{}{"exchange_order_id": exchangeOrderID}
+	return r.updateReturn(ctx, actor, metadata, query, id, exchangeOrderID)
+}
+
+// updateReturn runs a guarded status update of a return and adds the new
+// status to the order's timeline in one transaction.
+func (r *Repository) updateReturn(ctx context.Context, actor models.OrderActor, metadata map[string]interface{}, query string, args ...any) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	ret, err := scanReturn(tx.QueryRow(ctx, query, args...))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if ret.RefundID != nil {
+		if metadata == nil {
+			metadata = map[string]interface{}{}
+		}
+		metadata["refund_id"] = *ret.RefundID
+		metadata["refund_amount"] = ret.RefundAmount
+	}
+
+	if err := insertReturnEvent(ctx, tx, ret, actor, metadata); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// insertReturnEvent adds a return's new status to its order's timeline.
+func insertReturnEvent(ctx context.Context, tx pgx.Tx, ret *models.Return, actor models.OrderActor, metadata map[string]interface{}) error {
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	metadata["return_id"] = ret.ID
+	metadata["rma_number"] = ret.RMANumber
+	metadata["status"] = ret.Status
+
+	_, err := insertOrderEvent(ctx, tx, models.OrderEvent{
+		OrderID:  ret.OrderID,
+		Type:     models.OrderEventReturn,
+		Actor:    actor,
+		Message:  fmt.Sprintf("Return %s %s", ret.RMANumber, ret.Status),
+		Metadata: metadata,
+	})
+	return err
+}
+
+func scanReturn(row pgx.Row) (*models.Return, error) {
+	var ret models.Return
+	err := row.Scan(
+		&ret.ID,
+		&ret.RMANumber,
+		&ret.OrderID,
+		&ret.UserID,
+		&ret.Status,
+		&ret.Resolution,
+		&ret.LabelReference,
+		&ret.AdminNote,
+		&ret.RefundAmount,
+		&ret.RefundID,
+		&ret.ExchangeOrderID,
+		&ret.CreatedAt,
+		&ret.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
@@ -0,0 +1,165 @@
+package postgresql
+
+import (
+	"context"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+func (r *Repository) GetSavedItems(ctx context.Context, userID int) ([]models.SavedItem, error) {
+	query := `
+		SELECT si.id, si.user_id, si.product_id, si.quantity, si.created_at, si.updated_at,
+		       p.id, p.name, p.description, p.price, p.stock_quantity, p.category_id, p.sku, p.image_url, p.weight_kg, p.length_cm, p.width_cm, p.height_cm, p.is_active, p.created_at, p.updated_at
+		FROM saved_items si
+		JOIN products p ON si.product_id = p.id
+		WHERE si.user_id = $1
+		ORDER BY si.updated_at DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.SavedItem
+	for rows.Next() {
+		var item models.SavedItem
+		var product models.Product
+		err := rows.Scan(
+			&item.ID,
+			&item.UserID,
+			&item.ProductID,
+			&item.Quantity,
+			&item.CreatedAt,
+			&item.UpdatedAt,
+			&product.ID,
+			&product.Name,
+			&product.Description,
+			&product.Price,
+			&product.StockQuantity,
+			&product.CategoryID,
+			&product.SKU,
+			&product.ImageURL,
+			&product.WeightKg,
+			&product.LengthCm,
+			&product.WidthCm,
+			&product.HeightCm,
+			&product.IsActive,
+			&product.CreatedAt,
+			&product.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		item.Product = &product
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func (r *Repository) GetSavedItem(ctx context.Context, userID, productID int) (*models.SavedItem, error) {
+	query := `
+		SELECT id, user_id, product_id, quantity, created_at, updated_at
+		FROM saved_items
+		WHERE user_id = $1 AND product_id = $2
+	`
+
+	var item models.SavedItem
+	err := r.db.QueryRow(ctx, query, userID, productID).Scan(
+		&item.ID,
+		&item.UserID,
+		&item.ProductID,
+		&item.Quantity,
+		&item.CreatedAt,
+		&item.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &item, nil
+}
+
+// SaveForLater moves a product from the user's cart to their saved items,
+// adding to the quantity already saved. It returns false when the product is
+// not in the cart.
+func (r *Repository) SaveForLater(ctx context.Context, userID, productID int) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	var quantity int
+	deleteQuery := `DELETE FROM cart_items WHERE user_id = $1 AND product_id = $2 RETURNING quantity`
+	if err := tx.QueryRow(ctx, deleteQuery, userID, productID).Scan(&quantity); err != nil {
+		if err == pgx.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	saveQuery := `
+		INSERT INTO saved_items (user_id, product_id, quantity)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, product_id)
+		DO UPDATE SET quantity = saved_items.quantity + $3, updated_at = CURRENT_TIMESTAMP
+	`
+	if _, err := tx.Exec(ctx, saveQuery, userID, productID, quantity); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// MoveSavedItemToCart moves a saved product back into the user's cart at
+// addedPrice. It returns false when the product is not saved.
+func (r *Repository) MoveSavedItemToCart(ctx context.Context, userID, productID int, addedPrice float64) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	var quantity int
+	deleteQuery := `DELETE FROM saved_items WHERE user_id = $1 AND product_id = $2 RETURNING quantity`
+	if err := tx.QueryRow(ctx, deleteQuery, userID, productID).Scan(&quantity); err != nil {
+		if err == pgx.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	cartQuery := `
+		INSERT INTO cart_items (user_id, product_id, quantity, added_price)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, product_id)
+		DO UPDATE SET
+			quantity = cart_items.quantity + $3,
+			added_price = $4,
+			updated_at = CURRENT_TIMESTAMP
+	`
+	if _, err := tx.Exec(ctx, cartQuery, userID, productID, quantity, addedPrice); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *Repository) RemoveSavedItem(ctx context.Context, userID, productID int) error {
+	query := `DELETE FROM saved_items WHERE user_id = $1 AND product_id = $2`
+	_, err := r.db.Exec(ctx, query, userID, productID)
+	return err
+}
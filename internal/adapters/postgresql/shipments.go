@@ -0,0 +1,591 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+const shipmentColumns = `id, order_id, status, carrier, tracking_number, shipped_at, delivered_at, created_at, updated_at`
+
+const shipmentLabelColumns = `id, shipment_id, carrier, service, tracking_number, format, cost, data, created_by, created_at`
+
+// CreateShipment packs some of a confirmed order's items into a shipment.
+// The order is locked while quantities are checked, so no item is packed more
+// times than it was ordered. A shipment created with a carrier and tracking
+// number is shipped straight away and the order's status follows.
+func (r *Repository) CreateShipment(ctx context.Context, orderID int, req models.CreateShipmentRequest, actor models.OrderActor) (*models.Shipment, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var status string
+	lockQuery := `SELECT status FROM orders WHERE id = $1 FOR UPDATE`
+	if err := tx.QueryRow(ctx, lockQuery, orderID).Scan(&status); err != nil {
+		return nil, err
+	}
+
+	if status != "confirmed" && status != "partially_shipped" {
+		return nil, fmt.Errorf("a %s order cannot be shipped", status)
+	}
+
+	for _, item := range req.Items {
+		var available int
+		availableQuery := `
+			SELECT oi.quantity - COALESCE((
+				SELECT SUM(si.quantity) FROM shipment_items si WHERE si.order_item_id = oi.id
+			), 0)
+			FROM order_items oi
+			WHERE oi.id = $1 AND oi.order_id = $2
+		`
+		if err := tx.QueryRow(ctx, availableQuery, item.OrderItemID, orderID).Scan(&available); err != nil {
+			if err == pgx.ErrNoRows {
+				return nil, fmt.Errorf("order item %d is not part of the order", item.OrderItemID)
+			}
+			return nil, err
+		}
+
+		if item.Quantity > available {
+			return nil, fmt.Errorf("only %d of order item %d are left to ship", available, item.OrderItemID)
+		}
+	}
+
+	shipped := req.Carrier != "" && req.TrackingNumber != ""
+	shipmentQuery := `
+		INSERT INTO shipments (order_id, status, carrier, tracking_number, shipped_at)
+		VALUES ($1, CASE WHEN $4 THEN 'shipped' ELSE 'pending' END, $2, $3, CASE WHEN $4 THEN CURRENT_TIMESTAMP END)
+		RETURNING ` + shipmentColumns
+
+	shipment, err := scanShipment(tx.QueryRow(ctx, shipmentQuery, orderID, req.Carrier, req.TrackingNumber, shipped))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range req.Items {
+		itemQuery := `
+			INSERT INTO shipment_items (shipment_id, order_item_id, quantity)
+			VALUES ($1, $2, $3)
+			RETURNING id, shipment_id, order_item_id, quantity
+		`
+
+		var shipmentItem models.ShipmentItem
+		err := tx.QueryRow(ctx, itemQuery, shipment.ID, item.OrderItemID, item.Quantity).Scan(
+			&shipmentItem.ID,
+			&shipmentItem.ShipmentID,
+			&shipmentItem.OrderItemID,
+			&shipmentItem.Quantity,
+		)
+		if err != nil {
+			return nil, err
+		}
+		shipment.Items = append(shipment.Items, shipmentItem)
+	}
+
+	if err := insertShipmentEvent(ctx, tx, shipment, actor); err != nil {
+		return nil, err
+	}
+
+	if err := syncOrderStatusTx(ctx, tx, orderID, actor); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return shipment, nil
+}
+
+// ShipShipment sends a pending shipment off with a carrier and tracking
+// number. It returns false when the shipment is no longer pending.
+func (r *Repository) ShipShipment(ctx context.Context, id int, carrier, trackingNumber string, actor models.OrderActor) (bool, error) {
+	query := `
+		UPDATE shipments
+		SET status = 'shipped', carrier = $2, tracking_number = $3, shipped_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'pending'
+		RETURNING ` + shipmentColumns
+
+	return r.updateShipment(ctx, id, actor, query, id, carrier, trackingNumber)
+}
+
+// DeliverShipment records a shipped shipment as delivered. It returns false
+// when the shipment is not shipped.
+func (r *Repository) DeliverShipment(ctx context.Context, id int, actor models.OrderActor) (bool, error) {
+	query := `
+		UPDATE shipments
+		SET status = 'delivered', delivered_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'shipped'
+		RETURNING ` + shipmentColumns
+
+	return r.updateShipment(ctx, id, actor, query, id)
+}
+
+// updateShipment runs a guarded status update of a shipment, adds it to the
+// order's timeline and brings the order's status in line, in one transaction.
+// The shipment's order is locked before the shipment is updated, in the same
+// order CreateShipment and TransitionOrderStatus take their locks.
+func (r *Repository) updateShipment(ctx context.Context, id int, actor models.OrderActor, query string, args ...any) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockShipmentOrderTx(ctx, tx, id); err != nil {
+		if err == pgx.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	shipment, err := scanShipment(tx.QueryRow(ctx, query, args...))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if err := insertShipmentEvent(ctx, tx, shipment, actor); err != nil {
+		return false, err
+	}
+
+	if err := syncOrderStatusTx(ctx, tx, shipment.OrderID, actor); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *Repository) GetShipmentByID(ctx context.Context, id int) (*models.Shipment, error) {
+	query := `
+		SELECT ` + shipmentColumns + `
+		FROM shipments
+		WHERE id = $1
+	`
+
+	shipment, err := scanShipment(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		return nil, err
+	}
+
+	itemsQuery := `
+		SELECT id, shipment_id, order_item_id, quantity
+		FROM shipment_items
+		WHERE shipment_id = $1
+		ORDER BY id
+	`
+
+	rows, err := r.db.Query(ctx, itemsQuery, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.ShipmentItem
+		if err := rows.Scan(&item.ID, &item.ShipmentID, &item.OrderItemID, &item.Quantity); err != nil {
+			return nil, err
+		}
+		shipment.Items = append(shipment.Items, item)
+	}
+
+	return shipment, nil
+}
+
+func (r *Repository) GetShipmentsByOrderID(ctx context.Context, orderID int) ([]models.Shipment, error) {
+	query := `
+		SELECT ` + shipmentColumns + `
+		FROM shipments
+		WHERE order_id = $1
+		ORDER BY created_at, id
+	`
+
+	rows, err := r.db.Query(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var shipments []models.Shipment
+	index := make(map[int]int)
+	for rows.Next() {
+		shipment, err := scanShipment(rows)
+		if err != nil {
+			return nil, err
+		}
+		index[shipment.ID] = len(shipments)
+		shipments = append(shipments, *shipment)
+	}
+	rows.Close()
+
+	itemsQuery := `
+		SELECT si.id, si.shipment_id, si.order_item_id, si.quantity
+		FROM shipment_items si
+		JOIN shipments s ON si.shipment_id = s.id
+		WHERE s.order_id = $1
+		ORDER BY si.id
+	`
+
+	itemRows, err := r.db.Query(ctx, itemsQuery, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		var item models.ShipmentItem
+		if err := itemRows.Scan(&item.ID, &item.ShipmentID, &item.OrderItemID, &item.Quantity); err != nil {
+			return nil, err
+		}
+		shipment := &shipments[index[item.ShipmentID]]
+		shipment.Items = append(shipment.Items, item)
+	}
+
+	return shipments, nil
+}
+
+// GetShipmentByTrackingNumber finds the shipment a carrier's tracking number
+// belongs to.
+func (r *Repository) GetShipmentByTrackingNumber(ctx context.Context, carrier, trackingNumber string) (*models.Shipment, error) {
+	query := `
+		SELECT ` + shipmentColumns + `
+		FROM shipments
+		WHERE carrier = $1 AND tracking_number = $2
+		ORDER BY id DESC
+		LIMIT 1
+	`
+
+	return scanShipment(r.db.QueryRow(ctx, query, carrier, trackingNumber))
+}
+
+// CreateShipmentLabel records a label bought for a pending shipment and gives
+// the shipment the label's carrier and tracking number. A shipment only ever
+// has one label.
+func (r *Repository) CreateShipmentLabel(ctx context.Context, label models.ShipmentLabel, actor models.OrderActor) (*models.ShipmentLabel, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	updateQuery := `
+		UPDATE shipments
+		SET carrier = $2, tracking_number = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'pending'
+		RETURNING ` + shipmentColumns
+
+	shipment, err := scanShipment(tx.QueryRow(ctx, updateQuery, label.ShipmentID, label.Carrier, label.TrackingNumber))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("only pending shipments can be labelled")
+		}
+		return nil, err
+	}
+
+	labelQuery := `
+		INSERT INTO shipment_labels (shipment_id, carrier, service, tracking_number, format, cost, data, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (shipment_id) DO NOTHING
+		RETURNING ` + shipmentLabelColumns
+
+	created, err := scanShipmentLabel(tx.QueryRow(ctx, labelQuery,
+		label.ShipmentID,
+		label.Carrier,
+		label.Service,
+		label.TrackingNumber,
+		label.Format,
+		label.Cost,
+		label.Data,
+		label.CreatedBy,
+	))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("shipment %d already has a label", label.ShipmentID)
+		}
+		return nil, err
+	}
+
+	_, err = insertOrderEvent(ctx, tx, models.OrderEvent{
+		OrderID: shipment.OrderID,
+		Type:    models.OrderEventShipment,
+		Actor:   actor,
+		Message: fmt.Sprintf("Label bought for shipment %d", shipment.ID),
+		Metadata: map[string]interface{}{
+			"shipment_id":     shipment.ID,
+			"carrier":         created.Carrier,
+			"service":         created.Service,
+			"tracking_number": created.TrackingNumber,
+			"cost":            created.Cost,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (r *Repository) GetShipmentLabel(ctx context.Context, shipmentID int) (*models.ShipmentLabel, error) {
+	query := `
+		SELECT ` + shipmentLabelColumns + `
+		FROM shipment_labels
+		WHERE shipment_id = $1
+	`
+
+	return scanShipmentLabel(r.db.QueryRow(ctx, query, shipmentID))
+}
+
+// RecordTrackingEvent adds a carrier's tracking event to the shipment's
+// order timeline and, when status is given, moves the shipment to it:
+// shipped from pending, or delivered from pending or shipped. The order's
+// status follows. It returns false for an event already recorded, as
+// carriers resend their webhooks.
+func (r *Repository) RecordTrackingEvent(ctx context.Context, shipmentID int, event models.TrackingEvent, status string, actor models.OrderActor) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockShipmentOrderTx(ctx, tx, shipmentID); err != nil {
+		return false, err
+	}
+
+	lockQuery := `SELECT ` + shipmentColumns + ` FROM shipments WHERE id = $1 FOR UPDATE`
+	shipment, err := scanShipment(tx.QueryRow(ctx, lockQuery, shipmentID))
+	if err != nil {
+		return false, err
+	}
+
+	eventQuery := `
+		INSERT INTO shipment_tracking_events (shipment_id, event_id, status, description, location, occurred_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (shipment_id, event_id) DO NOTHING
+		RETURNING id
+	`
+
+	var id int
+	err = tx.QueryRow(ctx, eventQuery, shipmentID, event.EventID, event.Status, event.Description, event.Location, event.OccurredAt).Scan(&id)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	var statusQuery string
+	switch status {
+	case "shipped":
+		statusQuery = `
+			UPDATE shipments
+			SET status = 'shipped', shipped_at = $2, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $1 AND status = 'pending'
+		`
+	case "delivered":
+		statusQuery = `
+			UPDATE shipments
+			SET status = 'delivered', shipped_at = COALESCE(shipped_at, $2), delivered_at = $2, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $1 AND status IN ('pending', 'shipped')
+		`
+	}
+	if statusQuery != "" {
+		if _, err := tx.Exec(ctx, statusQuery, shipmentID, event.OccurredAt); err != nil {
+			return false, err
+		}
+	}
+
+	message := event.Description
+	if message == "" {
+		message = event.Status
+	}
+	metadata := map[string]interface{}{
+		"shipment_id":     shipment.ID,
+		"carrier":         shipment.Carrier,
+		"tracking_number": event.TrackingNumber,
+		"tracking_status": event.Status,
+		"occurred_at":     event.OccurredAt,
+	}
+	if event.Location != "" {
+		metadata["location"] = event.Location
+	}
+
+	_, err = insertOrderEvent(ctx, tx, models.OrderEvent{
+		OrderID:  shipment.OrderID,
+		Type:     models.OrderEventShipment,
+		Actor:    actor,
+		Message:  fmt.Sprintf("Shipment %d: %s", shipment.ID, message),
+		Metadata: metadata,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if err := syncOrderStatusTx(ctx, tx, shipment.OrderID, actor); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// lockShipmentOrderTx locks the order a shipment belongs to. Anything that
+// changes a shipment locks its order first, as CreateShipment and
+// TransitionOrderStatus do, so the two rows are always locked in the same
+// order.
+func lockShipmentOrderTx(ctx context.Context, tx pgx.Tx, shipmentID int) error {
+	query := `
+		SELECT o.id
+		FROM orders o
+		JOIN shipments s ON s.order_id = o.id
+		WHERE s.id = $1
+		FOR UPDATE OF o
+	`
+
+	var orderID int
+	return tx.QueryRow(ctx, query, shipmentID).Scan(&orderID)
+}
+
+// syncOrderStatusTx derives the order's status from its shipments: delivered
+// once every item has been delivered, shipped once every item has left, and
+// partially shipped while only some have. A change is added to the order's
+// timeline. The order must be locked by tx.
+func syncOrderStatusTx(ctx context.Context, tx pgx.Tx, orderID int, actor models.OrderActor) error {
+	query := `
+		SELECT o.status,
+		       (SELECT COALESCE(SUM(quantity), 0) FROM order_items WHERE order_id = o.id),
+		       COALESCE(SUM(si.quantity) FILTER (WHERE s.status IN ('shipped', 'delivered')), 0),
+		       COALESCE(SUM(si.quantity) FILTER (WHERE s.status = 'delivered'), 0)
+		FROM orders o
+		LEFT JOIN shipments s ON s.order_id = o.id
+		LEFT JOIN shipment_items si ON si.shipment_id = s.id
+		WHERE o.id = $1
+		GROUP BY o.id
+	`
+
+	var current string
+	var ordered, shipped, delivered int
+	if err := tx.QueryRow(ctx, query, orderID).Scan(&current, &ordered, &shipped, &delivered); err != nil {
+		return err
+	}
+
+	status := shippedOrderStatus(current, ordered, shipped, delivered)
+	if status == current {
+		return nil
+	}
+
+	updateQuery := `UPDATE orders SET status = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`
+	if _, err := tx.Exec(ctx, updateQuery, orderID, status); err != nil {
+		return err
+	}
+
+	_, err := insertOrderEvent(ctx, tx, models.OrderEvent{
+		OrderID:  orderID,
+		Type:     models.OrderEventStatusChanged,
+		Actor:    actor,
+		Message:  fmt.Sprintf("Order %s", status),
+		Metadata: map[string]interface{}{"from": current, "to": status},
+	})
+	return err
+}
+
+// shippedOrderStatus derives an order's status from how many of its items
+// were ordered, have left and have been delivered. Only confirmed orders and
+// those already shipping follow their shipments; others keep their status.
+func shippedOrderStatus(current string, ordered, shipped, delivered int) string {
+	if current != "confirmed" && current != "partially_shipped" && current != "shipped" {
+		return current
+	}
+
+	switch {
+	case ordered > 0 && delivered >= ordered:
+		return "delivered"
+	case ordered > 0 && shipped >= ordered:
+		return "shipped"
+	case shipped > 0:
+		return "partially_shipped"
+	}
+	return current
+}
+
+// insertShipmentEvent adds a shipment's current status to its order's
+// timeline.
+func insertShipmentEvent(ctx context.Context, tx pgx.Tx, shipment *models.Shipment, actor models.OrderActor) error {
+	metadata := map[string]interface{}{
+		"shipment_id": shipment.ID,
+		"status":      shipment.Status,
+	}
+	if shipment.Carrier != "" {
+		metadata["carrier"] = shipment.Carrier
+	}
+	if shipment.TrackingNumber != "" {
+		metadata["tracking_number"] = shipment.TrackingNumber
+	}
+
+	_, err := insertOrderEvent(ctx, tx, models.OrderEvent{
+		OrderID:  shipment.OrderID,
+		Type:     models.OrderEventShipment,
+		Actor:    actor,
+		Message:  fmt.Sprintf("Shipment %d %s", shipment.ID, shipment.Status),
+		Metadata: metadata,
+	})
+	return err
+}
+
+func scanShipment(row pgx.Row) (*models.Shipment, error) {
+	var shipment models.Shipment
+	err := row.Scan(
+		&shipment.ID,
+		&shipment.OrderID,
+		&shipment.Status,
+		&shipment.Carrier,
+		&shipment.TrackingNumber,
+		&shipment.ShippedAt,
+		&shipment.DeliveredAt,
+		&shipment.CreatedAt,
+		&shipment.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &shipment, nil
+}
+
+func scanShipmentLabel(row pgx.Row) (*models.ShipmentLabel, error) {
+	var label models.ShipmentLabel
+	err := row.Scan(
+		&label.ID,
+		&label.ShipmentID,
+		&label.Carrier,
+		&label.Service,
+		&label.TrackingNumber,
+		&label.Format,
+		&label.Cost,
+		&label.Data,
+		&label.CreatedBy,
+		&label.CreatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &label, nil
+}
@@ -0,0 +1,34 @@
+package postgresql
+
+import "testing"
+
+func TestShippedOrderStatus(t *testing.T) {
+	tests := []struct {
+		name                        string
+		current                     string
+		ordered, shipped, delivered int
+		want                        string
+	}{
+		{"nothing shipped", "confirmed", 3, 0, 0, "confirmed"},
+		{"some shipped", "confirmed", 3, 1, 0, "partially_shipped"},
+		{"more shipped", "partially_shipped", 3, 2, 0, "partially_shipped"},
+		{"all shipped", "partially_shipped", 3, 3, 0, "shipped"},
+		{"all shipped at once", "confirmed", 3, 3, 0, "shipped"},
+		{"some delivered", "shipped", 3, 3, 2, "shipped"},
+		{"all delivered", "shipped", 3, 3, 3, "delivered"},
+		{"delivered straight from confirmed", "confirmed", 2, 2, 2, "delivered"},
+		{"some delivered, rest packed", "partially_shipped", 3, 1, 1, "partially_shipped"},
+		{"no items", "confirmed", 0, 0, 0, "confirmed"},
+		{"pending is left alone", "pending", 3, 3, 0, "pending"},
+		{"cancelled is left alone", "cancelled", 3, 1, 0, "cancelled"},
+		{"delivered is left alone", "delivered", 3, 3, 3, "delivered"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shippedOrderStatus(tt.current, tt.ordered, tt.shipped, tt.delivered); got != tt.want {
+				t.Errorf("shippedOrderStatus(%q, %d, %d, %d) = %q, want %q", tt.current, tt.ordered, tt.shipped, tt.delivered, got, tt.want)
+			}
+		})
+	}
+}
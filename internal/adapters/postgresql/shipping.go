@@ -0,0 +1,163 @@
+package postgresql
+
+import (
+	"context"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+const shippingMethodColumns = `id, zone_id, code, name, rate_type, rate, weight_tiers, free_over, volumetric_divisor,
+	is_active, created_at, updated_at`
+
+func (r *Repository) CreateShippingZone(ctx context.Context, req models.CreateShippingZoneRequest) (*models.ShippingZone, error) {
+	if req.Locations == nil {
+		req.Locations = []models.ShippingZoneLocation{}
+	}
+
+	query := `
+		INSERT INTO shipping_zones (name, locations)
+		VALUES ($1, $2)
+		RETURNING id, name, locations, created_at, updated_at
+	`
+
+	return scanShippingZone(r.db.QueryRow(ctx, query, req.Name, req.Locations))
+}
+
+func (r *Repository) GetShippingZones(ctx context.Context) ([]models.ShippingZone, error) {
+	query := `
+		SELECT id, name, locations, created_at, updated_at
+		FROM shipping_zones
+		ORDER BY id
+	`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var zones []models.ShippingZone
+	for rows.Next() {
+		zone, err := scanShippingZone(rows)
+		if err != nil {
+			return nil, err
+		}
+		zones = append(zones, *zone)
+	}
+
+	return zones, nil
+}
+
+func (r *Repository) GetShippingZoneByID(ctx context.Context, id int) (*models.ShippingZone, error) {
+	query := `
+		SELECT id, name, locations, created_at, updated_at
+		FROM shipping_zones
+		WHERE id = $1
+	`
+
+	return scanShippingZone(r.db.QueryRow(ctx, query, id))
+}
+
+func (r *Repository) DeleteShippingZone(ctx context.Context, id int) error {
+	query := `DELETE FROM shipping_zones WHERE id = $1`
+	_, err := r.db.Exec(ctx, query, id)
+	return err
+}
+
+func (r *Repository) CreateShippingMethod(ctx context.Context, req models.CreateShippingMethodRequest) (*models.ShippingMethod, error) {
+	if req.WeightTiers == nil {
+		req.WeightTiers = []models.ShippingWeightTier{}
+	}
+
+	query := `
+		INSERT INTO shipping_methods (zone_id, code, name, rate_type, rate, weight_tiers, free_over, volumetric_divisor)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING ` + shippingMethodColumns
+
+	return scanShippingMethod(r.db.QueryRow(ctx, query,
+		req.ZoneID,
+		req.Code,
+		req.Name,
+		req.RateType,
+		req.Rate,
+		req.WeightTiers,
+		req.FreeOver,
+		req.VolumetricDivisor,
+	))
+}
+
+// GetShippingMethods lists the methods of a zone, or of every zone when
+// zoneID is 0. activeOnly leaves out deactivated methods.
+func (r *Repository) GetShippingMethods(ctx context.Context, zoneID int, activeOnly bool) ([]models.ShippingMethod, error) {
+	query := `
+		SELECT ` + shippingMethodColumns + `
+		FROM shipping_methods
+		WHERE ($1 = 0 OR zone_id = $1) AND (NOT $2 OR is_active = true)
+		ORDER BY zone_id, id
+	`
+
+	rows, err := r.db.Query(ctx, query, zoneID, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var methods []models.ShippingMethod
+	for rows.Next() {
+		method, err := scanShippingMethod(rows)
+		if err != nil {
+			return nil, err
+		}
+		methods = append(methods, *method)
+	}
+
+	return methods, nil
+}
+
+func (r *Repository) DeactivateShippingMethod(ctx context.Context, id int) error {
+	query := `UPDATE shipping_methods SET is_active = false WHERE id = $1`
+	_, err := r.db.Exec(ctx, query, id)
+	return err
+}
+
+func scanShippingZone(row pgx.Row) (*models.ShippingZone, error) {
+	var zone models.ShippingZone
+	err := row.Scan(
+		&zone.ID,
+		&zone.Name,
+		&zone.Locations,
+		&zone.CreatedAt,
+		&zone.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &zone, nil
+}
+
+func scanShippingMethod(row pgx.Row) (*models.ShippingMethod, error) {
+	var method models.ShippingMethod
+	err := row.Scan(
+		&method.ID,
+		&method.ZoneID,
+		&method.Code,
+		&method.Name,
+		&method.RateType,
+		&method.Rate,
+		&method.WeightTiers,
+		&method.FreeOver,
+		&method.VolumetricDivisor,
+		&method.IsActive,
+		&method.CreatedAt,
+		&method.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &method, nil
+}
@@ -0,0 +1,385 @@
+package postgresql
+
+import (
+	"context"
+	"time"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+const taxRateColumns = `id, jurisdiction_id, tax_class_id, name, rate, effective_from, effective_to, created_at`
+
+func (r *Repository) CreateTaxClass(ctx context.Context, req models.CreateTaxClassRequest) (*models.TaxClass, error) {
+	query := `
+		INSERT INTO tax_classes (name, description)
+		VALUES ($1, $2)
+		RETURNING id, name, description, created_at, updated_at
+	`
+
+	var class models.TaxClass
+	err := r.db.QueryRow(ctx, query, req.Name, req.Description).Scan(
+		&class.ID,
+		&class.Name,
+		&class.Description,
+		&class.CreatedAt,
+		&class.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &class, nil
+}
+
+func (r *Repository) GetTaxClasses(ctx context.Context) ([]models.TaxClass, error) {
+	query := `
+		SELECT id, name, description, created_at, updated_at
+		FROM tax_classes
+		ORDER BY name
+	`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var classes []models.TaxClass
+	for rows.Next() {
+		var class models.TaxClass
+		err := rows.Scan(
+			&class.ID,
+			&class.Name,
+			&class.Description,
+			&class.CreatedAt,
+			&class.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		classes = append(classes, class)
+	}
+
+	return classes, nil
+}
+
+func (r *Repository) GetTaxClassByID(ctx context.Context, id int) (*models.TaxClass, error) {
+	query := `
+		SELECT id, name, description, created_at, updated_at
+		FROM tax_classes
+		WHERE id = $1
+	`
+
+	var class models.TaxClass
+	err := r.db.QueryRow(ctx, query, id).Scan(
+		&class.ID,
+		&class.Name,
+		&class.Description,
+		&class.CreatedAt,
+		&class.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &class, nil
+}
+
+// SetCategoryTaxClass sets or clears the tax class of a category. It returns
+// false when the category does not exist.
+func (r *Repository) SetCategoryTaxClass(ctx context.Context, categoryID int, classID *int) (bool, error) {
+	query := `UPDATE categories SET tax_class_id = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`
+
+	tag, err := r.db.Exec(ctx, query, categoryID, classID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// GetCategoryTaxClasses maps each of the categories that has a tax class to
+// that class.
+func (r *Repository) GetCategoryTaxClasses(ctx context.Context, categoryIDs []int) (map[int]int, error) {
+	query := `SELECT id, tax_class_id FROM categories WHERE id = ANY($1) AND tax_class_id IS NOT NULL`
+
+	rows, err := r.db.Query(ctx, query, categoryIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	classes := make(map[int]int)
+	for rows.Next() {
+		var categoryID, classID int
+		if err := rows.Scan(&categoryID, &classID); err != nil {
+			return nil, err
+		}
+		classes[categoryID] = classID
+	}
+
+	return classes, nil
+}
+
+func (r *Repository) CreateTaxJurisdiction(ctx context.Context, req models.CreateTaxJurisdictionRequest) (*models.TaxJurisdiction, error) {
+	query := `
+		INSERT INTO tax_jurisdictions (country, region, name, prices_include_tax)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, country, region, name, prices_include_tax, created_at, updated_at
+	`
+
+	return scanTaxJurisdiction(r.db.QueryRow(ctx, query, req.Country, req.Region, req.Name, req.PricesIncludeTax))
+}
+
+func (r *Repository) GetTaxJurisdictions(ctx context.Context) ([]models.TaxJurisdiction, error) {
+	query := `
+		SELECT id, country, region, name, prices_include_tax, created_at, updated_at
+		FROM tax_jurisdictions
+		ORDER BY country, region
+	`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jurisdictions []models.TaxJurisdiction
+	for rows.Next() {
+		jurisdiction, err := scanTaxJurisdiction(rows)
+		if err != nil {
+			return nil, err
+		}
+		jurisdictions = append(jurisdictions, *jurisdiction)
+	}
+
+	return jurisdictions, nil
+}
+
+func (r *Repository) GetTaxJurisdictionByID(ctx context.Context, id int) (*models.TaxJurisdiction, error) {
+	query := `
+		SELECT id, country, region, name, prices_include_tax, created_at, updated_at
+		FROM tax_jurisdictions
+		WHERE id = $1
+	`
+
+	return scanTaxJurisdiction(r.db.QueryRow(ctx, query, id))
+}
+
+// CreateTaxRate adds a rate. An open-ended rate for the same jurisdiction and
+// tax class that started earlier is ended on the day the new one starts, so
+// a rate change only needs the new rate.
+func (r *Repository) CreateTaxRate(ctx context.Context, rate models.TaxRate) (*models.TaxRate, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	closeQuery := `
+		UPDATE tax_rates
+		SET effective_to = $3
+		WHERE jurisdiction_id = $1
+		  AND tax_class_id IS NOT DISTINCT FROM $2
+		  AND effective_to IS NULL
+		  AND effective_from < $3
+	`
+	if _, err := tx.Exec(ctx, closeQuery, rate.JurisdictionID, rate.TaxClassID, rate.EffectiveFrom); err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO tax_rates (jurisdiction_id, tax_class_id, name, rate, effective_from, effective_to)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + taxRateColumns
+
+	created, err := scanTaxRate(tx.QueryRow(ctx, query,
+		rate.JurisdictionID,
+		rate.TaxClassID,
+		rate.Name,
+		rate.Rate,
+		rate.EffectiveFrom,
+		rate.EffectiveTo,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// GetTaxRates lists the rates of a jurisdiction, or of every jurisdiction
+// when jurisdictionID is 0, newest first.
+func (r *Repository) GetTaxRates(ctx context.Context, jurisdictionID int) ([]models.TaxRate, error) {
+	query := `
+		SELECT ` + taxRateColumns + `
+		FROM tax_rates
+		WHERE $1 = 0 OR jurisdiction_id = $1
+		ORDER BY jurisdiction_id, effective_from DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, jurisdictionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rates []models.TaxRate
+	for rows.Next() {
+		rate, err := scanTaxRate(rows)
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, *rate)
+	}
+
+	return rates, nil
+}
+
+// GetApplicableTaxRates returns the rates in effect on the given day in the
+// country's jurisdiction and the region's, for every tax class.
+func (r *Repository) GetApplicableTaxRates(ctx context.Context, country, region string, on time.Time) ([]models.TaxRate, error) {
+	query := `
+		SELECT tr.id, tr.jurisdiction_id, tr.tax_class_id, tr.name, tr.rate, tr.effective_from, tr.effective_to, tr.created_at,
+		       tj.name, tj.prices_include_tax
+		FROM tax_rates tr
+		JOIN tax_jurisdictions tj ON tj.id = tr.jurisdiction_id
+		WHERE tj.country = UPPER($1)
+		  AND (tj.region = '' OR UPPER(tj.region) = UPPER($2))
+		  AND tr.effective_from <= $3::date
+		  AND (tr.effective_to IS NULL OR tr.effective_to > $3::date)
+		ORDER BY tj.region, tr.id
+	`
+
+	rows, err := r.db.Query(ctx, query, country, region, on)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rates []models.TaxRate
+	for rows.Next() {
+		var rate models.TaxRate
+		err := rows.Scan(
+			&rate.ID,
+			&rate.JurisdictionID,
+			&rate.TaxClassID,
+			&rate.Name,
+			&rate.Rate,
+			&rate.EffectiveFrom,
+			&rate.EffectiveTo,
+			&rate.CreatedAt,
+			&rate.Jurisdiction,
+			&rate.PricesIncludeTax,
+		)
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, nil
+}
+
+func insertOrderTaxesTx(ctx context.Context, tx pgx.Tx, orderID int, lines []models.TaxLine) error {
+	query := `
+		INSERT INTO order_taxes (order_id, tax_rate_id, name, jurisdiction, rate, inclusive, amount)
+		VALUES ($1, NULLIF($2, 0), $3, $4, $5, $6, $7)
+	`
+
+	for _, line := range lines {
+		_, err := tx.Exec(ctx, query,
+			orderID,
+			line.TaxRateID,
+			line.Name,
+			line.Jurisdiction,
+			line.Rate,
+			line.Inclusive,
+			line.Amount,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Repository) getOrderTaxes(ctx context.Context, orderID int) ([]models.TaxLine, error) {
+	query := `
+		SELECT COALESCE(tax_rate_id, 0), name, jurisdiction, rate, inclusive, amount
+		FROM order_taxes
+		WHERE order_id = $1
+		ORDER BY id
+	`
+
+	rows, err := r.db.Query(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lines []models.TaxLine
+	for rows.Next() {
+		var line models.TaxLine
+		err := rows.Scan(
+			&line.TaxRateID,
+			&line.Name,
+			&line.Jurisdiction,
+			&line.Rate,
+			&line.Inclusive,
+			&line.Amount,
+		)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, nil
+}
+
+func scanTaxJurisdiction(row pgx.Row) (*models.TaxJurisdiction, error) {
+	var jurisdiction models.TaxJurisdiction
+	err := row.Scan(
+		&jurisdiction.ID,
+		&jurisdiction.Country,
+		&jurisdiction.Region,
+		&jurisdiction.Name,
+		&jurisdiction.PricesIncludeTax,
+		&jurisdiction.CreatedAt,
+		&jurisdiction.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &jurisdiction, nil
+}
+
+func scanTaxRate(row pgx.Row) (*models.TaxRate, error) {
+	var rate models.TaxRate
+	err := row.Scan(
+		&rate.ID,
+		&rate.JurisdictionID,
+		&rate.TaxClassID,
+		&rate.Name,
+		&rate.Rate,
+		&rate.EffectiveFrom,
+		&rate.EffectiveTo,
+		&rate.CreatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &rate, nil
+}
@@ -0,0 +1,166 @@
+package postgresql
+
+import (
+	"context"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+)
+
+func (r *Repository) CreateWatch(ctx context.Context, userID int, req models.CreateWatchRequest, unsubscribeToken string) (*models.ProductWatch, error) {
+	query := `
+		INSERT INTO product_watches (user_id, product_id, back_in_stock, target_price, unsubscribe_token)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, user_id, product_id, back_in_stock, target_price, unsubscribe_token, notified_at, created_at
+	`
+
+	var watch models.ProductWatch
+	err := r.db.QueryRow(ctx, query,
+		userID,
+		req.ProductID,
+		req.BackInStock,
+		req.TargetPrice,
+		unsubscribeToken,
+	).Scan(
+		&watch.ID,
+		&watch.UserID,
+		&watch.ProductID,
+		&watch.BackInStock,
+		&watch.TargetPrice,
+		&watch.UnsubscribeToken,
+		&watch.NotifiedAt,
+		&watch.CreatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &watch, nil
+}
+
+func (r *Repository) GetWatchesByUserID(ctx context.Context, userID int) ([]models.ProductWatch, error) {
+	query := `
+		SELECT w.id, w.user_id, w.product_id, w.back_in_stock, w.target_price, w.unsubscribe_token, w.notified_at, w.created_at,
+		       p.id, p.name, p.description, p.price, p.stock_quantity, p.category_id, p.sku, p.image_url, p.is_active, p.created_at, p.updated_at
+		FROM product_watches w
+		JOIN products p ON w.product_id = p.id
+		WHERE w.user_id = $1
+		ORDER BY w.created_at DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var watches []models.ProductWatch
+	for rows.Next() {
+		var watch models.ProductWatch
+		var product models.Product
+		err := rows.Scan(
+			&watch.ID,
+			&watch.UserID,
+			&watch.ProductID,
+			&watch.BackInStock,
+			&watch.TargetPrice,
+			&watch.UnsubscribeToken,
+			&watch.NotifiedAt,
+			&watch.CreatedAt,
+			&product.ID,
+			&product.Name,
+			&product.Description,
+			&product.Price,
+			&product.StockQuantity,
+			&product.CategoryID,
+			&product.SKU,
+			&product.ImageURL,
+			&product.IsActive,
+			&product.CreatedAt,
+			&product.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		watch.Product = &product
+		watches = append(watches, watch)
+	}
+
+	return watches, nil
+}
+
+func (r *Repository) GetPendingWatchesByProductID(ctx context.Context, productID int) ([]models.ProductWatch, error) {
+	query := `
+		SELECT id, user_id, product_id, back_in_stock, target_price, unsubscribe_token, notified_at, created_at
+		FROM product_watches
+		WHERE product_id = $1 AND notified_at IS NULL
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.Query(ctx, query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var watches []models.ProductWatch
+	for rows.Next() {
+		var watch models.ProductWatch
+		err := rows.Scan(
+			&watch.ID,
+			&watch.UserID,
+			&watch.ProductID,
+			&watch.BackInStock,
+			&watch.TargetPrice,
+			&watch.UnsubscribeToken,
+			&watch.NotifiedAt,
+			&watch.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		watches = append(watches, watch)
+	}
+
+	return watches, nil
+}
+
+// ClaimWatch marks a pending watch as notified. It returns false when another
+// caller already claimed it, which is what keeps each watch firing once.
+func (r *Repository) ClaimWatch(ctx context.Context, id int) (bool, error) {
+	query := `
+		UPDATE product_watches
+		SET notified_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND notified_at IS NULL
+	`
+
+	tag, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *Repository) ReleaseWatch(ctx context.Context, id int) error {
+	query := `UPDATE product_watches SET notified_at = NULL WHERE id = $1`
+	_, err := r.db.Exec(ctx, query, id)
+	return err
+}
+
+func (r *Repository) DeleteWatch(ctx context.Context, id, userID int) (bool, error) {
+	query := `DELETE FROM product_watches WHERE id = $1 AND user_id = $2`
+	tag, err := r.db.Exec(ctx, query, id, userID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (r *Repository) DeleteWatchByToken(ctx context.Context, unsubscribeToken string) (bool, error) {
+	query := `DELETE FROM product_watches WHERE unsubscribe_token = $1`
+	tag, err := r.db.Exec(ctx, query, unsubscribeToken)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,234 @@
+package postgresql
+
+import (
+	"context"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+)
+
+func (r *Repository) CreateWishlist(ctx context.Context, userID int, name, shareToken string) (*models.Wishlist, error) {
+	query := `
+		INSERT INTO wishlists (user_id, name, share_token)
+		VALUES ($1, $2, $3)
+		RETURNING id, user_id, name, share_token, created_at, updated_at
+	`
+
+	var wishlist models.Wishlist
+	err := r.db.QueryRow(ctx, query, userID, name, shareToken).Scan(
+		&wishlist.ID,
+		&wishlist.UserID,
+		&wishlist.Name,
+		&wishlist.ShareToken,
+		&wishlist.CreatedAt,
+		&wishlist.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &wishlist, nil
+}
+
+func (r *Repository) GetWishlistsByUserID(ctx context.Context, userID int) ([]models.Wishlist, error) {
+	query := `
+		SELECT id, user_id, name, share_token, created_at, updated_at
+		FROM wishlists
+		WHERE user_id = $1
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var wishlists []models.Wishlist
+	for rows.Next() {
+		var wishlist models.Wishlist
+		err := rows.Scan(
+			&wishlist.ID,
+			&wishlist.UserID,
+			&wishlist.Name,
+			&wishlist.ShareToken,
+			&wishlist.CreatedAt,
+			&wishlist.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		wishlists = append(wishlists, wishlist)
+	}
+
+	return wishlists, nil
+}
+
+func (r *Repository) GetWishlistByID(ctx context.Context, id int) (*models.Wishlist, error) {
+	query := `
+		SELECT id, user_id, name, share_token, created_at, updated_at
+		FROM wishlists
+		WHERE id = $1
+	`
+
+	var wishlist models.Wishlist
+	err := r.db.QueryRow(ctx, query, id).Scan(
+		&wishlist.ID,
+		&wishlist.UserID,
+		&wishlist.Name,
+		&wishlist.ShareToken,
+		&wishlist.CreatedAt,
+		&wishlist.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &wishlist, nil
+}
+
+func (r *Repository) GetWishlistByShareToken(ctx context.Context, shareToken string) (*models.Wishlist, error) {
+	query := `
+		SELECT id, user_id, name, share_token, created_at, updated_at
+		FROM wishlists
+		WHERE share_token = $1
+	`
+
+	var wishlist models.Wishlist
+	err := r.db.QueryRow(ctx, query, shareToken).Scan(
+		&wishlist.ID,
+		&wishlist.UserID,
+		&wishlist.Name,
+		&wishlist.ShareToken,
+		&wishlist.CreatedAt,
+		&wishlist.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &wishlist, nil
+}
+
+func (r *Repository) UpdateWishlist(ctx context.Context, id int, name string) error {
+	query := `
+		UPDATE wishlists
+		SET name = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`
+
+	_, err := r.db.Exec(ctx, query, id, name)
+	return err
+}
+
+func (r *Repository) DeleteWishlist(ctx context.Context, id int) error {
+	query := `DELETE FROM wishlists WHERE id = $1`
+	_, err := r.db.Exec(ctx, query, id)
+	return err
+}
+
+func (r *Repository) AddWishlistItem(ctx context.Context, wishlistID, productID int) (*models.WishlistItem, error) {
+	query := `
+		INSERT INTO wishlist_items (wishlist_id, product_id)
+		VALUES ($1, $2)
+		ON CONFLICT (wishlist_id, product_id)
+		DO UPDATE SET product_id = EXCLUDED.product_id
+		RETURNING id, wishlist_id, product_id, created_at
+	`
+
+	var item models.WishlistItem
+	err := r.db.QueryRow(ctx, query, wishlistID, productID).Scan(
+		&item.ID,
+		&item.WishlistID,
+		&item.ProductID,
+		&item.CreatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := r.GetProductByID(ctx, productID)
+	if err == nil {
+		item.Product = product
+	}
+
+	return &item, nil
+}
+
+func (r *Repository) GetWishlistItem(ctx context.Context, wishlistID, productID int) (*models.WishlistItem, error) {
+	query := `
+		SELECT id, wishlist_id, product_id, created_at
+		FROM wishlist_items
+		WHERE wishlist_id = $1 AND product_id = $2
+	`
+
+	var item models.WishlistItem
+	err := r.db.QueryRow(ctx, query, wishlistID, productID).Scan(
+		&item.ID,
+		&item.WishlistID,
+		&item.ProductID,
+		&item.CreatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &item, nil
+}
+
+func (r *Repository) GetWishlistItems(ctx context.Context, wishlistID int) ([]models.WishlistItem, error) {
+	query := `
+		SELECT wi.id, wi.wishlist_id, wi.product_id, wi.created_at,
+		       p.id, p.name, p.description, p.price, p.stock_quantity, p.category_id, p.sku, p.image_url, p.is_active, p.created_at, p.updated_at
+		FROM wishlist_items wi
+		JOIN products p ON wi.product_id = p.id
+		WHERE wi.wishlist_id = $1
+		ORDER BY wi.created_at DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, wishlistID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.WishlistItem
+	for rows.Next() {
+		var item models.WishlistItem
+		var product models.Product
+		err := rows.Scan(
+			&item.ID,
+			&item.WishlistID,
+			&item.ProductID,
+			&item.CreatedAt,
+			&product.ID,
+			&product.Name,
+			&product.Description,
+			&product.Price,
+			&product.StockQuantity,
+			&product.CategoryID,
+			&product.SKU,
+			&product.ImageURL,
+			&product.IsActive,
+			&product.CreatedAt,
+			&product.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		item.Product = &product
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func (r *Repository) RemoveWishlistItem(ctx context.Context, wishlistID, productID int) error {
+	query := `DELETE FROM wishlist_items WHERE wishlist_id = $1 AND product_id = $2`
+	_, err := r.db.Exec(ctx, query, wishlistID, productID)
+	return err
+}
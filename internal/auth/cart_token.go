@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/VishalHilal/e-commerce-api/internal/json"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	CartContextKey = contextKey("cart")
+
+	CartTokenHeader = "X-Cart-Token"
+	CartTokenCookie = "cart_token"
+
+	cartTokenTTL = 30 * 24 * time.Hour
+	// Tokens closer than this to expiry are reissued so active carts live on.
+	cartTokenRenewWithin = 7 * 24 * time.Hour
+)
+
+type CartClaims struct {
+	CartID string `json:"cart_id"`
+	jwt.RegisteredClaims
+}
+
+func (j *JWTService) GenerateCartToken(cartID string) (string, error) {
+	claims := CartClaims{
+		CartID: cartID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(cartTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Issuer:    "e-commerce-api",
+			Subject:   "guest-cart",
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(j.secretKey)
+}
+
+func (j *JWTService) ValidateCartToken(tokenString string) (*CartClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &CartClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return j.secretKey, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*CartClaims); ok && token.Valid && claims.Subject == "guest-cart" && claims.CartID != "" {
+		return claims, nil
+	}
+
+	return nil, fmt.Errorf("invalid cart token")
+}
+
+// CartSessionMiddleware lets authenticated users through with their claims
+// and gives everyone else a guest cart. The guest cart ID comes from a signed
+// token in the X-Cart-Token header or cart_token cookie; a new one is issued
+// when the request carries none.
+func (j *JWTService) CartSessionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if authHeader := r.Header.Get("Authorization"); authHeader != "" {
+			tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+			claims, err := j.ValidateToken(tokenString)
+			if tokenString == authHeader || err != nil {
+				json.Write(w, http.StatusUnauthorized, map[string]string{"error": "Invalid token"})
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), UserContextKey, claims)
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
+		cartID := ""
+		renew := true
+		if claims, err := j.ValidateCartToken(CartTokenFromRequest(r)); err == nil {
+			cartID = claims.CartID
+			renew = time.Until(claims.ExpiresAt.Time) < cartTokenRenewWithin
+		}
+
+		if cartID == "" {
+			cartID = uuid.New().String()
+		}
+
+		if renew {
+			token, err := j.GenerateCartToken(cartID)
+			if err != nil {
+				json.Write(w, http.StatusInternalServerError, map[string]string{"error": "Failed to issue cart token"})
+				return
+			}
+			SetCartToken(w, token)
+		}
+
+		ctx := context.WithValue(r.Context(), CartContextKey, cartID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// CartTokenFromRequest returns the raw guest cart token, preferring the
+// header over the cookie.
+func CartTokenFromRequest(r *http.Request) string {
+	if token := r.Header.Get(CartTokenHeader); token != "" {
+		return token
+	}
+
+	if cookie, err := r.Cookie(CartTokenCookie); err == nil {
+		return cookie.Value
+	}
+
+	return ""
+}
+
+func SetCartToken(w http.ResponseWriter, token string) {
+	w.Header().Set(CartTokenHeader, token)
+	http.SetCookie(w, &http.Cookie{
+		Name:     CartTokenCookie,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(cartTokenTTL.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func ClearCartToken(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     CartTokenCookie,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func GetCartIDFromContext(ctx context.Context) string {
+	if cartID, ok := ctx.Value(CartContextKey).(string); ok {
+		return cartID
+	}
+	return ""
+}
@@ -52,7 +52,8 @@ func (j *JWTService) ValidateToken(tokenString string) (*JWTClaims, error) {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid {
+	// Guest cart tokens share the signing key but carry no user.
+	if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid && claims.UserID != 0 {
 		return claims, nil
 	}
 
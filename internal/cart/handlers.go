@@ -1,6 +1,7 @@
 package cart
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -18,25 +19,98 @@ func NewHandler(service *Service) *handler {
 	return &handler{service: service}
 }
 
+// cartOwner resolves who the cart belongs to: the authenticated user, or the
+// guest cart attached by auth.CartSessionMiddleware.
+func cartOwner(r *http.Request) (userID int, guestToken string, ok bool) {
+	if claims := auth.GetUserFromContext(r.Context()); claims != nil {
+		return claims.UserID, "", true
+	}
+
+	if guestToken := auth.GetCartIDFromContext(r.Context()); guestToken != "" {
+		return 0, guestToken, true
+	}
+
+	return 0, "", false
+}
+
 func (h *handler) GetCart(w http.ResponseWriter, r *http.Request) {
-	claims := auth.GetUserFromContext(r.Context())
-	if claims == nil {
+	userID, guestToken, ok := cartOwner(r)
+	if !ok {
 		json.WriteError(w, http.StatusUnauthorized, "User not authenticated")
 		return
 	}
 
-	cart, err := h.service.GetCart(r.Context(), claims.UserID)
+	var cart *models.CartResponse
+	var err error
+	if guestToken != "" {
+		cart, err = h.service.GetGuestCart(r.Context(), guestToken)
+	} else {
+		cart, err = h.service.GetCart(r.Context(), userID)
+	}
 	if err != nil {
 		json.WriteError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	if country := r.URL.Query().Get("country"); country != "" {
+		address := models.Address{
+			Country:    country,
+			Region:     r.URL.Query().Get("region"),
+			PostalCode: r.URL.Query().Get("postal_code"),
+		}
+		if err := h.service.EstimateTax(r.Context(), cart, address); err != nil {
+			json.WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
 	json.Write(w, http.StatusOK, cart)
 }
 
+func (h *handler) GetShippingOptions(w http.ResponseWriter, r *http.Request) {
+	userID, guestToken, ok := cartOwner(r)
+	if !ok {
+		json.WriteError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	address := models.Address{
+		Country:    r.URL.Query().Get("country"),
+		Region:     r.URL.Query().Get("region"),
+		PostalCode: r.URL.Query().Get("postal_code"),
+	}
+	if address.Country == "" {
+		json.WriteError(w, http.StatusBadRequest, "country is required")
+		return
+	}
+
+	var cart *models.CartResponse
+	var err error
+	if guestToken != "" {
+		cart, err = h.service.GetGuestCart(r.Context(), guestToken)
+	} else {
+		cart, err = h.service.GetCart(r.Context(), userID)
+	}
+	if err != nil {
+		json.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	options, err := h.service.ShippingOptions(r.Context(), cart, address)
+	if err != nil {
+		json.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, map[string]interface{}{
+		"shipping_options": options,
+		"count":            len(options),
+	})
+}
+
 func (h *handler) AddToCart(w http.ResponseWriter, r *http.Request) {
-	claims := auth.GetUserFromContext(r.Context())
-	if claims == nil {
+	userID, guestToken, ok := cartOwner(r)
+	if !ok {
 		json.WriteError(w, http.StatusUnauthorized, "User not authenticated")
 		return
 	}
@@ -47,7 +121,13 @@ func (h *handler) AddToCart(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cartItem, err := h.service.AddToCart(r.Context(), claims.UserID, req)
+	var cartItem *models.CartItem
+	var err error
+	if guestToken != "" {
+		cartItem, err = h.service.AddToGuestCart(r.Context(), guestToken, req)
+	} else {
+		cartItem, err = h.service.AddToCart(r.Context(), userID, req)
+	}
 	if err != nil {
 		json.WriteError(w, http.StatusBadRequest, err.Error())
 		return
@@ -57,8 +137,8 @@ func (h *handler) AddToCart(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *handler) UpdateCartItem(w http.ResponseWriter, r *http.Request) {
-	claims := auth.GetUserFromContext(r.Context())
-	if claims == nil {
+	userID, guestToken, ok := cartOwner(r)
+	if !ok {
 		json.WriteError(w, http.StatusUnauthorized, "User not authenticated")
 		return
 	}
@@ -75,7 +155,12 @@ func (h *handler) UpdateCartItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.UpdateCartItem(r.Context(), claims.UserID, productID, req.Quantity); err != nil {
+	if guestToken != "" {
+		err = h.service.UpdateGuestCartItem(r.Context(), guestToken, productID, req.Quantity)
+	} else {
+		err = h.service.UpdateCartItem(r.Context(), userID, productID, req.Quantity)
+	}
+	if err != nil {
 		json.WriteError(w, http.StatusBadRequest, err.Error())
 		return
 	}
@@ -84,8 +169,8 @@ func (h *handler) UpdateCartItem(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *handler) RemoveFromCart(w http.ResponseWriter, r *http.Request) {
-	claims := auth.GetUserFromContext(r.Context())
-	if claims == nil {
+	userID, guestToken, ok := cartOwner(r)
+	if !ok {
 		json.WriteError(w, http.StatusUnauthorized, "User not authenticated")
 		return
 	}
@@ -96,7 +181,12 @@ func (h *handler) RemoveFromCart(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.RemoveFromCart(r.Context(), claims.UserID, productID); err != nil {
+	if guestToken != "" {
+		err = h.service.RemoveFromGuestCart(r.Context(), guestToken, productID)
+	} else {
+		err = h.service.RemoveFromCart(r.Context(), userID, productID)
+	}
+	if err != nil {
 		json.WriteError(w, http.StatusBadRequest, err.Error())
 		return
 	}
@@ -105,16 +195,207 @@ func (h *handler) RemoveFromCart(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *handler) ClearCart(w http.ResponseWriter, r *http.Request) {
+	userID, guestToken, ok := cartOwner(r)
+	if !ok {
+		json.WriteError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var err error
+	if guestToken != "" {
+		err = h.service.ClearGuestCart(r.Context(), guestToken)
+	} else {
+		err = h.service.ClearCart(r.Context(), userID)
+	}
+	if err != nil {
+		json.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, map[string]string{"message": "Cart cleared successfully"})
+}
+
+func (h *handler) AcknowledgeChanges(w http.ResponseWriter, r *http.Request) {
+	userID, guestToken, ok := cartOwner(r)
+	if !ok {
+		json.WriteError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var req models.AcknowledgeCartRequest
+	if err := json.Read(r, &req); err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	var err error
+	if guestToken != "" {
+		err = h.service.AcknowledgeGuestChanges(r.Context(), guestToken, req)
+	} else {
+		err = h.service.AcknowledgeChanges(r.Context(), userID, req)
+	}
+	if err != nil {
+		var conflict *models.CheckoutConflict
+		if errors.As(err, &conflict) {
+			json.Write(w, http.StatusConflict, conflict)
+			return
+		}
+		json.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, map[string]string{"message": "Cart changes acknowledged successfully"})
+}
+
+func (h *handler) GetSavedItems(w http.ResponseWriter, r *http.Request) {
 	claims := auth.GetUserFromContext(r.Context())
 	if claims == nil {
 		json.WriteError(w, http.StatusUnauthorized, "User not authenticated")
 		return
 	}
 
-	if err := h.service.ClearCart(r.Context(), claims.UserID); err != nil {
+	items, err := h.service.GetSavedItems(r.Context(), claims.UserID)
+	if err != nil {
 		json.WriteError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	json.Write(w, http.StatusOK, map[string]string{"message": "Cart cleared successfully"})
+	json.Write(w, http.StatusOK, map[string]interface{}{
+		"saved_items": items,
+		"count":       len(items),
+	})
+}
+
+func (h *handler) SaveForLater(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		json.WriteError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	productID, err := strconv.Atoi(chi.URLParam(r, "product_id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	if err := h.service.SaveForLater(r.Context(), claims.UserID, productID); err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, map[string]string{"message": "Item saved for later successfully"})
+}
+
+func (h *handler) MoveSavedItemToCart(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		json.WriteError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	productID, err := strconv.Atoi(chi.URLParam(r, "product_id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	if err := h.service.MoveToCart(r.Context(), claims.UserID, productID); err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, map[string]string{"message": "Item moved to cart successfully"})
+}
+
+func (h *handler) RemoveSavedItem(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		json.WriteError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	productID, err := strconv.Atoi(chi.URLParam(r, "product_id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	if err := h.service.RemoveSavedItem(r.Context(), claims.UserID, productID); err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, map[string]string{"message": "Saved item removed successfully"})
+}
+
+func (h *handler) ShareCart(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		json.WriteError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var req models.CreateCartShareRequest
+	if r.ContentLength != 0 {
+		if err := json.Read(r, &req); err != nil {
+			json.WriteError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	share, err := h.service.ShareCart(r.Context(), claims.UserID, req)
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusCreated, share)
+}
+
+func (h *handler) GetSharedCart(w http.ResponseWriter, r *http.Request) {
+	share, err := h.service.GetSharedCart(r.Context(), chi.URLParam(r, "token"))
+	if err != nil {
+		json.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, share)
+}
+
+func (h *handler) CopySharedCart(w http.ResponseWriter, r *http.Request) {
+	userID, guestToken, ok := cartOwner(r)
+	if !ok {
+		json.WriteError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	token := chi.URLParam(r, "token")
+	var err error
+	if guestToken != "" {
+		err = h.service.CopySharedCartToGuest(r.Context(), token, guestToken)
+	} else {
+		err = h.service.CopySharedCart(r.Context(), token, userID)
+	}
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, map[string]string{"message": "Shared cart copied successfully"})
+}
+
+func (h *handler) RevokeCartShare(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		json.WriteError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	if err := h.service.RevokeCartShare(r.Context(), chi.URLParam(r, "token"), claims.UserID); err != nil {
+		json.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, map[string]string{"message": "Shared cart revoked successfully"})
 }
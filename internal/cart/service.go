@@ -3,42 +3,100 @@ package cart
 import (
 	"context"
 	"fmt"
+	"math"
+	"time"
 
 	"github.com/VishalHilal/e-commerce-api/internal/models"
+	"github.com/google/uuid"
 )
 
 type Repository interface {
-	AddToCart(ctx context.Context, userID int, req models.AddToCartRequest) (*models.CartItem, error)
+	AddToCart(ctx context.Context, userID int, req models.AddToCartRequest, addedPrice float64) (*models.CartItem, error)
 	GetCartItems(ctx context.Context, userID int) ([]models.CartItem, error)
-	UpdateCartItem(ctx context.Context, userID, productID int, quantity int) error
+	UpdateCartItem(ctx context.Context, userID, productID int, quantity int, addedPrice float64) error
 	RemoveFromCart(ctx context.Context, userID, productID int) error
 	ClearCart(ctx context.Context, userID int) error
 	GetProductByID(ctx context.Context, productID int) (*models.Product, error)
+	AddToGuestCart(ctx context.Context, guestToken string, req models.AddToCartRequest, addedPrice float64) (*models.CartItem, error)
+	GetGuestCartItems(ctx context.Context, guestToken string) ([]models.CartItem, error)
+	UpdateGuestCartItem(ctx context.Context, guestToken string, productID int, quantity int, addedPrice float64) error
+	RemoveFromGuestCart(ctx context.Context, guestToken string, productID int) error
+	ClearGuestCart(ctx context.Context, guestToken string) error
+	MergeGuestCart(ctx context.Context, guestToken string, userID int, items []models.CartItem) error
+	AcknowledgeCartChanges(ctx context.Context, userID int, items []models.CartItem) error
+	AcknowledgeGuestCartChanges(ctx context.Context, guestToken string, items []models.CartItem) error
+	GetSavedItems(ctx context.Context, userID int) ([]models.SavedItem, error)
+	GetSavedItem(ctx context.Context, userID, productID int) (*models.SavedItem, error)
+	SaveForLater(ctx context.Context, userID, productID int) (bool, error)
+	MoveSavedItemToCart(ctx context.Context, userID, productID int, addedPrice float64) (bool, error)
+	RemoveSavedItem(ctx context.Context, userID, productID int) error
+	CreateCartShare(ctx context.Context, userID int, token string, items []models.CartShareItem, expiresAt time.Time) (*models.CartShare, error)
+	GetCartShareByToken(ctx context.Context, token string) (*models.CartShare, error)
+	DeleteCartShare(ctx context.Context, token string, userID int) (bool, error)
+	AddItemsToCart(ctx context.Context, userID int, items []models.CartItem) error
+	AddItemsToGuestCart(ctx context.Context, guestToken string, items []models.CartItem) error
+}
+
+const (
+	defaultShareTTL = 72 * time.Hour
+	maxShareTTL     = 30 * 24 * time.Hour
+)
+
+// Pricer sets each item's unit price to the best one the user gets for the
+// item's quantity. userID is 0 for guest carts.
+type Pricer interface {
+	PriceItems(ctx context.Context, userID int, items []models.CartItem) error
+}
+
+// Adjuster adds discount lines to a cart before it is returned. userID is 0
+// for guest carts.
+type Adjuster interface {
+	AdjustCart(ctx context.Context, userID int, cart *models.CartResponse) error
+}
+
+// TaxCalculator works out the tax on cart items shipped to an address, after
+// the cart's discount.
+type TaxCalculator interface {
+	CartTax(ctx context.Context, address models.Address, items []models.CartItem, discount float64) (*models.TaxBreakdown, error)
+}
+
+// ShippingQuoter prices the shipping methods available for sending items to
+// an address. subtotal is the discounted item total.
+type ShippingQuoter interface {
+	Quote(ctx context.Context, address models.Address, items []models.CartItem, subtotal float64) ([]models.ShippingOption, error)
 }
 
 type Service struct {
-	repo Repository
+	repo      Repository
+	pricer    Pricer
+	taxes     TaxCalculator
+	shipping  ShippingQuoter
+	adjusters []Adjuster
 }
 
-func NewService(repo Repository) *Service {
-	return &Service{repo: repo}
+// NewService creates the cart service. taxes may be nil, in which case carts
+// never show tax, and shipping may be nil, in which case no shipping options
+// are offered.
+func NewService(repo Repository, pricer Pricer, taxes TaxCalculator, shipping ShippingQuoter, adjusters ...Adjuster) *Service {
+	return &Service{repo: repo, pricer: pricer, taxes: taxes, shipping: shipping, adjusters: adjusters}
 }
 
 func (s *Service) AddToCart(ctx context.Context, userID int, req models.AddToCartRequest) (*models.CartItem, error) {
-	product, err := s.repo.GetProductByID(ctx, req.ProductID)
-	if err != nil {
-		return nil, fmt.Errorf("product not found: %w", err)
+	if err := s.checkAvailability(ctx, req.ProductID, req.Quantity); err != nil {
+		return nil, err
 	}
 
-	if !product.IsActive {
-		return nil, fmt.Errorf("product is not available")
+	items, err := s.repo.GetCartItems(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cart items: %w", err)
 	}
 
-	if product.StockQuantity < req.Quantity {
-		return nil, fmt.Errorf("insufficient stock")
+	addedPrice, err := s.unitPrice(ctx, userID, req.ProductID, cartQuantity(items, req.ProductID)+req.Quantity)
+	if err != nil {
+		return nil, err
 	}
 
-	cartItem, err := s.repo.AddToCart(ctx, userID, req)
+	cartItem, err := s.repo.AddToCart(ctx, userID, req, addedPrice)
 	if err != nil {
 		return nil, fmt.Errorf("failed to add to cart: %w", err)
 	}
@@ -47,31 +105,381 @@ func (s *Service) AddToCart(ctx context.Context, userID int, req models.AddToCar
 }
 
 func (s *Service) GetCart(ctx context.Context, userID int) (*models.CartResponse, error) {
-	items, err := s.repo.GetCartItems(ctx, userID)
+	items, err := s.revalidate(ctx, userID, func() ([]models.CartItem, error) {
+		return s.repo.GetCartItems(ctx, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.adjust(ctx, userID, buildCartResponse(items))
+}
+
+func (s *Service) UpdateCartItem(ctx context.Context, userID, productID int, quantity int) error {
+	if quantity <= 0 {
+		return fmt.Errorf("quantity must be greater than 0")
+	}
+
+	if err := s.checkAvailability(ctx, productID, quantity); err != nil {
+		return err
+	}
+
+	addedPrice, err := s.unitPrice(ctx, userID, productID, quantity)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.UpdateCartItem(ctx, userID, productID, quantity, addedPrice); err != nil {
+		return fmt.Errorf("failed to update cart item: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) RemoveFromCart(ctx context.Context, userID, productID int) error {
+	if err := s.repo.RemoveFromCart(ctx, userID, productID); err != nil {
+		return fmt.Errorf("failed to remove from cart: %w", err)
+	}
+	return nil
+}
+
+// AcknowledgeChanges clears the cart's warnings: items that can no longer be
+// bought are removed, quantities above the stock left are lowered to it and
+// the others keep the price they were shown at. req must repeat the items as
+// the cart showed them; if the cart has changed since, nothing is accepted and
+// a *models.CheckoutConflict lists the differences.
+func (s *Service) AcknowledgeChanges(ctx context.Context, userID int, req models.AcknowledgeCartRequest) error {
+	items, err := s.revalidate(ctx, userID, func() ([]models.CartItem, error) {
+		return s.repo.GetCartItems(ctx, userID)
+	})
+	if err != nil {
+		return err
+	}
+
+	accepted, err := acknowledged(items, req)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.AcknowledgeCartChanges(ctx, userID, accepted); err != nil {
+		return fmt.Errorf("failed to acknowledge cart changes: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) ClearCart(ctx context.Context, userID int) error {
+	if err := s.repo.ClearCart(ctx, userID); err != nil {
+		return fmt.Errorf("failed to clear cart: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) AddToGuestCart(ctx context.Context, guestToken string, req models.AddToCartRequest) (*models.CartItem, error) {
+	if err := s.checkAvailability(ctx, req.ProductID, req.Quantity); err != nil {
+		return nil, err
+	}
+
+	items, err := s.repo.GetGuestCartItems(ctx, guestToken)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get cart items: %w", err)
 	}
 
-	var totalItems int
-	var totalPrice float64
+	addedPrice, err := s.unitPrice(ctx, 0, req.ProductID, cartQuantity(items, req.ProductID)+req.Quantity)
+	if err != nil {
+		return nil, err
+	}
 
-	for _, item := range items {
-		totalItems += item.Quantity
-		totalPrice += float64(item.Quantity) * item.Product.Price
+	cartItem, err := s.repo.AddToGuestCart(ctx, guestToken, req, addedPrice)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add to cart: %w", err)
 	}
 
-	return &models.CartResponse{
-		Items:      items,
-		TotalItems: totalItems,
-		TotalPrice: totalPrice,
-	}, nil
+	return cartItem, nil
 }
 
-func (s *Service) UpdateCartItem(ctx context.Context, userID, productID int, quantity int) error {
+func (s *Service) GetGuestCart(ctx context.Context, guestToken string) (*models.CartResponse, error) {
+	items, err := s.revalidate(ctx, 0, func() ([]models.CartItem, error) {
+		return s.repo.GetGuestCartItems(ctx, guestToken)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.adjust(ctx, 0, buildCartResponse(items))
+}
+
+func (s *Service) UpdateGuestCartItem(ctx context.Context, guestToken string, productID int, quantity int) error {
 	if quantity <= 0 {
 		return fmt.Errorf("quantity must be greater than 0")
 	}
 
+	if err := s.checkAvailability(ctx, productID, quantity); err != nil {
+		return err
+	}
+
+	addedPrice, err := s.unitPrice(ctx, 0, productID, quantity)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.UpdateGuestCartItem(ctx, guestToken, productID, quantity, addedPrice); err != nil {
+		return fmt.Errorf("failed to update cart item: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) RemoveFromGuestCart(ctx context.Context, guestToken string, productID int) error {
+	if err := s.repo.RemoveFromGuestCart(ctx, guestToken, productID); err != nil {
+		return fmt.Errorf("failed to remove from cart: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) AcknowledgeGuestChanges(ctx context.Context, guestToken string, req models.AcknowledgeCartRequest) error {
+	items, err := s.revalidate(ctx, 0, func() ([]models.CartItem, error) {
+		return s.repo.GetGuestCartItems(ctx, guestToken)
+	})
+	if err != nil {
+		return err
+	}
+
+	accepted, err := acknowledged(items, req)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.AcknowledgeGuestCartChanges(ctx, guestToken, accepted); err != nil {
+		return fmt.Errorf("failed to acknowledge cart changes: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) ClearGuestCart(ctx context.Context, guestToken string) error {
+	if err := s.repo.ClearGuestCart(ctx, guestToken); err != nil {
+		return fmt.Errorf("failed to clear cart: %w", err)
+	}
+	return nil
+}
+
+// MergeGuestCart moves the guest cart into the user's cart, adding quantities
+// for products in both and clamping the result to the available stock.
+func (s *Service) MergeGuestCart(ctx context.Context, guestToken string, userID int) error {
+	guestItems, err := s.repo.GetGuestCartItems(ctx, guestToken)
+	if err != nil {
+		return fmt.Errorf("failed to get cart items: %w", err)
+	}
+
+	add := make([]models.CartShareItem, 0, len(guestItems))
+	for _, item := range guestItems {
+		add = append(add, models.CartShareItem{ProductID: item.ProductID, Quantity: item.Quantity})
+	}
+
+	cartItems, err := s.repo.GetCartItems(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get cart items: %w", err)
+	}
+
+	items, err := s.combine(ctx, userID, cartItems, add)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.MergeGuestCart(ctx, guestToken, userID, items); err != nil {
+		return fmt.Errorf("failed to merge guest cart: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) GetSavedItems(ctx context.Context, userID int) ([]models.SavedItem, error) {
+	items, err := s.repo.GetSavedItems(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get saved items: %w", err)
+	}
+	return items, nil
+}
+
+// SaveForLater moves a product out of the cart into the saved items.
+func (s *Service) SaveForLater(ctx context.Context, userID, productID int) error {
+	moved, err := s.repo.SaveForLater(ctx, userID, productID)
+	if err != nil {
+		return fmt.Errorf("failed to save item for later: %w", err)
+	}
+
+	if !moved {
+		return fmt.Errorf("product is not in the cart")
+	}
+	return nil
+}
+
+// MoveToCart moves a saved product back into the cart, if it can still be
+// bought in the saved quantity on top of any already in the cart.
+func (s *Service) MoveToCart(ctx context.Context, userID, productID int) error {
+	item, err := s.repo.GetSavedItem(ctx, userID, productID)
+	if err != nil {
+		return fmt.Errorf("saved item not found")
+	}
+
+	cartItems, err := s.repo.GetCartItems(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get cart items: %w", err)
+	}
+
+	quantity := cartQuantity(cartItems, productID) + item.Quantity
+	if err := s.checkAvailability(ctx, productID, quantity); err != nil {
+		return err
+	}
+
+	addedPrice, err := s.unitPrice(ctx, userID, productID, quantity)
+	if err != nil {
+		return err
+	}
+
+	moved, err := s.repo.MoveSavedItemToCart(ctx, userID, productID, addedPrice)
+	if err != nil {
+		return fmt.Errorf("failed to move item to cart: %w", err)
+	}
+
+	if !moved {
+		return fmt.Errorf("saved item not found")
+	}
+	return nil
+}
+
+func (s *Service) RemoveSavedItem(ctx context.Context, userID, productID int) error {
+	if err := s.repo.RemoveSavedItem(ctx, userID, productID); err != nil {
+		return fmt.Errorf("failed to remove saved item: %w", err)
+	}
+	return nil
+}
+
+// ShareCart creates a link to a copy of the user's cart as it is now. The
+// link expires after ExpiresInHours, 72 by default and 30 days at most.
+func (s *Service) ShareCart(ctx context.Context, userID int, req models.CreateCartShareRequest) (*models.CartShare, error) {
+	ttl := defaultShareTTL
+	if req.ExpiresInHours < 0 {
+		return nil, fmt.Errorf("expires_in_hours must be greater than 0")
+	}
+	if req.ExpiresInHours > 0 {
+		ttl = time.Duration(req.ExpiresInHours) * time.Hour
+	}
+	if ttl > maxShareTTL {
+		return nil, fmt.Errorf("shared carts can expire at most 30 days from now")
+	}
+
+	cartItems, err := s.repo.GetCartItems(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cart items: %w", err)
+	}
+
+	if len(cartItems) == 0 {
+		return nil, fmt.Errorf("cart is empty")
+	}
+
+	items := make([]models.CartShareItem, 0, len(cartItems))
+	for _, item := range cartItems {
+		items = append(items, models.CartShareItem{ProductID: item.ProductID, Quantity: item.Quantity})
+	}
+
+	share, err := s.repo.CreateCartShare(ctx, userID, uuid.New().String(), items, time.Now().Add(ttl))
+	if err != nil {
+		return nil, fmt.Errorf("failed to share cart: %w", err)
+	}
+
+	return share, nil
+}
+
+// GetSharedCart returns the items behind a share link with their products.
+func (s *Service) GetSharedCart(ctx context.Context, token string) (*models.CartShare, error) {
+	share, err := s.getShare(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range share.Items {
+		product, err := s.repo.GetProductByID(ctx, share.Items[i].ProductID)
+		if err != nil {
+			continue
+		}
+		share.Items[i].Product = product
+	}
+
+	return share, nil
+}
+
+// CopySharedCart adds the items behind a share link to the user's cart. Items
+// that are no longer available are skipped and quantities are clamped to the
+// stock left.
+func (s *Service) CopySharedCart(ctx context.Context, token string, userID int) error {
+	share, err := s.getShare(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	cartItems, err := s.repo.GetCartItems(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get cart items: %w", err)
+	}
+
+	items, err := s.combine(ctx, userID, cartItems, share.Items)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.AddItemsToCart(ctx, userID, items); err != nil {
+		return fmt.Errorf("failed to copy shared cart: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) CopySharedCartToGuest(ctx context.Context, token, guestToken string) error {
+	share, err := s.getShare(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	cartItems, err := s.repo.GetGuestCartItems(ctx, guestToken)
+	if err != nil {
+		return fmt.Errorf("failed to get cart items: %w", err)
+	}
+
+	items, err := s.combine(ctx, 0, cartItems, share.Items)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.AddItemsToGuestCart(ctx, guestToken, items); err != nil {
+		return fmt.Errorf("failed to copy shared cart: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) RevokeCartShare(ctx context.Context, token string, userID int) error {
+	deleted, err := s.repo.DeleteCartShare(ctx, token, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke shared cart: %w", err)
+	}
+
+	if !deleted {
+		return fmt.Errorf("shared cart not found")
+	}
+	return nil
+}
+
+func (s *Service) getShare(ctx context.Context, token string) (*models.CartShare, error) {
+	share, err := s.repo.GetCartShareByToken(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("shared cart not found")
+	}
+
+	if time.Now().After(share.ExpiresAt) {
+		return nil, fmt.Errorf("shared cart link has expired")
+	}
+
+	return share, nil
+}
+
+func (s *Service) checkAvailability(ctx context.Context, productID, quantity int) error {
 	product, err := s.repo.GetProductByID(ctx, productID)
 	if err != nil {
 		return fmt.Errorf("product not found: %w", err)
@@ -85,23 +493,252 @@ func (s *Service) UpdateCartItem(ctx context.Context, userID, productID int, qua
 		return fmt.Errorf("insufficient stock")
 	}
 
-	if err := s.repo.UpdateCartItem(ctx, userID, productID, quantity); err != nil {
-		return fmt.Errorf("failed to update cart item: %w", err)
+	return nil
+}
+
+// revalidate loads the cart, prices it for the user and checks every item
+// against the catalogue. Each change since an item was added becomes a
+// warning on the item; nothing is written until the changes are
+// acknowledged. userID is 0 for guest carts.
+func (s *Service) revalidate(ctx context.Context, userID int, load func() ([]models.CartItem, error)) ([]models.CartItem, error) {
+	items, err := load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cart items: %w", err)
 	}
 
-	return nil
+	if err := s.pricer.PriceItems(ctx, userID, items); err != nil {
+		return nil, fmt.Errorf("failed to price cart items: %w", err)
+	}
+
+	for i := range items {
+		items[i].Warnings = itemWarnings(&items[i])
+	}
+
+	return items, nil
 }
 
-func (s *Service) RemoveFromCart(ctx context.Context, userID, productID int) error {
-	if err := s.repo.RemoveFromCart(ctx, userID, productID); err != nil {
-		return fmt.Errorf("failed to remove from cart: %w", err)
+// unitPrice prices quantity of the product for the user, who is 0 for a
+// guest.
+func (s *Service) unitPrice(ctx context.Context, userID, productID, quantity int) (float64, error) {
+	items := []models.CartItem{{ProductID: productID, Quantity: quantity}}
+	if err := s.pricer.PriceItems(ctx, userID, items); err != nil {
+		return 0, fmt.Errorf("failed to price product %d: %w", productID, err)
 	}
-	return nil
+	return items[0].UnitPrice, nil
 }
 
-func (s *Service) ClearCart(ctx context.Context, userID int) error {
-	if err := s.repo.ClearCart(ctx, userID); err != nil {
-		return fmt.Errorf("failed to clear cart: %w", err)
+// combine works out the cart items that adding items to a cart's current
+// contents gives: quantities of products already in the cart are added
+// together and clamped to the stock left, products that can no longer be
+// bought are skipped, and each is priced for the user at its new quantity.
+func (s *Service) combine(ctx context.Context, userID int, current []models.CartItem, add []models.CartShareItem) ([]models.CartItem, error) {
+	var items []models.CartItem
+	for _, item := range add {
+		product, err := s.repo.GetProductByID(ctx, item.ProductID)
+		if err != nil || !product.IsActive || product.StockQuantity <= 0 {
+			continue
+		}
+
+		quantity := min(cartQuantity(current, item.ProductID)+item.Quantity, product.StockQuantity)
+		items = append(items, models.CartItem{ProductID: item.ProductID, Quantity: quantity})
+	}
+
+	if err := s.pricer.PriceItems(ctx, userID, items); err != nil {
+		return nil, fmt.Errorf("failed to price cart items: %w", err)
+	}
+	for i := range items {
+		items[i].AddedPrice = items[i].UnitPrice
+	}
+
+	return items, nil
+}
+
+// cartQuantity returns how many of the product the cart items hold.
+func cartQuantity(items []models.CartItem, productID int) int {
+	quantity := 0
+	for _, item := range items {
+		if item.ProductID == productID {
+			quantity += item.Quantity
+		}
+	}
+	return quantity
+}
+
+// acknowledged checks that the customer was shown the cart as it is now and
+// returns the items to keep, each with the quantity it can still be bought in
+// and the price it was shown at. Items that can no longer be bought are left
+// out.
+func acknowledged(items []models.CartItem, req models.AcknowledgeCartRequest) ([]models.CartItem, error) {
+	shown := make(map[int]models.AcknowledgedCartItem)
+	for _, item := range req.Items {
+		shown[item.ProductID] = item
+	}
+
+	conflict := &models.CheckoutConflict{}
+	var accepted []models.CartItem
+	for _, item := range items {
+		seen, ok := shown[item.ProductID]
+		delete(shown, item.ProductID)
+		switch {
+		case !ok || seen.Quantity != item.Quantity:
+			conflict.StockIssues = append(conflict.StockIssues, models.CheckoutStockIssue{
+				ProductID: item.ProductID,
+				Name:      item.Product.Name,
+				Requested: seen.Quantity,
+				Available: item.Quantity,
+				Reason:    "quantity_changed",
+			})
+			continue
+		case seen.UnitPrice != item.UnitPrice:
+			conflict.PriceChanges = append(conflict.PriceChanges, models.CheckoutPriceChange{
+				ProductID: item.ProductID,
+				Name:      item.Product.Name,
+				Quantity:  item.Quantity,
+				OldPrice:  seen.UnitPrice,
+				NewPrice:  item.UnitPrice,
+			})
+			continue
+		}
+
+		product := item.Product
+		if !product.IsActive || product.StockQuantity <= 0 {
+			continue
+		}
+
+		item.Quantity = min(item.Quantity, product.StockQuantity)
+		item.AddedPrice = item.UnitPrice
+		accepted = append(accepted, item)
+	}
+
+	for productID, item := range shown {
+		conflict.StockIssues = append(conflict.StockIssues, models.CheckoutStockIssue{
+			ProductID: productID,
+			Requested: item.Quantity,
+			Reason:    "not_in_cart",
+		})
+	}
+
+	if len(conflict.StockIssues) > 0 || len(conflict.PriceChanges) > 0 {
+		conflict.Message = "the cart has changed since it was shown; review it and acknowledge again"
+		return nil, conflict
+	}
+
+	return accepted, nil
+}
+
+func itemWarnings(item *models.CartItem) []models.CartItemWarning {
+	product := item.Product
+	var warnings []models.CartItemWarning
+
+	switch {
+	case !product.IsActive:
+		warnings = append(warnings, models.CartItemWarning{
+			Type:    models.CartWarningUnavailable,
+			Message: fmt.Sprintf("%s is no longer available", product.Name),
+		})
+		return warnings
+	case product.StockQuantity <= 0:
+		warnings = append(warnings, models.CartItemWarning{
+			Type:    models.CartWarningOutOfStock,
+			Message: fmt.Sprintf("%s is out of stock", product.Name),
+		})
+		return warnings
+	}
+
+	if product.StockQuantity < item.Quantity {
+		warnings = append(warnings, models.CartItemWarning{
+			Type: models.CartWarningInsufficientStock,
+			Message: fmt.Sprintf("Only %d of %s left in stock; acknowledging lowers the quantity from %d",
+				product.StockQuantity, product.Name, item.Quantity),
+		})
+	}
+
+	if item.UnitPrice != item.AddedPrice {
+		warnings = append(warnings, models.CartItemWarning{
+			Type:    models.CartWarningPriceChanged,
+			Message: fmt.Sprintf("Price of %s changed from $%.2f to $%.2f", product.Name, item.AddedPrice, item.UnitPrice),
+		})
+	}
+
+	return warnings
+}
+
+// adjust runs the adjusters over the cart and totals their discount lines.
+// Discounts never take the total below zero.
+func (s *Service) adjust(ctx context.Context, userID int, cart *models.CartResponse) (*models.CartResponse, error) {
+	for _, adjuster := range s.adjusters {
+		if err := adjuster.AdjustCart(ctx, userID, cart); err != nil {
+			return nil, err
+		}
 	}
+
+	cart.DiscountTotal = 0
+	for _, discount := range cart.Discounts {
+		cart.DiscountTotal += discount.Amount
+	}
+	cart.DiscountTotal = math.Min(math.Round(cart.DiscountTotal*100)/100, cart.TotalPrice)
+	cart.GrandTotal = math.Round((cart.TotalPrice-cart.DiscountTotal)*100) / 100
+
+	return cart, nil
+}
+
+// EstimateTax adds the tax lines for shipping the cart to the address. Taxes
+// that are not included in the prices are added to the grand total.
+func (s *Service) EstimateTax(ctx context.Context, cart *models.CartResponse, address models.Address) error {
+	if s.taxes == nil {
+		return nil
+	}
+
+	tax, err := s.taxes.CartTax(ctx, address, cart.Items, cart.DiscountTotal)
+	if err != nil {
+		return err
+	}
+
+	cart.TaxLines = tax.Lines
+	cart.TaxTotal = tax.Total
+	cart.GrandTotal = math.Round((cart.GrandTotal+tax.Added)*100) / 100
 	return nil
 }
+
+// ShippingOptions quotes every shipping method available for sending the
+// cart to the address. A free shipping promotion on the cart makes them all
+// free.
+func (s *Service) ShippingOptions(ctx context.Context, cart *models.CartResponse, address models.Address) ([]models.ShippingOption, error) {
+	if s.shipping == nil || len(cart.Items) == 0 {
+		return nil, nil
+	}
+
+	options, err := s.shipping.Quote(ctx, address, cart.Items, cart.TotalPrice-cart.DiscountTotal)
+	if err != nil {
+		return nil, err
+	}
+
+	if cart.FreeShipping {
+		for i := range options {
+			options[i].Cost = 0
+		}
+	}
+
+	return options, nil
+}
+
+func buildCartResponse(items []models.CartItem) *models.CartResponse {
+	var totalItems int
+	var totalPrice float64
+
+	var requiresAcknowledgement bool
+
+	for _, item := range items {
+		totalItems += item.Quantity
+		totalPrice += float64(item.Quantity) * item.UnitPrice
+		requiresAcknowledgement = requiresAcknowledgement || len(item.Warnings) > 0
+	}
+
+	return &models.CartResponse{
+		Items:                   items,
+		TotalItems:              totalItems,
+		TotalPrice:              totalPrice,
+		GrandTotal:              totalPrice,
+		RequiresAcknowledgement: requiresAcknowledgement,
+	}
+}
@@ -0,0 +1,79 @@
+package cart
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+)
+
+func cartItem(productID, quantity, stock int, unitPrice float64, active bool) models.CartItem {
+	return models.CartItem{
+		ProductID: productID,
+		Quantity:  quantity,
+		UnitPrice: unitPrice,
+		Product:   &models.Product{ID: productID, StockQuantity: stock, IsActive: active},
+	}
+}
+
+func TestAcknowledged(t *testing.T) {
+	items := []models.CartItem{
+		cartItem(1, 3, 10, 9.5, true),
+		cartItem(2, 5, 2, 20, true),
+		cartItem(3, 1, 0, 4, true),
+		cartItem(4, 1, 5, 8, false),
+	}
+	shown := []models.AcknowledgedCartItem{
+		{ProductID: 1, Quantity: 3, UnitPrice: 9.5},
+		{ProductID: 2, Quantity: 5, UnitPrice: 20},
+		{ProductID: 3, Quantity: 1, UnitPrice: 4},
+		{ProductID: 4, Quantity: 1, UnitPrice: 8},
+	}
+
+	tests := []struct {
+		name       string
+		shown      []models.AcknowledgedCartItem
+		want       map[int]int
+		wantPrices int
+		wantStock  int
+	}{
+		{"matching cart", shown, map[int]int{1: 3, 2: 2}, 0, 0},
+		{"price changed since shown", append([]models.AcknowledgedCartItem{{ProductID: 1, Quantity: 3, UnitPrice: 10}}, shown[1:]...), nil, 1, 0},
+		{"quantity changed since shown", append([]models.AcknowledgedCartItem{{ProductID: 1, Quantity: 2, UnitPrice: 9.5}}, shown[1:]...), nil, 0, 1},
+		{"item added since shown", shown[1:], nil, 0, 1},
+		{"item removed since shown", append(shown, models.AcknowledgedCartItem{ProductID: 5, Quantity: 1, UnitPrice: 1}), nil, 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accepted, err := acknowledged(items, models.AcknowledgeCartRequest{Items: tt.shown})
+
+			var conflict *models.CheckoutConflict
+			if tt.wantPrices > 0 || tt.wantStock > 0 {
+				if !errors.As(err, &conflict) {
+					t.Fatalf("acknowledged error = %v, want a conflict", err)
+				}
+				if len(conflict.PriceChanges) != tt.wantPrices || len(conflict.StockIssues) != tt.wantStock {
+					t.Errorf("acknowledged conflict = %d price changes, %d stock issues, want %d and %d",
+						len(conflict.PriceChanges), len(conflict.StockIssues), tt.wantPrices, tt.wantStock)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("acknowledged: %v", err)
+			}
+
+			got := make(map[int]int)
+			for _, item := range accepted {
+				got[item.ProductID] = item.Quantity
+				if item.AddedPrice != item.UnitPrice {
+					t.Errorf("acknowledged product %d added at %v, want the shown %v", item.ProductID, item.AddedPrice, item.UnitPrice)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("acknowledged quantities = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
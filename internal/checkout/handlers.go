@@ -0,0 +1,191 @@
+package checkout
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/VishalHilal/e-commerce-api/internal/auth"
+	"github.com/VishalHilal/e-commerce-api/internal/json"
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+	"github.com/go-chi/chi/v5"
+)
+
+type handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *handler {
+	return &handler{service: service}
+}
+
+func (h *handler) CreateSession(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		json.WriteError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	session, err := h.service.CreateSession(r.Context(), claims.UserID)
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusCreated, session)
+}
+
+func (h *handler) GetSession(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		json.WriteError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	sessionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid checkout session ID")
+		return
+	}
+
+	session, err := h.service.GetSession(r.Context(), sessionID, claims.UserID)
+	if err != nil {
+		json.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, session)
+}
+
+func (h *handler) SetAddresses(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		json.WriteError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	sessionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid checkout session ID")
+		return
+	}
+
+	var req models.SetCheckoutAddressesRequest
+	if err := json.Read(r, &req); err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	session, err := h.service.SetAddresses(r.Context(), sessionID, claims.UserID, req)
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, session)
+}
+
+func (h *handler) SetShippingMethod(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		json.WriteError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	sessionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid checkout session ID")
+		return
+	}
+
+	var req models.SetShippingMethodRequest
+	if err := json.Read(r, &req); err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	session, err := h.service.SetShippingMethod(r.Context(), sessionID, claims.UserID, req)
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, session)
+}
+
+func (h *handler) ApplyDiscount(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		json.WriteError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	sessionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid checkout session ID")
+		return
+	}
+
+	var req models.ApplyDiscountRequest
+	if err := json.Read(r, &req); err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	session, err := h.service.ApplyDiscount(r.Context(), sessionID, claims.UserID, req)
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, session)
+}
+
+func (h *handler) RemoveDiscount(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		json.WriteError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	sessionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid checkout session ID")
+		return
+	}
+
+	session, err := h.service.RemoveDiscount(r.Context(), sessionID, claims.UserID)
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, session)
+}
+
+func (h *handler) Confirm(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		json.WriteError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	sessionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid checkout session ID")
+		return
+	}
+
+	var req models.ConfirmCheckoutRequest
+	if err := json.Read(r, &req); err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	confirmation, err := h.service.Confirm(r.Context(), sessionID, claims.UserID, req)
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusCreated, confirmation)
+}
@@ -0,0 +1,420 @@
+package checkout
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/VishalHilal/e-commerce-api/internal/cart"
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+	"github.com/VishalHilal/e-commerce-api/internal/orders"
+)
+
+const sessionTTL = 30 * time.Minute
+
+// Session statuses. A session moves forward through open, addressed and
+// shipping_selected; confirming moves it to processing and then completed.
+// Addresses can be changed again until confirmation, which resets the
+// shipping selection.
+const (
+	statusOpen             = "open"
+	statusAddressed        = "addressed"
+	statusShippingSelected = "shipping_selected"
+	statusProcessing       = "processing"
+	statusCompleted        = "completed"
+	statusExpired          = "expired"
+)
+
+type Repository interface {
+	CreateCheckoutSession(ctx context.Context, userID int, expiresAt time.Time) (*models.CheckoutSession, error)
+	GetCheckoutSession(ctx context.Context, id int) (*models.CheckoutSession, error)
+	UpdateCheckoutSession(ctx context.Context, session *models.CheckoutSession) error
+	TransitionCheckoutSession(ctx context.Context, id int, from, to string) (bool, error)
+	CompleteCheckoutSession(ctx context.Context, id, orderID int) error
+}
+
+// Discounter evaluates the automatic promotions and prices a discount code
+// against the items in a session.
+type Discounter interface {
+	Discounts(ctx context.Context, userID int, code string, items []models.CartItem) ([]models.DiscountLine, error)
+}
+
+// TaxCalculator works out the tax on the session's items shipped to its
+// shipping address, after the session's discount.
+type TaxCalculator interface {
+	CartTax(ctx context.Context, address models.Address, items []models.CartItem, discount float64) (*models.TaxBreakdown, error)
+}
+
+// ShippingQuoter prices the shipping methods available for sending the
+// session's items to its shipping address.
+type ShippingQuoter interface {
+	Quote(ctx context.Context, address models.Address, items []models.CartItem, subtotal float64) ([]models.ShippingOption, error)
+}
+
+type Service struct {
+	repo       Repository
+	cartSvc    *cart.Service
+	orderSvc   *orders.Service
+	discounter Discounter
+	taxes      TaxCalculator
+	shipping   ShippingQuoter
+}
+
+// NewService wires the checkout flow. discounter may be nil, in which case
+// no promotions apply and discount codes are rejected; taxes may be nil, in
+// which case sessions are not taxed; shipping may be nil, in which case no
+// shipping methods are offered.
+func NewService(repo Repository, cartSvc *cart.Service, orderSvc *orders.Service, discounter Discounter, taxes TaxCalculator, shipping ShippingQuoter) *Service {
+	return &Service{
+		repo:       repo,
+		cartSvc:    cartSvc,
+		orderSvc:   orderSvc,
+		discounter: discounter,
+		taxes:      taxes,
+		shipping:   shipping,
+	}
+}
+
+// CreateSession starts a checkout from the user's cart, carrying over the
+// coupon applied to the cart if it still applies.
+func (s *Service) CreateSession(ctx context.Context, userID int) (*models.CheckoutSession, error) {
+	userCart, err := s.cartSvc.GetCart(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.repo.CreateCheckoutSession(ctx, userID, time.Now().Add(sessionTTL))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create checkout session: %w", err)
+	}
+	session.DiscountCode = userCart.CouponCode
+
+	if err := s.reprice(ctx, session); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateCheckoutSession(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to update checkout session: %w", err)
+	}
+
+	return session, nil
+}
+
+// GetSession re-prices the session against the current cart and returns the
+// preview of its totals.
+func (s *Service) GetSession(ctx context.Context, sessionID, userID int) (*models.CheckoutSession, error) {
+	session, err := s.getOwnedSession(ctx, sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.Status == statusCompleted || session.Status == statusProcessing {
+		return session, nil
+	}
+
+	return s.step(ctx, session, []string{statusOpen, statusAddressed, statusShippingSelected}, func(session *models.CheckoutSession) string {
+		return session.Status
+	})
+}
+
+func (s *Service) SetAddresses(ctx context.Context, sessionID, userID int, req models.SetCheckoutAddressesRequest) (*models.CheckoutSession, error) {
+	if !req.ShippingAddress.Complete() || !req.BillingAddress.Complete() {
+		return nil, fmt.Errorf("shipping and billing addresses with line1, city and country are required")
+	}
+
+	session, err := s.getOwnedSession(ctx, sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.step(ctx, session, []string{statusOpen, statusAddressed, statusShippingSelected}, func(session *models.CheckoutSession) string {
+		session.ShippingAddress = req.ShippingAddress
+		session.BillingAddress = req.BillingAddress
+		session.ShippingMethod = ""
+		return statusAddressed
+	})
+}
+
+func (s *Service) SetShippingMethod(ctx context.Context, sessionID, userID int, req models.SetShippingMethodRequest) (*models.CheckoutSession, error) {
+	if req.ShippingMethod == "" {
+		return nil, fmt.Errorf("shipping method is required")
+	}
+
+	session, err := s.getOwnedSession(ctx, sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.step(ctx, session, []string{statusAddressed, statusShippingSelected}, func(session *models.CheckoutSession) string {
+		session.ShippingMethod = req.ShippingMethod
+		return statusShippingSelected
+	})
+}
+
+func (s *Service) ApplyDiscount(ctx context.Context, sessionID, userID int, req models.ApplyDiscountRequest) (*models.CheckoutSession, error) {
+	if req.Code == "" {
+		return nil, fmt.Errorf("discount code is required")
+	}
+
+	session, err := s.getOwnedSession(ctx, sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.step(ctx, session, []string{statusOpen, statusAddressed, statusShippingSelected}, func(session *models.CheckoutSession) string {
+		session.DiscountCode = req.Code
+		return session.Status
+	})
+}
+
+func (s *Service) RemoveDiscount(ctx context.Context, sessionID, userID int) (*models.CheckoutSession, error) {
+	session, err := s.getOwnedSession(ctx, sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.step(ctx, session, []string{statusOpen, statusAddressed, statusShippingSelected}, func(session *models.CheckoutSession) string {
+		session.DiscountCode = ""
+		return session.Status
+	})
+}
+
+// Confirm re-prices the session one last time and places the order with a
+// pending payment in a single transaction. The session is claimed first, so
+// a double submit cannot create two orders.
+func (s *Service) Confirm(ctx context.Context, sessionID, userID int, req models.ConfirmCheckoutRequest) (*models.CheckoutConfirmation, error) {
+	if req.PaymentMethod == "" {
+		return nil, fmt.Errorf("payment method is required")
+	}
+
+	session, err := s.getOwnedSession(ctx, sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkStatus(ctx, session, []string{statusShippingSelected}); err != nil {
+		return nil, err
+	}
+
+	if err := s.reprice(ctx, session); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateCheckoutSession(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to update checkout session: %w", err)
+	}
+
+	claimed, err := s.repo.TransitionCheckoutSession(ctx, session.ID, statusShippingSelected, statusProcessing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update checkout session: %w", err)
+	}
+	if !claimed {
+		return nil, fmt.Errorf("checkout session is already being confirmed")
+	}
+
+	orderReq := models.CreateOrderRequest{
+		ShippingAddress: session.ShippingAddress,
+		BillingAddress:  session.BillingAddress,
+		ShippingMethod:  session.ShippingMethod,
+		CouponCode:      session.DiscountCode,
+	}
+	for _, item := range session.Items {
+		orderReq.Items = append(orderReq.Items, models.OrderItemRequest{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+		})
+	}
+
+	order, payment, err := s.orderSvc.PlaceOrderWithPayment(ctx, orderReq, userID, req.PaymentMethod)
+	if err != nil {
+		if _, releaseErr := s.repo.TransitionCheckoutSession(ctx, session.ID, statusProcessing, statusShippingSelected); releaseErr != nil {
+			return nil, fmt.Errorf("%w (failed to release checkout session: %v)", err, releaseErr)
+		}
+		return nil, err
+	}
+
+	if err := s.repo.CompleteCheckoutSession(ctx, session.ID, order.ID); err != nil {
+		return nil, fmt.Errorf("failed to complete checkout session: %w", err)
+	}
+
+	session.Status = statusCompleted
+	session.OrderID = &order.ID
+
+	return &models.CheckoutConfirmation{
+		Session: session,
+		Order:   order,
+		Payment: payment,
+	}, nil
+}
+
+// step runs one checkout step: it checks the session may take the step,
+// applies the change, re-prices, saves, and moves to the step's status.
+func (s *Service) step(ctx context.Context, session *models.CheckoutSession, allowed []string, apply func(*models.CheckoutSession) string) (*models.CheckoutSession, error) {
+	if err := s.checkStatus(ctx, session, allowed); err != nil {
+		return nil, err
+	}
+
+	from := session.Status
+	to := apply(session)
+
+	if err := s.reprice(ctx, session); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateCheckoutSession(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to update checkout session: %w", err)
+	}
+
+	if to != from {
+		moved, err := s.repo.TransitionCheckoutSession(ctx, session.ID, from, to)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update checkout session: %w", err)
+		}
+		if !moved {
+			return nil, fmt.Errorf("checkout session was modified concurrently")
+		}
+		session.Status = to
+	}
+
+	return session, nil
+}
+
+// checkStatus expires stale sessions and rejects steps that are not allowed
+// from the session's current status.
+func (s *Service) checkStatus(ctx context.Context, session *models.CheckoutSession, allowed []string) error {
+	if session.Status != statusCompleted && session.Status != statusExpired && time.Now().After(session.ExpiresAt) {
+		if _, err := s.repo.TransitionCheckoutSession(ctx, session.ID, session.Status, statusExpired); err != nil {
+			return fmt.Errorf("failed to update checkout session: %w", err)
+		}
+		session.Status = statusExpired
+	}
+
+	if session.Status == statusExpired {
+		return fmt.Errorf("checkout session has expired")
+	}
+
+	for _, status := range allowed {
+		if session.Status == status {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("checkout session cannot take this step while %s", session.Status)
+}
+
+// reprice loads the current cart and recomputes every total on the session.
+func (s *Service) reprice(ctx context.Context, session *models.CheckoutSession) error {
+	userCart, err := s.cartSvc.GetCart(ctx, session.UserID)
+	if err != nil {
+		return err
+	}
+
+	if len(userCart.Items) == 0 {
+		return fmt.Errorf("cart is empty")
+	}
+
+	if userCart.RequiresAcknowledgement {
+		return fmt.Errorf("cart has changed since items were added; acknowledge the changes before checking out")
+	}
+
+	for _, item := range userCart.Items {
+		if !item.Product.IsActive {
+			return fmt.Errorf("%s is no longer available", item.Product.Name)
+		}
+		if item.Product.StockQuantity < item.Quantity {
+			return fmt.Errorf("insufficient stock for %s", item.Product.Name)
+		}
+	}
+
+	session.Items = userCart.Items
+	session.Subtotal = roundPrice(userCart.TotalPrice)
+
+	session.DiscountAmount = 0
+	session.Discounts = nil
+	freeShipping := false
+	if s.discounter == nil {
+		if session.DiscountCode != "" {
+			return fmt.Errorf("discount codes are not available")
+		}
+	} else {
+		discounts, err := s.discounter.Discounts(ctx, session.UserID, session.DiscountCode, session.Items)
+		if err != nil {
+			return fmt.Errorf("discounts cannot be applied: %w", err)
+		}
+
+		var amount float64
+		for _, discount := range discounts {
+			amount += discount.Amount
+			freeShipping = freeShipping || discount.FreeShipping
+		}
+		session.Discounts = discounts
+		session.DiscountAmount = roundPrice(math.Min(amount, session.Subtotal))
+	}
+
+	if err := s.quoteShipping(ctx, session, freeShipping); err != nil {
+		return err
+	}
+
+	session.TaxAmount = 0
+	session.TaxLines = nil
+	var addedTax float64
+	if s.taxes != nil {
+		tax, err := s.taxes.CartTax(ctx, session.ShippingAddress, session.Items, session.DiscountAmount)
+		if err != nil {
+			return fmt.Errorf("tax cannot be calculated: %w", err)
+		}
+		session.TaxLines = tax.Lines
+		session.TaxAmount = tax.Total
+		addedTax = tax.Added
+	}
+
+	session.TotalAmount = roundPrice(session.Subtotal + session.ShippingCost - session.DiscountAmount + addedTax)
+	return nil
+}
+
+// quoteShipping offers the methods that serve the session's shipping address
+// and prices the selected one.
+func (s *Service) quoteShipping(ctx context.Context, session *models.CheckoutSession, free bool) error {
+	session.ShippingMethods = nil
+	session.ShippingCost = 0
+	if s.shipping != nil && session.ShippingAddress.Country != "" {
+		options, err := s.shipping.Quote(ctx, session.ShippingAddress, session.Items, session.Subtotal-session.DiscountAmount)
+		if err != nil {
+			return fmt.Errorf("shipping cannot be quoted: %w", err)
+		}
+		session.ShippingMethods = options
+	}
+
+	if session.ShippingMethod == "" {
+		return nil
+	}
+
+	for _, option := range session.ShippingMethods {
+		if option.Code == session.ShippingMethod {
+			if !free {
+				session.ShippingCost = option.Cost
+			}
+			return nil
+		}
+	}
+
+	return fmt.Errorf("shipping method %s is not available for this address", session.ShippingMethod)
+}
+
+func (s *Service) getOwnedSession(ctx context.Context, sessionID, userID int) (*models.CheckoutSession, error) {
+	session, err := s.repo.GetCheckoutSession(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("checkout session not found")
+	}
+
+	if session.UserID != userID {
+		return nil, fmt.Errorf("unauthorized access to checkout session")
+	}
+
+	return session, nil
+}
+
+func roundPrice(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
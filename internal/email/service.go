@@ -2,7 +2,10 @@ package email
 
 import (
 	"fmt"
+	"html"
 	"net/smtp"
+	"net/url"
+	"strings"
 
 	"github.com/VishalHilal/e-commerce-api/internal/models"
 )
@@ -114,25 +117,59 @@ func (es *EmailService) SendOrderConfirmationEmail(user *models.User, order *mod
 	return es.SendEmail(msg)
 }
 
-func (es *EmailService) SendShippingConfirmationEmail(user *models.User, order *models.Order) error {
+// SendShippingConfirmationEmail tells the customer that one shipment of
+// their order has left, with its carrier and tracking number.
+func (es *EmailService) SendShippingConfirmationEmail(user *models.User, order *models.Order, shipment *models.Shipment) error {
+	items := 0
+	for _, item := range shipment.Items {
+		items += item.Quantity
+	}
+
 	msg := EmailMessage{
 		To:      []string{user.Email},
 		Subject: fmt.Sprintf("Your Order Has Shipped - %s", order.OrderNumber),
 		Body: fmt.Sprintf(`
 			<h2>Good News! Your Order Has Shipped!</h2>
 			<p>Dear %s,</p>
-			<p>Your order <strong>%s</strong> has been shipped and is on its way to you.</p>
+			<p>A shipment of your order <strong>%s</strong> is on its way to you.</p>
 			
 			<h3>Shipping Information:</h3>
 			<p><strong>Order Number:</strong> %s</p>
+			<p><strong>Items in this shipment:</strong> %d</p>
+			<p><strong>Carrier:</strong> %s</p>
+			<p><strong>Tracking Number:</strong> %s</p>
 			<p><strong>Shipping Address:</strong> %s</p>
 			<p><strong>Estimated Delivery:</strong> 3-5 business days</p>
 			
-			<p>You can track your order using the order number on our website.</p>
+			<p>You can track your shipment using the tracking number with the carrier.</p>
 			
 			<p>Thank you for your patience!</p>
 			<p>Best regards,<br>The E-Commerce Team</p>
-		`, user.FirstName, order.OrderNumber, order.OrderNumber, order.ShippingAddress),
+		`, user.FirstName, order.OrderNumber, order.OrderNumber, items, html.EscapeString(shipment.Carrier), html.EscapeString(shipment.TrackingNumber), order.ShippingAddress),
+		IsHTML: true,
+	}
+
+	return es.SendEmail(msg)
+}
+
+func (es *EmailService) SendOrderCancellationEmail(user *models.User, order *models.Order) error {
+	msg := EmailMessage{
+		To:      []string{user.Email},
+		Subject: fmt.Sprintf("Order Cancelled - %s", order.OrderNumber),
+		Body: fmt.Sprintf(`
+			<h2>Your Order Has Been Cancelled</h2>
+			<p>Dear %s,</p>
+			<p>Your order <strong>%s</strong> has been cancelled.</p>
+			
+			<h3>Order Details:</h3>
+			<p><strong>Order Number:</strong> %s</p>
+			<p><strong>Total Amount:</strong> $%.2f</p>
+			
+			<p>If you paid for this order, the payment has been refunded to your original payment method.</p>
+			
+			<p>We hope to see you again soon.</p>
+			<p>Best regards,<br>The E-Commerce Team</p>
+		`, user.FirstName, order.OrderNumber, order.OrderNumber, order.TotalAmount),
 		IsHTML: true,
 	}
 
@@ -159,3 +196,82 @@ func (es *EmailService) SendPasswordResetEmail(user *models.User, resetToken str
 
 	return es.SendEmail(msg)
 }
+
+func (es *EmailService) SendProductWatchEmail(user *models.User, product *models.Product, watch *models.ProductWatch) error {
+	subject := fmt.Sprintf("%s is back in stock", product.Name)
+	headline := "Good news! An item you are watching is back in stock."
+	if watch.TargetPrice != nil {
+		subject = fmt.Sprintf("Price drop on %s", product.Name)
+		headline = fmt.Sprintf("Good news! An item you are watching is now below $%.2f.", *watch.TargetPrice)
+	}
+
+	msg := EmailMessage{
+		To:      []string{user.Email},
+		Subject: subject,
+		Body: fmt.Sprintf(`
+			<h2>%s</h2>
+			<p>Dear %s,</p>
+			<p><strong>%s</strong> is now available for <strong>$%.2f</strong>.</p>
+			<p><a href="https://yourstore.com/products/%d" style="background-color: #007bff; color: white; padding: 10px 20px; text-decoration: none; border-radius: 5px;">View Product</a></p>
+			<p>Stock is limited, so don't wait too long!</p>
+			<p>Best regards,<br>The E-Commerce Team</p>
+			<p style="font-size: 12px; color: #888;">You received this email because you asked to be notified about this product.
+			<a href="https://yourstore.com/watches/unsubscribe/%s">Unsubscribe</a></p>
+		`, headline, user.FirstName, product.Name, product.Price, product.ID, watch.UnsubscribeToken),
+		IsHTML: true,
+	}
+
+	return es.SendEmail(msg)
+}
+
+// couponAmount formats a coupon's discount by its type: a percentage, or a
+// fixed amount of money.
+func couponAmount(coupon *models.Coupon) string {
+	if coupon.DiscountType == "fixed" {
+		return fmt.Sprintf("$%.2f", coupon.DiscountValue)
+	}
+	return fmt.Sprintf("%g%%", coupon.DiscountValue)
+}
+
+// SendAbandonedCartEmail reminds the user of the items left in their cart.
+// When coupon is set, its code is shown and carried in the link to the cart.
+func (es *EmailService) SendAbandonedCartEmail(user *models.User, items []models.CartItem, coupon *models.Coupon) error {
+	var rows strings.Builder
+	for _, item := range items {
+		name := fmt.Sprintf("Product #%d", item.ProductID)
+		if item.Product != nil {
+			name = item.Product.Name
+		}
+		fmt.Fprintf(&rows, "<li>%d &times; %s</li>", item.Quantity, html.EscapeString(name))
+	}
+
+	cartURL := "https://yourstore.com/cart"
+	offer := ""
+	if coupon != nil {
+		cartURL += "?coupon=" + url.QueryEscape(coupon.Code)
+		offer = fmt.Sprintf(`<p>Complete your order with code <strong>%s</strong> for %s off. The code can be used once`,
+			coupon.Code, couponAmount(coupon))
+		if coupon.EndsAt != nil {
+			offer += fmt.Sprintf(" until %s", coupon.EndsAt.Format("January 2, 2006"))
+		}
+		offer += ".</p>"
+	}
+
+	msg := EmailMessage{
+		To:      []string{user.Email},
+		Subject: "You left something in your cart",
+		Body: fmt.Sprintf(`
+			<h2>Still thinking it over?</h2>
+			<p>Dear %s,</p>
+			<p>You left these items in your cart:</p>
+			<ul>%s</ul>
+			%s
+			<p><a href="%s" style="background-color: #007bff; color: white; padding: 10px 20px; text-decoration: none; border-radius: 5px;">Return to Your Cart</a></p>
+			<p>Items in your cart are not reserved, so don't wait too long!</p>
+			<p>Best regards,<br>The E-Commerce Team</p>
+		`, user.FirstName, rows.String(), offer, cartURL),
+		IsHTML: true,
+	}
+
+	return es.SendEmail(msg)
+}
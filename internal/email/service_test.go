@@ -0,0 +1,25 @@
+package email
+
+import (
+	"testing"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+)
+
+func TestCouponAmount(t *testing.T) {
+	tests := []struct {
+		coupon models.Coupon
+		want   string
+	}{
+		{models.Coupon{DiscountType: "percentage", DiscountValue: 10}, "10%"},
+		{models.Coupon{DiscountType: "percentage", DiscountValue: 12.5}, "12.5%"},
+		{models.Coupon{DiscountType: "fixed", DiscountValue: 5}, "$5.00"},
+		{models.Coupon{DiscountType: "fixed", DiscountValue: 7.5}, "$7.50"},
+	}
+
+	for _, tt := range tests {
+		if got := couponAmount(&tt.coupon); got != tt.want {
+			t.Errorf("couponAmount(%s %v) = %q, want %q", tt.coupon.DiscountType, tt.coupon.DiscountValue, got, tt.want)
+		}
+	}
+}
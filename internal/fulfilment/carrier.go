@@ -0,0 +1,51 @@
+package fulfilment
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+)
+
+// Tracking statuses carriers report parcels in. In transit and out for
+// delivery mean a shipment has left; delivered means it has arrived.
+const (
+	trackingLabelCreated   = "label_created"
+	trackingInTransit      = "in_transit"
+	trackingOutForDelivery = "out_for_delivery"
+	trackingDelivered      = "delivered"
+	trackingException      = "exception"
+)
+
+// Label formats a carrier can print labels in.
+const (
+	labelFormatPDF = "pdf"
+	labelFormatZPL = "zpl"
+)
+
+// Carrier is a shipping company the store sends parcels with.
+//
+// Rates prices a parcel with each of the carrier's services. CreateLabel buys
+// a label for a parcel with one service and returns it with its tracking
+// number, cost and printable data in the format asked for. Track looks up a
+// parcel's journey so far. ParseWebhook verifies a tracking webhook sent by
+// the carrier and returns the events in it.
+type Carrier interface {
+	Name() string
+	Rates(ctx context.Context, parcel models.Parcel) ([]models.CarrierRate, error)
+	CreateLabel(ctx context.Context, parcel models.Parcel, service, format string) (*models.ShipmentLabel, error)
+	Track(ctx context.Context, trackingNumber string) (*models.TrackingInfo, error)
+	ParseWebhook(header http.Header, body []byte) ([]models.TrackingEvent, error)
+}
+
+// shipmentStatus is the shipment status a tracking status moves a shipment
+// to, if any.
+func shipmentStatus(trackingStatus string) string {
+	switch trackingStatus {
+	case trackingInTransit, trackingOutForDelivery:
+		return statusShipped
+	case trackingDelivered:
+		return statusDelivered
+	}
+	return ""
+}
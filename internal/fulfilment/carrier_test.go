@@ -0,0 +1,163 @@
+package fulfilment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+)
+
+func TestShipmentStatus(t *testing.T) {
+	tests := []struct {
+		trackingStatus string
+		want           string
+	}{
+		{trackingLabelCreated, ""},
+		{trackingInTransit, statusShipped},
+		{trackingOutForDelivery, statusShipped},
+		{trackingDelivered, statusDelivered},
+		{trackingException, ""},
+		{"unknown", ""},
+	}
+
+	for _, tt := range tests {
+		if got := shipmentStatus(tt.trackingStatus); got != tt.want {
+			t.Errorf("shipmentStatus(%q) = %q, want %q", tt.trackingStatus, got, tt.want)
+		}
+	}
+}
+
+func TestFakeCarrierRates(t *testing.T) {
+	carrier := NewFakeCarrier("")
+
+	tests := []struct {
+		weightKg float64
+		want     map[string]float64
+	}{
+		{0, map[string]float64{"ground": 5.54, "express": 11.09, "overnight": 21.74}},
+		{0.5, map[string]float64{"ground": 5.54, "express": 11.09, "overnight": 21.74}},
+		{2, map[string]float64{"ground": 7.19, "express": 14.39, "overnight": 26.99}},
+	}
+
+	for _, tt := range tests {
+		for run := 0; run < 2; run++ {
+			rates, err := carrier.Rates(context.Background(), models.Parcel{WeightKg: tt.weightKg})
+			if err != nil {
+				t.Fatalf("Rates(%v): %v", tt.weightKg, err)
+			}
+			if len(rates) != len(tt.want) {
+				t.Fatalf("Rates(%v) returned %d rates, want %d", tt.weightKg, len(rates), len(tt.want))
+			}
+			for _, rate := range rates {
+				if rate.Carrier != "fake" || rate.Amount != tt.want[rate.Service] {
+					t.Errorf("Rates(%v) %s = %s %v, want fake %v", tt.weightKg, rate.Service, rate.Carrier, rate.Amount, tt.want[rate.Service])
+				}
+			}
+		}
+	}
+}
+
+func TestFakeCarrierCreateLabel(t *testing.T) {
+	carrier := NewFakeCarrier("")
+	parcel := models.Parcel{Reference: "ORD-1234-S1", WeightKg: 1}
+
+	tests := []struct {
+		service string
+		format  string
+		wantErr bool
+	}{
+		{"ground", labelFormatPDF, false},
+		{"express", labelFormatZPL, false},
+		{"teleport", labelFormatPDF, true},
+		{"ground", "png", true},
+	}
+
+	for _, tt := range tests {
+		first, err := carrier.CreateLabel(context.Background(), parcel, tt.service, tt.format)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("CreateLabel(%s, %s) succeeded, want error", tt.service, tt.format)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("CreateLabel(%s, %s): %v", tt.service, tt.format, err)
+		}
+
+		second, err := carrier.CreateLabel(context.Background(), parcel, tt.service, tt.format)
+		if err != nil {
+			t.Fatalf("CreateLabel(%s, %s): %v", tt.service, tt.format, err)
+		}
+		if first.TrackingNumber != second.TrackingNumber || !strings.HasPrefix(first.TrackingNumber, "FAKE") {
+			t.Errorf("CreateLabel(%s) tracking numbers = %q and %q, want the same FAKE number", tt.service, first.TrackingNumber, second.TrackingNumber)
+		}
+		if first.Cost != second.Cost || len(first.Data) == 0 {
+			t.Errorf("CreateLabel(%s) = cost %v and %v with %d bytes, want the same cost and a label", tt.service, first.Cost, second.Cost, len(first.Data))
+		}
+	}
+
+	ground, _ := carrier.CreateLabel(context.Background(), parcel, "ground", labelFormatPDF)
+	express, _ := carrier.CreateLabel(context.Background(), parcel, "express", labelFormatPDF)
+	if ground.TrackingNumber == express.TrackingNumber {
+		t.Errorf("ground and express share tracking number %q", ground.TrackingNumber)
+	}
+}
+
+func TestFakeCarrierParseWebhook(t *testing.T) {
+	const secret = "s3cret"
+	body := []byte(`{"events":[{"id":"evt-1","tracking_number":"FAKE0123456789AB","status":"in_transit","occurred_at":"2024-05-01T10:00:00Z"}]}`)
+
+	tests := []struct {
+		name       string
+		secret     string
+		body       []byte
+		signWith   string
+		signature  string
+		wantEvents int
+		wantErr    bool
+	}{
+		{name: "signed", secret: secret, body: body, signWith: secret, wantEvents: 1},
+		{name: "no secret", secret: "", body: body, signWith: "", wantErr: true},
+		{name: "wrong key", secret: secret, body: body, signWith: "other", wantErr: true},
+		{name: "not hex", secret: secret, body: body, signature: "zz", wantErr: true},
+		{name: "unsigned", secret: secret, body: body, wantErr: true},
+		{name: "unknown status", secret: secret, body: []byte(`{"events":[{"id":"evt-1","tracking_number":"FAKE1","status":"lost","occurred_at":"2024-05-01T10:00:00Z"}]}`), signWith: secret, wantErr: true},
+		{name: "missing id", secret: secret, body: []byte(`{"events":[{"tracking_number":"FAKE1","status":"delivered","occurred_at":"2024-05-01T10:00:00Z"}]}`), signWith: secret, wantErr: true},
+		{name: "bad json", secret: secret, body: []byte(`{`), signWith: secret, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			signature := tt.signature
+			if tt.signWith != "" {
+				mac := hmac.New(sha256.New, []byte(tt.signWith))
+				mac.Write(tt.body)
+				signature = hex.EncodeToString(mac.Sum(nil))
+			}
+			header.Set(FakeCarrierSignatureHeader, signature)
+
+			events, err := NewFakeCarrier(tt.secret).ParseWebhook(header, tt.body)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseWebhook succeeded, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseWebhook: %v", err)
+			}
+			if len(events) != tt.wantEvents {
+				t.Fatalf("ParseWebhook returned %d events, want %d", len(events), tt.wantEvents)
+			}
+			if events[0].Status != trackingInTransit || events[0].EventID != "evt-1" {
+				t.Errorf("ParseWebhook event = %+v", events[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package fulfilment
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/VishalHilal/e-commerce-api/internal/auth"
+	"github.com/VishalHilal/e-commerce-api/internal/json"
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+	"github.com/go-chi/chi/v5"
+)
+
+// maxWebhookBytes caps the size of a carrier webhook body.
+const maxWebhookBytes = 1 << 20
+
+type handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *handler {
+	return &handler{service: service}
+}
+
+func (h *handler) GetOrderShipments(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil || claims.Role != "admin" {
+		json.WriteError(w, http.StatusForbidden, "Admin access required")
+		return
+	}
+
+	orderID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	shipments, err := h.service.GetOrderShipments(r.Context(), orderID)
+	if err != nil {
+		json.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, map[string]interface{}{
+		"shipments": shipments,
+		"count":     len(shipments),
+	})
+}
+
+func (h *handler) CreateShipment(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil || claims.Role != "admin" {
+		json.WriteError(w, http.StatusForbidden, "Admin access required")
+		return
+	}
+
+	orderID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	var req models.CreateShipmentRequest
+	if err := json.Read(r, &req); err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	shipment, err := h.service.CreateShipment(r.Context(), orderID, req, actor(claims))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusCreated, shipment)
+}
+
+func (h *handler) ShipShipment(w http.ResponseWriter, r *http.Request) {
+	claims, shipmentID, ok := adminShipment(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.ShipShipmentRequest
+	if err := json.Read(r, &req); err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	shipment, err := h.service.ShipShipment(r.Context(), shipmentID, req, actor(claims))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, shipment)
+}
+
+func (h *handler) DeliverShipment(w http.ResponseWriter, r *http.Request) {
+	claims, shipmentID, ok := adminShipment(w, r)
+	if !ok {
+		return
+	}
+
+	shipment, err := h.service.DeliverShipment(r.Context(), shipmentID, actor(claims))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, shipment)
+}
+
+func (h *handler) GetRates(w http.ResponseWriter, r *http.Request) {
+	_, shipmentID, ok := adminShipment(w, r)
+	if !ok {
+		return
+	}
+
+	rates, err := h.service.GetRates(r.Context(), shipmentID, r.URL.Query().Get("carrier"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, map[string]interface{}{
+		"rates": rates,
+		"count": len(rates),
+	})
+}
+
+func (h *handler) BuyLabel(w http.ResponseWriter, r *http.Request) {
+	claims, shipmentID, ok := adminShipment(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.BuyLabelRequest
+	if err := json.Read(r, &req); err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	label, err := h.service.BuyLabel(r.Context(), shipmentID, req, actor(claims))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusCreated, label)
+}
+
+// GetLabel downloads a shipment's label, ready to print.
+func (h *handler) GetLabel(w http.ResponseWriter, r *http.Request) {
+	_, shipmentID, ok := adminShipment(w, r)
+	if !ok {
+		return
+	}
+
+	label, err := h.service.GetLabel(r.Context(), shipmentID)
+	if err != nil {
+		json.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	contentType := "application/pdf"
+	if label.Format == labelFormatZPL {
+		contentType = "application/x-zpl"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="label-%s.%s"`, label.TrackingNumber, label.Format))
+	w.WriteHeader(http.StatusOK)
+	w.Write(label.Data)
+}
+
+func (h *handler) Track(w http.ResponseWriter, r *http.Request) {
+	_, shipmentID, ok := adminShipment(w, r)
+	if !ok {
+		return
+	}
+
+	info, err := h.service.Track(r.Context(), shipmentID)
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, info)
+}
+
+// CarrierWebhook receives tracking updates from a carrier. The carrier
+// authenticates the request itself, so it needs no user.
+func (h *handler) CarrierWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBytes))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	recorded, err := h.service.HandleWebhook(r.Context(), chi.URLParam(r, "carrier"), r.Header, body)
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, map[string]interface{}{
+		"recorded": recorded,
+	})
+}
+
+// adminShipment checks that the request comes from an admin and parses the
+// shipment ID, writing the error response if either fails.
+func adminShipment(w http.ResponseWriter, r *http.Request) (*auth.JWTClaims, int, bool) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil || claims.Role != "admin" {
+		json.WriteError(w, http.StatusForbidden, "Admin access required")
+		return nil, 0, false
+	}
+
+	shipmentID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid shipment ID")
+		return nil, 0, false
+	}
+
+	return claims, shipmentID, true
+}
+
+func actor(claims *auth.JWTClaims) models.OrderActor {
+	userID := claims.UserID
+	return models.OrderActor{ID: &userID, Role: claims.Role}
+}
@@ -0,0 +1,448 @@
+package fulfilment
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/VishalHilal/e-commerce-api/internal/email"
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+)
+
+// Shipment statuses. A shipment is packed pending, shipped once it leaves
+// with a carrier and tracking number, and delivered when it arrives.
+const (
+	statusPending   = "pending"
+	statusShipped   = "shipped"
+	statusDelivered = "delivered"
+)
+
+type Repository interface {
+	CreateShipment(ctx context.Context, orderID int, req models.CreateShipmentRequest, actor models.OrderActor) (*models.Shipment, error)
+	ShipShipment(ctx context.Context, id int, carrier, trackingNumber string, actor models.OrderActor) (bool, error)
+	DeliverShipment(ctx context.Context, id int, actor models.OrderActor) (bool, error)
+	GetShipmentByID(ctx context.Context, id int) (*models.Shipment, error)
+	GetShipmentsByOrderID(ctx context.Context, orderID int) ([]models.Shipment, error)
+	GetShipmentByTrackingNumber(ctx context.Context, carrier, trackingNumber string) (*models.Shipment, error)
+	CreateShipmentLabel(ctx context.Context, label models.ShipmentLabel, actor models.OrderActor) (*models.ShipmentLabel, error)
+	GetShipmentLabel(ctx context.Context, shipmentID int) (*models.ShipmentLabel, error)
+	RecordTrackingEvent(ctx context.Context, shipmentID int, event models.TrackingEvent, status string, actor models.OrderActor) (bool, error)
+	GetOrderByID(ctx context.Context, id int) (*models.Order, error)
+	GetPaymentsByOrderID(ctx context.Context, orderID int) ([]models.Payment, error)
+	GetUserByID(ctx context.Context, id int) (*models.User, error)
+	GetProductByID(ctx context.Context, id int) (*models.Product, error)
+}
+
+type Service struct {
+	repo     Repository
+	emailSvc *email.EmailService
+	carriers map[string]Carrier
+}
+
+func NewService(repo Repository, emailSvc *email.EmailService, carriers []Carrier) *Service {
+	byName := make(map[string]Carrier, len(carriers))
+	for _, carrier := range carriers {
+		byName[carrier.Name()] = carrier
+	}
+
+	return &Service{
+		repo:     repo,
+		emailSvc: emailSvc,
+		carriers: byName,
+	}
+}
+
+// CreateShipment packs some of a confirmed, paid order's items into a
+// shipment. Items can ship in part, and over several shipments, up to the
+// quantity ordered. Given a carrier and tracking number the shipment leaves
+// at once.
+func (s *Service) CreateShipment(ctx context.Context, orderID int, req models.CreateShipmentRequest, actor models.OrderActor) (*models.Shipment, error) {
+	if len(req.Items) == 0 {
+		return nil, fmt.Errorf("at least one item is required")
+	}
+
+	seen := make(map[int]bool)
+	for _, item := range req.Items {
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("quantity must be greater than 0")
+		}
+		if seen[item.OrderItemID] {
+			return nil, fmt.Errorf("order item %d is listed more than once", item.OrderItemID)
+		}
+		seen[item.OrderItemID] = true
+	}
+
+	req.Carrier = strings.TrimSpace(req.Carrier)
+	req.TrackingNumber = strings.TrimSpace(req.TrackingNumber)
+	if (req.Carrier == "") != (req.TrackingNumber == "") {
+		return nil, fmt.Errorf("carrier and tracking number must be given together")
+	}
+
+	if err := s.requireShippable(ctx, orderID); err != nil {
+		return nil, err
+	}
+
+	shipment, err := s.repo.CreateShipment(ctx, orderID, req, actor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create shipment: %w", err)
+	}
+
+	if shipment.Status == statusShipped {
+		s.notify(ctx, shipment)
+	}
+
+	return shipment, nil
+}
+
+// ShipShipment sends a pending shipment of a shippable order off with a
+// carrier and tracking number and emails the customer about it. A shipment with a label keeps
+// the label's carrier and tracking number unless others are given.
+func (s *Service) ShipShipment(ctx context.Context, shipmentID int, req models.ShipShipmentRequest, actor models.OrderActor) (*models.Shipment, error) {
+	current, err := s.repo.GetShipmentByID(ctx, shipmentID)
+	if err != nil {
+		return nil, fmt.Errorf("shipment not found")
+	}
+
+	if err := s.requireShippable(ctx, current.OrderID); err != nil {
+		return nil, err
+	}
+
+	carrier := strings.TrimSpace(req.Carrier)
+	trackingNumber := strings.TrimSpace(req.TrackingNumber)
+	if carrier == "" && trackingNumber == "" {
+		carrier, trackingNumber = current.Carrier, current.TrackingNumber
+	}
+	if carrier == "" || trackingNumber == "" {
+		return nil, fmt.Errorf("carrier and tracking number are required")
+	}
+
+	shipment, err := s.transition(ctx, shipmentID, statusPending, func() (bool, error) {
+		return s.repo.ShipShipment(ctx, shipmentID, carrier, trackingNumber, actor)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(ctx, shipment)
+	return shipment, nil
+}
+
+// DeliverShipment records that a shipped shipment has arrived.
+func (s *Service) DeliverShipment(ctx context.Context, shipmentID int, actor models.OrderActor) (*models.Shipment, error) {
+	return s.transition(ctx, shipmentID, statusShipped, func() (bool, error) {
+		return s.repo.DeliverShipment(ctx, shipmentID, actor)
+	})
+}
+
+func (s *Service) GetOrderShipments(ctx context.Context, orderID int) ([]models.Shipment, error) {
+	if _, err := s.repo.GetOrderByID(ctx, orderID); err != nil {
+		return nil, fmt.Errorf("order not found")
+	}
+
+	shipments, err := s.repo.GetShipmentsByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shipments: %w", err)
+	}
+	return shipments, nil
+}
+
+// GetRates prices a shipment with the services of each carrier, or of the
+// one named.
+func (s *Service) GetRates(ctx context.Context, shipmentID int, carrierName string) ([]models.CarrierRate, error) {
+	carriers := make([]Carrier, 0, len(s.carriers))
+	if carrierName != "" {
+		carrier, err := s.carrier(carrierName)
+		if err != nil {
+			return nil, err
+		}
+		carriers = append(carriers, carrier)
+	} else {
+		for _, carrier := range s.carriers {
+			carriers = append(carriers, carrier)
+		}
+	}
+
+	shipment, err := s.repo.GetShipmentByID(ctx, shipmentID)
+	if err != nil {
+		return nil, fmt.Errorf("shipment not found")
+	}
+
+	parcel, err := s.parcel(ctx, shipment)
+	if err != nil {
+		return nil, err
+	}
+
+	rates := []models.CarrierRate{}
+	for _, carrier := range carriers {
+		carrierRates, err := carrier.Rates(ctx, parcel)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s rates: %w", carrier.Name(), err)
+		}
+		rates = append(rates, carrierRates...)
+	}
+
+	sort.Slice(rates, func(i, j int) bool {
+		if rates[i].Amount != rates[j].Amount {
+			return rates[i].Amount < rates[j].Amount
+		}
+		return rates[i].Carrier+rates[i].Service < rates[j].Carrier+rates[j].Service
+	})
+	return rates, nil
+}
+
+// BuyLabel buys a carrier label for a pending shipment, PDF unless ZPL is
+// asked for. The shipment takes the label's carrier and tracking number and
+// ships once the carrier reports it picked up, or when shipped by hand.
+func (s *Service) BuyLabel(ctx context.Context, shipmentID int, req models.BuyLabelRequest, actor models.OrderActor) (*models.ShipmentLabel, error) {
+	carrierName := strings.TrimSpace(req.Carrier)
+	if carrierName == "" && len(s.carriers) == 1 {
+		for name := range s.carriers {
+			carrierName = name
+		}
+	}
+	carrier, err := s.carrier(carrierName)
+	if err != nil {
+		return nil, err
+	}
+
+	format := req.Format
+	if format == "" {
+		format = labelFormatPDF
+	}
+	if format != labelFormatPDF && format != labelFormatZPL {
+		return nil, fmt.Errorf("format must be pdf or zpl")
+	}
+
+	shipment, err := s.repo.GetShipmentByID(ctx, shipmentID)
+	if err != nil {
+		return nil, fmt.Errorf("shipment not found")
+	}
+
+	if shipment.Status != statusPending {
+		return nil, fmt.Errorf("only pending shipments can be labelled")
+	}
+	if _, err := s.repo.GetShipmentLabel(ctx, shipmentID); err == nil {
+		return nil, fmt.Errorf("shipment already has a label")
+	}
+
+	parcel, err := s.parcel(ctx, shipment)
+	if err != nil {
+		return nil, err
+	}
+
+	label, err := carrier.CreateLabel(ctx, parcel, req.Service, format)
+	if err != nil {
+		return nil, fmt.Errorf("carrier refused the label: %w", err)
+	}
+	label.ShipmentID = shipment.ID
+	label.Carrier = carrier.Name()
+	label.CreatedBy = actor.ID
+
+	created, err := s.repo.CreateShipmentLabel(ctx, *label, actor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save label: %w", err)
+	}
+	return created, nil
+}
+
+func (s *Service) GetLabel(ctx context.Context, shipmentID int) (*models.ShipmentLabel, error) {
+	label, err := s.repo.GetShipmentLabel(ctx, shipmentID)
+	if err != nil {
+		return nil, fmt.Errorf("label not found")
+	}
+	return label, nil
+}
+
+// Track looks up a labelled shipment with its carrier.
+func (s *Service) Track(ctx context.Context, shipmentID int) (*models.TrackingInfo, error) {
+	shipment, err := s.repo.GetShipmentByID(ctx, shipmentID)
+	if err != nil {
+		return nil, fmt.Errorf("shipment not found")
+	}
+
+	carrier, ok := s.carriers[shipment.Carrier]
+	if !ok || shipment.TrackingNumber == "" {
+		return nil, fmt.Errorf("shipment is not sent with a carrier that can be tracked")
+	}
+
+	info, err := carrier.Track(ctx, shipment.TrackingNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to track shipment: %w", err)
+	}
+	return info, nil
+}
+
+// HandleWebhook applies the tracking events of a carrier's webhook to the
+// shipments they belong to: each new event is added to the order's timeline
+// and may ship or deliver the shipment. Events already recorded and events
+// for tracking numbers the store does not know are skipped. It returns the
+// number of events recorded.
+func (s *Service) HandleWebhook(ctx context.Context, carrierName string, header http.Header, body []byte) (int, error) {
+	carrier, err := s.carrier(carrierName)
+	if err != nil {
+		return 0, err
+	}
+
+	events, err := carrier.ParseWebhook(header, body)
+	if err != nil {
+		return 0, err
+	}
+
+	actor := models.OrderActor{Role: "system"}
+	recorded := 0
+	for _, event := range events {
+		shipment, err := s.repo.GetShipmentByTrackingNumber(ctx, carrier.Name(), event.TrackingNumber)
+		if err != nil {
+			slog.Warn("tracking event for unknown shipment", "carrier", carrier.Name(), "tracking_number", event.TrackingNumber)
+			continue
+		}
+
+		ok, err := s.repo.RecordTrackingEvent(ctx, shipment.ID, event, shipmentStatus(event.Status), actor)
+		if err != nil {
+			return recorded, fmt.Errorf("failed to record tracking event: %w", err)
+		}
+		if !ok {
+			continue
+		}
+		recorded++
+
+		// A shipment that has left, even one first reported delivered, gets
+		// its shipping email.
+		if shipment.Status == statusPending {
+			updated, err := s.repo.GetShipmentByID(ctx, shipment.ID)
+			if err == nil && updated.Status != statusPending {
+				s.notify(ctx, updated)
+			}
+		}
+	}
+
+	return recorded, nil
+}
+
+func (s *Service) carrier(name string) (Carrier, error) {
+	carrier, ok := s.carriers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown carrier: %s", name)
+	}
+	return carrier, nil
+}
+
+// parcel describes a shipment to a carrier: its weight from the products
+// packed in it and the order's shipping address.
+func (s *Service) parcel(ctx context.Context, shipment *models.Shipment) (models.Parcel, error) {
+	order, err := s.repo.GetOrderByID(ctx, shipment.OrderID)
+	if err != nil {
+		return models.Parcel{}, fmt.Errorf("order not found")
+	}
+
+	products := make(map[int]int)
+	for _, item := range order.OrderItems {
+		products[item.ID] = item.ProductID
+	}
+
+	var weight float64
+	for _, item := range shipment.Items {
+		product, err := s.repo.GetProductByID(ctx, products[item.OrderItemID])
+		if err != nil {
+			return models.Parcel{}, fmt.Errorf("failed to get product: %w", err)
+		}
+		weight += product.WeightKg * float64(item.Quantity)
+	}
+
+	return models.Parcel{
+		Reference: fmt.Sprintf("%s-%d", order.OrderNumber, shipment.ID),
+		WeightKg:  math.Round(weight*1000) / 1000,
+		To:        order.ShippingAddress,
+	}, nil
+}
+
+// requireShippable loads the order and its payments and checks the order
+// can ship.
+func (s *Service) requireShippable(ctx context.Context, orderID int) error {
+	order, err := s.repo.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("order not found")
+	}
+
+	payments, err := s.repo.GetPaymentsByOrderID(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("failed to get payments: %w", err)
+	}
+
+	return checkShippable(order, payments)
+}
+
+// checkShippable checks the order is confirmed, or already partly shipped,
+// and paid for. Every shipment also leaves with a tracking number, so an
+// order only becomes shipped once paid and tracked. An order with nothing to
+// pay, such as an exchange's replacement, needs no payment.
+func checkShippable(order *models.Order, payments []models.Payment) error {
+	if order.Status != "confirmed" && order.Status != "partially_shipped" {
+		return fmt.Errorf("a %s order cannot be shipped", order.Status)
+	}
+
+	if order.TotalAmount == 0 {
+		return nil
+	}
+	for _, payment := range payments {
+		if payment.PaymentStatus == "completed" {
+			return nil
+		}
+	}
+	return fmt.Errorf("order must have a completed payment to be shipped")
+}
+
+// transition applies a guarded status update to a shipment in the from
+// status and returns the shipment as saved.
+func (s *Service) transition(ctx context.Context, shipmentID int, from string, update func() (bool, error)) (*models.Shipment, error) {
+	shipment, err := s.repo.GetShipmentByID(ctx, shipmentID)
+	if err != nil {
+		return nil, fmt.Errorf("shipment not found")
+	}
+
+	if shipment.Status != from {
+		return nil, fmt.Errorf("shipment is %s, not %s", shipment.Status, from)
+	}
+
+	moved, err := update()
+	if err != nil {
+		return nil, fmt.Errorf("failed to update shipment: %w", err)
+	}
+	if !moved {
+		return nil, fmt.Errorf("shipment was modified concurrently")
+	}
+
+	shipment, err = s.repo.GetShipmentByID(ctx, shipmentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shipment: %w", err)
+	}
+	return shipment, nil
+}
+
+// notify emails the customer that a shipment has left. A failed email does
+// not undo the shipment.
+func (s *Service) notify(ctx context.Context, shipment *models.Shipment) {
+	if s.emailSvc == nil {
+		return
+	}
+
+	order, err := s.repo.GetOrderByID(ctx, shipment.OrderID)
+	if err != nil {
+		slog.Error("failed to load order for shipping email", "shipment_id", shipment.ID, "error", err)
+		return
+	}
+
+	user, err := s.repo.GetUserByID(ctx, order.UserID)
+	if err != nil {
+		slog.Error("failed to load user for shipping email", "shipment_id", shipment.ID, "error", err)
+		return
+	}
+
+	if err := s.emailSvc.SendShippingConfirmationEmail(user, order, shipment); err != nil {
+		slog.Error("failed to send shipping email", "shipment_id", shipment.ID, "error", err)
+	}
+}
@@ -0,0 +1,35 @@
+package fulfilment
+
+import (
+	"testing"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+)
+
+func TestCheckShippable(t *testing.T) {
+	completed := []models.Payment{{PaymentStatus: "pending"}, {PaymentStatus: "completed"}}
+
+	tests := []struct {
+		name     string
+		order    models.Order
+		payments []models.Payment
+		wantErr  bool
+	}{
+		{"confirmed and paid", models.Order{Status: "confirmed", TotalAmount: 20}, completed, false},
+		{"partially shipped and paid", models.Order{Status: "partially_shipped", TotalAmount: 20}, completed, false},
+		{"confirmed without a completed payment", models.Order{Status: "confirmed", TotalAmount: 20}, completed[:1], true},
+		{"nothing to pay", models.Order{Status: "confirmed"}, nil, false},
+		{"pending", models.Order{Status: "pending", TotalAmount: 20}, completed, true},
+		{"cancelled", models.Order{Status: "cancelled", TotalAmount: 20}, completed, true},
+		{"already shipped", models.Order{Status: "shipped", TotalAmount: 20}, completed, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkShippable(&tt.order, tt.payments)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkShippable error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package invoices
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/VishalHilal/e-commerce-api/internal/auth"
+	"github.com/VishalHilal/e-commerce-api/internal/json"
+	"github.com/go-chi/chi/v5"
+)
+
+type handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *handler {
+	return &handler{service: service}
+}
+
+func (h *handler) GetInvoicePDF(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		json.WriteError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	orderID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	invoice, err := h.service.InvoicePDF(r.Context(), orderID, claims.UserID, claims.Role == "admin")
+	if err != nil {
+		json.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	writePDF(w, invoice.Number, invoice.PDF)
+}
+
+func (h *handler) GetCreditNotePDF(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		json.WriteError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	orderID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	creditNoteID, err := strconv.Atoi(chi.URLParam(r, "credit_note_id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid credit note ID")
+		return
+	}
+
+	note, err := h.service.CreditNotePDF(r.Context(), orderID, creditNoteID, claims.UserID, claims.Role == "admin")
+	if err != nil {
+		json.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	writePDF(w, note.Number, note.PDF)
+}
+
+func (h *handler) GetInvoice(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil || claims.Role != "admin" {
+		json.WriteError(w, http.StatusForbidden, "Admin access required")
+		return
+	}
+
+	orderID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	invoice, err := h.service.GetInvoice(r.Context(), orderID)
+	if err != nil {
+		json.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, invoice)
+}
+
+func (h *handler) RenderInvoice(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil || claims.Role != "admin" {
+		json.WriteError(w, http.StatusForbidden, "Admin access required")
+		return
+	}
+
+	orderID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	invoice, err := h.service.RenderInvoice(r.Context(), orderID)
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, invoice)
+}
+
+func writePDF(w http.ResponseWriter, number string, data []byte) {
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="%s.pdf"`, number))
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
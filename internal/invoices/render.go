@@ -0,0 +1,200 @@
+package invoices
+
+import (
+	"fmt"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+	"github.com/VishalHilal/e-commerce-api/internal/pdf"
+)
+
+// Page layout in points: A4 with a margin all round.
+const (
+	pageWidth  = 595
+	pageHeight = 842
+	margin     = 40
+)
+
+// page writes rows of text down A4 pages, starting a new page when one is
+// full.
+type page struct {
+	doc *pdf.Document
+	y   float64
+}
+
+type cell struct {
+	x    float64
+	text string
+}
+
+func newPage() *page {
+	return &page{doc: pdf.New(pageWidth, pageHeight), y: pageHeight - margin}
+}
+
+func (p *page) row(size float64, bold bool, cells ...cell) {
+	if p.y-size < margin {
+		p.doc.AddPage()
+		p.y = pageHeight - margin
+	}
+	p.y -= size
+	for _, c := range cells {
+		p.doc.Text(c.x, p.y, size, bold, c.text)
+	}
+	p.y -= 5
+}
+
+func (p *page) rule() {
+	p.doc.Line(margin, p.y, pageWidth-margin, p.y)
+	p.y -= 8
+}
+
+func (p *page) space(points float64) {
+	p.y -= points
+}
+
+// parties writes the seller and the buyer side by side.
+func (p *page) parties(seller, buyer models.InvoiceParty) {
+	p.row(10, true, cell{margin, "From"}, cell{pageWidth / 2, "Bill to"})
+
+	left, right := partyLines(seller), partyLines(buyer)
+	for i := 0; i < len(left) || i < len(right); i++ {
+		var cells []cell
+		if i < len(left) {
+			cells = append(cells, cell{margin, left[i]})
+		}
+		if i < len(right) {
+			cells = append(cells, cell{pageWidth / 2, right[i]})
+		}
+		p.row(10, false, cells...)
+	}
+}
+
+func partyLines(party models.InvoiceParty) []string {
+	address := party.Address
+	var lines []string
+	for _, line := range []string{
+		party.Name,
+		address.Line1,
+		address.Line2,
+		joinNonEmpty(address.PostalCode, address.City),
+		joinNonEmpty(address.Region, address.Country),
+		party.Email,
+	} {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if party.TaxID != "" {
+		lines = append(lines, "Tax ID: "+party.TaxID)
+	}
+	return lines
+}
+
+func renderInvoice(invoice *models.Invoice) []byte {
+	p := newPage()
+	p.row(20, true, cell{margin, "INVOICE"})
+	p.space(6)
+	p.row(10, false, cell{margin, "Invoice number: " + invoice.Number})
+	p.row(10, false, cell{margin, "Issued: " + invoice.IssuedAt.Format("2 January 2006")})
+	p.row(10, false, cell{margin, "Order: " + invoice.OrderNumber})
+	p.space(12)
+	p.parties(invoice.Seller, invoice.Buyer)
+	p.space(16)
+
+	columns := []float64{margin, 300, 340, 410, 460, 510}
+	p.row(10, true,
+		cell{columns[0], "Description"},
+		cell{columns[1], "Qty"},
+		cell{columns[2], "Unit price"},
+		cell{columns[3], "Tax rate"},
+		cell{columns[4], "Tax"},
+		cell{columns[5], "Total"},
+	)
+	p.rule()
+	for _, line := range invoice.Lines {
+		p.row(10, false,
+			cell{columns[0], truncate(line.Description, 45)},
+			cell{columns[1], fmt.Sprintf("%d", line.Quantity)},
+			cell{columns[2], money(line.UnitPrice)},
+			cell{columns[3], fmt.Sprintf("%.2f%%", line.TaxRate)},
+			cell{columns[4], money(line.TaxAmount)},
+			cell{columns[5], money(line.Total)},
+		)
+	}
+	p.rule()
+
+	p.row(10, false, cell{columns[3], "Subtotal"}, cell{columns[5], money(invoice.Subtotal)})
+	if invoice.DiscountAmount > 0 {
+		p.row(10, false, cell{columns[3], "Discount"}, cell{columns[5], money(-invoice.DiscountAmount)})
+	}
+	p.row(10, false, cell{columns[3], "Shipping"}, cell{columns[5], money(invoice.ShippingAmount)})
+	for _, tax := range invoice.TaxLines {
+		label := fmt.Sprintf("%s %.2f%%", tax.Name, tax.Rate)
+		if tax.Inclusive {
+			label += " (incl.)"
+		}
+		p.row(10, false, cell{columns[3] - 60, truncate(label, 24)}, cell{columns[5], money(tax.Amount)})
+	}
+	p.row(10, false, cell{columns[3], "Tax"}, cell{columns[5], money(invoice.TaxAmount)})
+	p.row(12, true, cell{columns[3], "Total"}, cell{columns[5], money(invoice.TotalAmount)})
+
+	return p.doc.Bytes()
+}
+
+func renderCreditNote(invoice *models.Invoice, note *models.CreditNote) []byte {
+	p := newPage()
+	p.row(20, true, cell{margin, "CREDIT NOTE"})
+	p.space(6)
+	p.row(10, false, cell{margin, "Credit note number: " + note.Number})
+	p.row(10, false, cell{margin, "Issued: " + note.IssuedAt.Format("2 January 2006")})
+	p.row(10, false, cell{margin, "Credits invoice: " + invoice.Number})
+	p.row(10, false, cell{margin, "Order: " + invoice.OrderNumber})
+	p.space(12)
+	p.parties(invoice.Seller, invoice.Buyer)
+	p.space(16)
+
+	columns := []float64{margin, 400, 510}
+	p.row(10, true, cell{columns[0], "Description"}, cell{columns[1], "Qty"}, cell{columns[2], "Amount"})
+	p.rule()
+	for _, line := range note.Lines {
+		quantity := ""
+		if line.Quantity > 0 {
+			quantity = fmt.Sprintf("%d", line.Quantity)
+		}
+		p.row(10, false,
+			cell{columns[0], truncate(line.Description, 60)},
+			cell{columns[1], quantity},
+			cell{columns[2], money(line.Amount)},
+		)
+	}
+	p.rule()
+	p.row(12, true, cell{columns[1], "Total credited"}, cell{columns[2], money(note.Amount)})
+
+	if note.Reason != "" {
+		p.space(12)
+		p.row(10, false, cell{margin, "Reason: " + truncate(note.Reason, 90)})
+	}
+
+	return p.doc.Bytes()
+}
+
+func money(amount float64) string {
+	return fmt.Sprintf("%.2f", amount)
+}
+
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-3]) + "..."
+}
+
+func joinNonEmpty(a, b string) string {
+	switch {
+	case a == "":
+		return b
+	case b == "":
+		return a
+	}
+	return a + " " + b
+}
@@ -0,0 +1,336 @@
+package invoices
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+)
+
+type Repository interface {
+	CreateInvoice(ctx context.Context, invoice models.Invoice) (*models.Invoice, error)
+	GetInvoiceByOrderID(ctx context.Context, orderID int) (*models.Invoice, error)
+	SaveInvoicePDF(ctx context.Context, id int, pdf []byte) error
+	CreateCreditNote(ctx context.Context, note models.CreditNote) (*models.CreditNote, error)
+	GetCreditNotesByInvoiceID(ctx context.Context, invoiceID int) ([]models.CreditNote, error)
+	SaveCreditNotePDF(ctx context.Context, id int, pdf []byte) error
+	GetOrderByID(ctx context.Context, id int) (*models.Order, error)
+	GetPaymentsByOrderID(ctx context.Context, orderID int) ([]models.Payment, error)
+	GetRefundsByOrderID(ctx context.Context, orderID int) ([]models.Refund, error)
+	GetUserByID(ctx context.Context, id int) (*models.User, error)
+	GetProductByID(ctx context.Context, id int) (*models.Product, error)
+}
+
+// Config names the seller printed on every invoice and credit note.
+type Config struct {
+	Seller models.InvoiceParty
+}
+
+type Service struct {
+	repo   Repository
+	config Config
+}
+
+func NewService(repo Repository, config Config) *Service {
+	return &Service{
+		repo:   repo,
+		config: config,
+	}
+}
+
+// IssueInvoice issues the invoice of a paid order and renders its PDF. The
+// seller, the buyer with the order's billing address, the lines and the tax
+// are copied onto the invoice as they are now. An order is only invoiced
+// once; asking again returns the invoice already issued.
+func (s *Service) IssueInvoice(ctx context.Context, orderID int) (*models.Invoice, error) {
+	order, err := s.repo.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("order not found")
+	}
+
+	if existing, err := s.repo.GetInvoiceByOrderID(ctx, orderID); err == nil {
+		return existing, nil
+	}
+
+	paid, err := s.paid(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if !paid {
+		return nil, fmt.Errorf("order has no completed payment to invoice")
+	}
+
+	user, err := s.repo.GetUserByID(ctx, order.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get buyer: %w", err)
+	}
+
+	invoice := models.Invoice{
+		OrderID:     order.ID,
+		OrderNumber: order.OrderNumber,
+		Seller:      s.config.Seller,
+		Buyer: models.InvoiceParty{
+			Name:    strings.TrimSpace(user.FirstName + " " + user.LastName),
+			Email:   user.Email,
+			Address: order.BillingAddress,
+		},
+		TaxLines:       order.TaxLines,
+		DiscountAmount: order.DiscountAmount,
+		ShippingAmount: order.ShippingCost,
+		TaxAmount:      order.TaxAmount,
+		TotalAmount:    order.TotalAmount,
+	}
+
+	for _, item := range order.OrderItems {
+		description := fmt.Sprintf("Product #%d", item.ProductID)
+		if product, err := s.repo.GetProductByID(ctx, item.ProductID); err == nil {
+			description = product.Name
+		}
+
+		invoice.Lines = append(invoice.Lines, models.InvoiceLine{
+			OrderItemID: item.ID,
+			Description: description,
+			Quantity:    item.Quantity,
+			UnitPrice:   item.UnitPrice,
+			TaxRate:     item.TaxRate,
+			TaxAmount:   item.TaxAmount,
+			Total:       item.TotalPrice,
+		})
+		invoice.Subtotal += item.TotalPrice
+	}
+	invoice.Subtotal = roundCents(invoice.Subtotal)
+
+	issued, err := s.repo.CreateInvoice(ctx, invoice)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue invoice: %w", err)
+	}
+
+	if issued.PDF == nil {
+		if err := s.renderInvoice(ctx, issued); err != nil {
+			return nil, err
+		}
+	}
+
+	return issued, nil
+}
+
+// IssueCreditNote issues a credit note for a completed refund, referencing
+// the invoice of the refunded order, and renders its PDF. A refund of items
+// credits those lines of the invoice; any other refund is credited as one
+// line.
+func (s *Service) IssueCreditNote(ctx context.Context, refund *models.Refund) (*models.CreditNote, error) {
+	invoice, err := s.repo.GetInvoiceByOrderID(ctx, refund.OrderID)
+	if err != nil {
+		return nil, fmt.Errorf("order %d has no invoice to credit", refund.OrderID)
+	}
+
+	note := models.CreditNote{
+		InvoiceID: invoice.ID,
+		RefundID:  refund.ID,
+		Amount:    refund.Amount,
+		Reason:    refund.Reason,
+	}
+
+	descriptions := make(map[int]string)
+	for _, line := range invoice.Lines {
+		descriptions[line.OrderItemID] = line.Description
+	}
+
+	for _, item := range refund.Items {
+		orderItemID := item.OrderItemID
+		note.Lines = append(note.Lines, models.CreditNoteLine{
+			OrderItemID: &orderItemID,
+			Description: descriptions[item.OrderItemID],
+			Quantity:    item.Quantity,
+			Amount:      item.Amount,
+		})
+	}
+	if len(note.Lines) == 0 {
+		note.Lines = []models.CreditNoteLine{{
+			Description: fmt.Sprintf("Refund against invoice %s", invoice.Number),
+			Amount:      refund.Amount,
+		}}
+	}
+
+	issued, err := s.repo.CreateCreditNote(ctx, note)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue credit note: %w", err)
+	}
+
+	if issued.PDF == nil {
+		if err := s.renderCreditNote(ctx, invoice, issued); err != nil {
+			return nil, err
+		}
+	}
+
+	return issued, nil
+}
+
+// GetInvoice returns the order's invoice with its credit notes.
+func (s *Service) GetInvoice(ctx context.Context, orderID int) (*models.Invoice, error) {
+	invoice, err := s.repo.GetInvoiceByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("invoice not found")
+	}
+
+	invoice.CreditNotes, err = s.repo.GetCreditNotesByInvoiceID(ctx, invoice.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credit notes: %w", err)
+	}
+
+	return invoice, nil
+}
+
+// InvoicePDF returns the PDF of the order's invoice to the user who placed
+// the order, or to an admin.
+func (s *Service) InvoicePDF(ctx context.Context, orderID, userID int, admin bool) (*models.Invoice, error) {
+	if err := s.checkAccess(ctx, orderID, userID, admin); err != nil {
+		return nil, err
+	}
+
+	invoice, err := s.repo.GetInvoiceByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("invoice not found")
+	}
+
+	if invoice.PDF == nil {
+		if err := s.renderInvoice(ctx, invoice); err != nil {
+			return nil, err
+		}
+	}
+
+	return invoice, nil
+}
+
+// CreditNotePDF returns the PDF of one of the order's credit notes to the
+// user who placed the order, or to an admin.
+func (s *Service) CreditNotePDF(ctx context.Context, orderID, creditNoteID, userID int, admin bool) (*models.CreditNote, error) {
+	if err := s.checkAccess(ctx, orderID, userID, admin); err != nil {
+		return nil, err
+	}
+
+	invoice, err := s.GetInvoice(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("credit note not found")
+	}
+
+	for i := range invoice.CreditNotes {
+		note := &invoice.CreditNotes[i]
+		if note.ID != creditNoteID {
+			continue
+		}
+		if note.PDF == nil {
+			if err := s.renderCreditNote(ctx, invoice, note); err != nil {
+				return nil, err
+			}
+		}
+		return note, nil
+	}
+
+	return nil, fmt.Errorf("credit note not found")
+}
+
+// RenderInvoice renders the PDFs of the order's invoice and its credit notes
+// again from what was issued; nothing on them changes but the layout. A paid
+// order that was never invoiced is invoiced now, and completed refunds that
+// were never credited get their credit notes.
+func (s *Service) RenderInvoice(ctx context.Context, orderID int) (*models.Invoice, error) {
+	invoice, err := s.GetInvoice(ctx, orderID)
+	if err != nil {
+		if _, err := s.IssueInvoice(ctx, orderID); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := s.renderInvoice(ctx, invoice); err != nil {
+			return nil, err
+		}
+		for i := range invoice.CreditNotes {
+			if err := s.renderCreditNote(ctx, invoice, &invoice.CreditNotes[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := s.creditRefunds(ctx, orderID); err != nil {
+		return nil, err
+	}
+
+	return s.GetInvoice(ctx, orderID)
+}
+
+// creditRefunds issues credit notes for the order's completed refunds that
+// have none, such as refunds paid before the order was invoiced.
+func (s *Service) creditRefunds(ctx context.Context, orderID int) error {
+	invoice, err := s.GetInvoice(ctx, orderID)
+	if err != nil {
+		return err
+	}
+
+	credited := make(map[int]bool)
+	for _, note := range invoice.CreditNotes {
+		credited[note.RefundID] = true
+	}
+
+	refunds, err := s.repo.GetRefundsByOrderID(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("failed to get refunds: %w", err)
+	}
+
+	for i := range refunds {
+		refund := &refunds[i]
+		if refund.Status != "completed" || credited[refund.ID] {
+			continue
+		}
+		if _, err := s.IssueCreditNote(ctx, refund); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) renderInvoice(ctx context.Context, invoice *models.Invoice) error {
+	invoice.PDF = renderInvoice(invoice)
+	if err := s.repo.SaveInvoicePDF(ctx, invoice.ID, invoice.PDF); err != nil {
+		return fmt.Errorf("failed to save invoice PDF: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) renderCreditNote(ctx context.Context, invoice *models.Invoice, note *models.CreditNote) error {
+	note.PDF = renderCreditNote(invoice, note)
+	if err := s.repo.SaveCreditNotePDF(ctx, note.ID, note.PDF); err != nil {
+		return fmt.Errorf("failed to save credit note PDF: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) checkAccess(ctx context.Context, orderID, userID int, admin bool) error {
+	order, err := s.repo.GetOrderByID(ctx, orderID)
+	if err != nil || (!admin && order.UserID != userID) {
+		return fmt.Errorf("order not found")
+	}
+	return nil
+}
+
+// paid reports whether the order has a captured payment, refunded since or
+// not.
+func (s *Service) paid(ctx context.Context, orderID int) (bool, error) {
+	payments, err := s.repo.GetPaymentsByOrderID(ctx, orderID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get payments: %w", err)
+	}
+
+	for _, payment := range payments {
+		if payment.PaymentStatus == "completed" || payment.PaymentStatus == "refunded" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Address is a postal address. Country is an ISO 3166-1 alpha-2 code and,
+// together with Region, decides which taxes apply.
+type Address struct {
+	Name       string `json:"name,omitempty"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2,omitempty"`
+	City       string `json:"city"`
+	Region     string `json:"region,omitempty"`
+	PostalCode string `json:"postal_code,omitempty"`
+	Country    string `json:"country"`
+}
+
+// UnmarshalJSON also accepts the single-line string addresses used before
+// addresses were structured, keeping the whole string as Line1.
+func (a *Address) UnmarshalJSON(data []byte) error {
+	var line string
+	if err := json.Unmarshal(data, &line); err == nil {
+		*a = Address{Line1: strings.TrimSpace(line)}
+		return nil
+	}
+
+	type address Address
+	return json.Unmarshal(data, (*address)(a))
+}
+
+// Complete reports whether the address has enough to ship to and tax.
+func (a Address) Complete() bool {
+	return a.Line1 != "" && a.City != "" && len(a.Country) == 2
+}
+
+// OneLine reports whether the address is a legacy single-line address, with
+// nothing set but Line1.
+func (a Address) OneLine() bool {
+	return a.Line1 != "" && a == Address{Line1: a.Line1}
+}
+
+// String formats the address on a single line.
+func (a Address) String() string {
+	var parts []string
+	for _, part := range []string{a.Name, a.Line1, a.Line2, a.City, a.Region, a.PostalCode, a.Country} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
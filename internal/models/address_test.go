@@ -0,0 +1,35 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestAddressUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		want        Address
+		wantOneLine bool
+	}{
+		{"object", `{"line1": "1 High St", "city": "London", "country": "GB"}`, Address{Line1: "1 High St", City: "London", Country: "GB"}, false},
+		{"legacy string", `" 1 High St, London "`, Address{Line1: "1 High St, London"}, true},
+		{"object with only line1", `{"line1": "1 High St"}`, Address{Line1: "1 High St"}, true},
+		{"empty string", `""`, Address{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Address
+			if err := json.Unmarshal([]byte(tt.data), &got); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Unmarshal = %+v, want %+v", got, tt.want)
+			}
+			if got.OneLine() != tt.wantOneLine {
+				t.Errorf("OneLine = %v, want %v", got.OneLine(), tt.wantOneLine)
+			}
+		})
+	}
+}
@@ -5,6 +5,73 @@ import (
 )
 
 type CartItem struct {
+	ID         int               `json:"id"`
+	UserID     int               `json:"user_id"`
+	ProductID  int               `json:"product_id"`
+	Quantity   int               `json:"quantity"`
+	AddedPrice float64           `json:"added_price"`
+	UnitPrice  float64           `json:"unit_price"`
+	ListPrice  float64           `json:"list_price"`
+	Warnings   []CartItemWarning `json:"warnings,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+	Product    *Product          `json:"product,omitempty"`
+}
+
+// Cart item warning types.
+const (
+	CartWarningPriceChanged      = "price_changed"
+	CartWarningOutOfStock        = "out_of_stock"
+	CartWarningInsufficientStock = "insufficient_stock"
+	CartWarningUnavailable       = "unavailable"
+)
+
+// CartItemWarning tells the customer how an item changed since it was added
+// to the cart.
+type CartItemWarning struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+type AddToCartRequest struct {
+	ProductID int `json:"product_id" validate:"required"`
+	Quantity  int `json:"quantity" validate:"required,min=1"`
+}
+
+type UpdateCartRequest struct {
+	Quantity int `json:"quantity" validate:"required,min=1"`
+}
+
+// AcknowledgeCartRequest repeats the cart's items as the customer was shown
+// them. The changes are only accepted while the cart still matches.
+type AcknowledgeCartRequest struct {
+	Items []AcknowledgedCartItem `json:"items"`
+}
+
+type AcknowledgedCartItem struct {
+	ProductID int     `json:"product_id"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"`
+}
+
+type CartResponse struct {
+	Items         []CartItem     `json:"items"`
+	TotalItems    int            `json:"total_items"`
+	TotalPrice    float64        `json:"total_price"`
+	CouponCode    string         `json:"coupon_code,omitempty"`
+	Discounts     []DiscountLine `json:"discounts,omitempty"`
+	DiscountTotal float64        `json:"discount_total"`
+	FreeShipping  bool           `json:"free_shipping"`
+	TaxLines      []TaxLine      `json:"tax_lines,omitempty"`
+	TaxTotal      float64        `json:"tax_total"`
+	GrandTotal    float64        `json:"grand_total"`
+	// RequiresAcknowledgement is set while any item carries a warning; the
+	// cart cannot be checked out until the changes are acknowledged.
+	RequiresAcknowledgement bool `json:"requires_acknowledgement"`
+}
+
+// SavedItem is a product the user moved out of the cart to buy later.
+type SavedItem struct {
 	ID        int       `json:"id"`
 	UserID    int       `json:"user_id"`
 	ProductID int       `json:"product_id"`
@@ -14,17 +81,92 @@ type CartItem struct {
 	Product   *Product  `json:"product,omitempty"`
 }
 
-type AddToCartRequest struct {
-	ProductID int `json:"product_id" validate:"required"`
-	Quantity  int `json:"quantity" validate:"required,min=1"`
+// CartShare is an expiring link to a copy of a user's cart. Opening it copies
+// the items into the visitor's cart.
+type CartShare struct {
+	ID        int             `json:"id"`
+	Token     string          `json:"token"`
+	UserID    int             `json:"-"`
+	Items     []CartShareItem `json:"items"`
+	ExpiresAt time.Time       `json:"expires_at"`
+	CreatedAt time.Time       `json:"created_at"`
 }
 
-type UpdateCartRequest struct {
-	Quantity int `json:"quantity" validate:"required,min=1"`
+type CartShareItem struct {
+	ProductID int      `json:"product_id"`
+	Quantity  int      `json:"quantity"`
+	Product   *Product `json:"product,omitempty"`
 }
 
-type CartResponse struct {
-	Items      []CartItem `json:"items"`
-	TotalItems int        `json:"total_items"`
-	TotalPrice float64    `json:"total_price"`
+type CreateCartShareRequest struct {
+	ExpiresInHours int `json:"expires_in_hours,omitempty"`
+}
+
+type CheckoutRequest struct {
+	ShippingAddress Address `json:"shipping_address" validate:"required"`
+	BillingAddress  Address `json:"billing_address" validate:"required"`
+	ShippingMethod  string  `json:"shipping_method,omitempty"`
+}
+
+type CheckoutPriceChange struct {
+	ProductID int     `json:"product_id"`
+	Name      string  `json:"name"`
+	Quantity  int     `json:"quantity"`
+	OldPrice  float64 `json:"old_price"`
+	NewPrice  float64 `json:"new_price"`
+}
+
+type CheckoutStockIssue struct {
+	ProductID int    `json:"product_id"`
+	Name      string `json:"name"`
+	Requested int    `json:"requested"`
+	Available int    `json:"available"`
+	Reason    string `json:"reason"`
+}
+
+// CheckoutConflict is returned when the cart no longer matches the catalogue.
+// It is written to the client as-is so the cart can be reviewed.
+type CheckoutConflict struct {
+	Message      string                `json:"error"`
+	PriceChanges []CheckoutPriceChange `json:"price_changes,omitempty"`
+	StockIssues  []CheckoutStockIssue  `json:"stock_issues,omitempty"`
+}
+
+func (c *CheckoutConflict) Error() string {
+	return c.Message
+}
+
+// AbandonedCart is one abandonment of a user's cart: the cart saw no update
+// after LastActivityAt. Status tracks the reminder; recovery is recorded when
+// the user next places an order.
+type AbandonedCart struct {
+	ID               int        `json:"id"`
+	UserID           int        `json:"user_id"`
+	LastActivityAt   time.Time  `json:"last_activity_at"`
+	ItemCount        int        `json:"item_count"`
+	CartValue        float64    `json:"cart_value"`
+	Status           string     `json:"status"`
+	SkipReason       string     `json:"skip_reason,omitempty"`
+	ReminderSentAt   *time.Time `json:"reminder_sent_at,omitempty"`
+	CouponID         *int       `json:"coupon_id,omitempty"`
+	RecoveredOrderID *int       `json:"recovered_order_id,omitempty"`
+	RecoveredAt      *time.Time `json:"recovered_at,omitempty"`
+	DetectedAt       time.Time  `json:"detected_at"`
+}
+
+// AbandonedCartReport sums up the carts abandoned in a period and how many
+// were recovered. Rates are percentages.
+type AbandonedCartReport struct {
+	From                   time.Time `json:"from"`
+	To                     time.Time `json:"to"`
+	Abandoned              int       `json:"abandoned"`
+	AbandonedValue         float64   `json:"abandoned_value"`
+	Reminded               int       `json:"reminded"`
+	Skipped                int       `json:"skipped"`
+	CouponsIssued          int       `json:"coupons_issued"`
+	Recovered              int       `json:"recovered"`
+	RecoveredValue         float64   `json:"recovered_value"`
+	RecoveredAfterReminder int       `json:"recovered_after_reminder"`
+	RecoveryRate           float64   `json:"recovery_rate"`
+	ReminderRecoveryRate   float64   `json:"reminder_recovery_rate"`
 }
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+)
+
+type CheckoutSession struct {
+	ID              int              `json:"id"`
+	UserID          int              `json:"user_id"`
+	Status          string           `json:"status"`
+	ShippingAddress Address          `json:"shipping_address"`
+	BillingAddress  Address          `json:"billing_address"`
+	ShippingMethod  string           `json:"shipping_method"`
+	DiscountCode    string           `json:"discount_code"`
+	Subtotal        float64          `json:"subtotal"`
+	ShippingCost    float64          `json:"shipping_cost"`
+	DiscountAmount  float64          `json:"discount_amount"`
+	TaxAmount       float64          `json:"tax_amount"`
+	TotalAmount     float64          `json:"total_amount"`
+	OrderID         *int             `json:"order_id,omitempty"`
+	ExpiresAt       time.Time        `json:"expires_at"`
+	CreatedAt       time.Time        `json:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+	Items           []CartItem       `json:"items,omitempty"`
+	Discounts       []DiscountLine   `json:"discounts,omitempty"`
+	TaxLines        []TaxLine        `json:"tax_lines,omitempty"`
+	ShippingMethods []ShippingOption `json:"shipping_methods,omitempty"`
+}
+
+type SetCheckoutAddressesRequest struct {
+	ShippingAddress Address `json:"shipping_address" validate:"required"`
+	BillingAddress  Address `json:"billing_address" validate:"required"`
+}
+
+type SetShippingMethodRequest struct {
+	ShippingMethod string `json:"shipping_method" validate:"required"`
+}
+
+type ApplyDiscountRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type ConfirmCheckoutRequest struct {
+	PaymentMethod string `json:"payment_method" validate:"required"`
+}
+
+type CheckoutConfirmation struct {
+	Session *CheckoutSession `json:"session"`
+	Order   *Order           `json:"order"`
+	Payment *Payment         `json:"payment"`
+}
@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// InvoiceParty is the seller or the buyer named on an invoice.
+type InvoiceParty struct {
+	Name    string  `json:"name"`
+	Email   string  `json:"email,omitempty"`
+	TaxID   string  `json:"tax_id,omitempty"`
+	Address Address `json:"address"`
+}
+
+// Invoice is the bill for a paid order. Its number is sequential within the
+// year it was issued, and everything on it is kept as it was on that day;
+// the PDF can be rendered again but the invoice never changes.
+type Invoice struct {
+	ID             int           `json:"id"`
+	OrderID        int           `json:"order_id"`
+	Number         string        `json:"invoice_number"`
+	OrderNumber    string        `json:"order_number"`
+	Seller         InvoiceParty  `json:"seller"`
+	Buyer          InvoiceParty  `json:"buyer"`
+	Lines          []InvoiceLine `json:"lines"`
+	TaxLines       []TaxLine     `json:"tax_lines"`
+	Subtotal       float64       `json:"subtotal"`
+	DiscountAmount float64       `json:"discount_amount"`
+	ShippingAmount float64       `json:"shipping_amount"`
+	TaxAmount      float64       `json:"tax_amount"`
+	TotalAmount    float64       `json:"total_amount"`
+	PDF            []byte        `json:"-"`
+	RenderedAt     *time.Time    `json:"rendered_at,omitempty"`
+	IssuedAt       time.Time     `json:"issued_at"`
+	CreditNotes    []CreditNote  `json:"credit_notes,omitempty"`
+}
+
+type InvoiceLine struct {
+	OrderItemID int     `json:"order_item_id"`
+	Description string  `json:"description"`
+	Quantity    int     `json:"quantity"`
+	UnitPrice   float64 `json:"unit_price"`
+	TaxRate     float64 `json:"tax_rate"`
+	TaxAmount   float64 `json:"tax_amount"`
+	Total       float64 `json:"total"`
+}
+
+// CreditNote is issued for a refund and credits part or all of the invoice
+// it references. Credit notes are numbered in their own yearly series.
+type CreditNote struct {
+	ID            int              `json:"id"`
+	InvoiceID     int              `json:"invoice_id"`
+	InvoiceNumber string           `json:"invoice_number"`
+	RefundID      int              `json:"refund_id"`
+	Number        string           `json:"credit_note_number"`
+	Lines         []CreditNoteLine `json:"lines"`
+	Amount        float64          `json:"amount"`
+	Reason        string           `json:"reason,omitempty"`
+	PDF           []byte           `json:"-"`
+	RenderedAt    *time.Time       `json:"rendered_at,omitempty"`
+	IssuedAt      time.Time        `json:"issued_at"`
+}
+
+type CreditNoteLine struct {
+	OrderItemID *int    `json:"order_item_id,omitempty"`
+	Description string  `json:"description"`
+	Quantity    int     `json:"quantity,omitempty"`
+	Amount      float64 `json:"amount"`
+}
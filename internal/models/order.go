@@ -5,17 +5,27 @@ import (
 )
 
 type Order struct {
-	ID              int         `json:"id"`
-	UserID          int         `json:"user_id"`
-	OrderNumber     string      `json:"order_number"`
-	Status          string      `json:"status"`
-	TotalAmount     float64     `json:"total_amount"`
-	ShippingAddress string      `json:"shipping_address"`
-	BillingAddress  string      `json:"billing_address"`
-	CreatedAt       time.Time   `json:"created_at"`
-	UpdatedAt       time.Time   `json:"updated_at"`
-	OrderItems      []OrderItem `json:"order_items,omitempty"`
-	User            *User       `json:"user,omitempty"`
+	ID                 int            `json:"id"`
+	UserID             int            `json:"user_id"`
+	OrderNumber        string         `json:"order_number"`
+	Status             string         `json:"status"`
+	TotalAmount        float64        `json:"total_amount"`
+	ShippingMethod     string         `json:"shipping_method,omitempty"`
+	ShippingCost       float64        `json:"shipping_cost"`
+	DiscountAmount     float64        `json:"discount_amount"`
+	TaxAmount          float64        `json:"tax_amount"`
+	NetPaidAmount      float64        `json:"net_paid_amount"`
+	CancellationReason string         `json:"cancellation_reason,omitempty"`
+	ShippingAddress    Address        `json:"shipping_address"`
+	BillingAddress     Address        `json:"billing_address"`
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+	OrderItems         []OrderItem    `json:"order_items,omitempty"`
+	Discounts          []DiscountLine `json:"discounts,omitempty"`
+	TaxLines           []TaxLine      `json:"tax_lines,omitempty"`
+	Shipments          []Shipment     `json:"shipments,omitempty"`
+	Timeline           []OrderEvent   `json:"timeline,omitempty"`
+	User               *User          `json:"user,omitempty"`
 }
 
 type OrderItem struct {
@@ -24,14 +34,29 @@ type OrderItem struct {
 	ProductID  int      `json:"product_id"`
 	Quantity   int      `json:"quantity"`
 	UnitPrice  float64  `json:"unit_price"`
+	ListPrice  float64  `json:"list_price"`
 	TotalPrice float64  `json:"total_price"`
+	TaxRate    float64  `json:"tax_rate"`
+	TaxAmount  float64  `json:"tax_amount"`
 	Product    *Product `json:"product,omitempty"`
 }
 
 type CreateOrderRequest struct {
 	Items           []OrderItemRequest `json:"items" validate:"required,min=1"`
-	ShippingAddress string             `json:"shipping_address" validate:"required"`
-	BillingAddress  string             `json:"billing_address" validate:"required"`
+	ShippingAddress Address            `json:"shipping_address" validate:"required"`
+	BillingAddress  Address            `json:"billing_address" validate:"required"`
+	ShippingMethod  string             `json:"shipping_method,omitempty"`
+	CouponCode      string             `json:"coupon_code,omitempty"`
+
+	// Set by the server: unit prices are resolved for the customer per
+	// product, the shipping cost is quoted for ShippingMethod, discounts come
+	// from the promotion rules and CouponCode priced against the items, and
+	// taxes from the shipping address.
+	UnitPrices     map[int]float64 `json:"-"`
+	ShippingCost   float64         `json:"-"`
+	DiscountAmount float64         `json:"-"`
+	Discounts      []DiscountLine  `json:"-"`
+	Tax            *TaxBreakdown   `json:"-"`
 }
 
 type OrderItemRequest struct {
@@ -40,7 +65,57 @@ type OrderItemRequest struct {
 }
 
 type UpdateOrderStatusRequest struct {
-	Status string `json:"status" validate:"required,oneof=pending confirmed shipped delivered cancelled"`
+	Status             string `json:"status" validate:"required,oneof=pending confirmed partially_shipped shipped delivered cancelled"`
+	CancellationReason string `json:"cancellation_reason,omitempty"`
+}
+
+// CancelOrderRequest is the optional body of a customer's cancellation.
+type CancelOrderRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// OrderTransitionError is returned when an order cannot move to the requested
+// status. It is written to the client as-is, listing the statuses the order
+// can move to from its current one.
+type OrderTransitionError struct {
+	Message         string   `json:"error"`
+	Status          string   `json:"status"`
+	AllowedStatuses []string `json:"allowed_statuses"`
+}
+
+func (e *OrderTransitionError) Error() string {
+	return e.Message
+}
+
+const (
+	OrderEventStatusChanged = "status_changed"
+	OrderEventPayment       = "payment"
+	OrderEventNote          = "note"
+	OrderEventReturn        = "return"
+	OrderEventShipment      = "shipment"
+)
+
+// OrderActor is who caused an order event. ID is nil for the system.
+type OrderActor struct {
+	ID   *int   `json:"id,omitempty"`
+	Role string `json:"role"`
+}
+
+// OrderEvent is an entry in an order's timeline. Internal events, such as
+// admin notes, are hidden from the customer.
+type OrderEvent struct {
+	ID        int                    `json:"id"`
+	OrderID   int                    `json:"order_id"`
+	Type      string                 `json:"type"`
+	Actor     OrderActor             `json:"actor"`
+	Message   string                 `json:"message,omitempty"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	Internal  bool                   `json:"internal"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+type CreateOrderNoteRequest struct {
+	Message string `json:"message" validate:"required"`
 }
 
 type Payment struct {
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+)
+
+type CustomerGroup struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type CreateCustomerGroupRequest struct {
+	Name        string `json:"name" validate:"required"`
+	Description string `json:"description,omitempty"`
+}
+
+type AssignCustomerGroupRequest struct {
+	CustomerGroupID *int `json:"customer_group_id"`
+}
+
+type PriceListItem struct {
+	CustomerGroupID int     `json:"customer_group_id"`
+	ProductID       int     `json:"product_id"`
+	Price           float64 `json:"price"`
+}
+
+type SetPriceListRequest struct {
+	Items []PriceListEntry `json:"items" validate:"required,min=1"`
+}
+
+type PriceListEntry struct {
+	ProductID int     `json:"product_id" validate:"required"`
+	Price     float64 `json:"price" validate:"gte=0"`
+}
+
+type PriceBreak struct {
+	ID              int     `json:"id"`
+	ProductID       int     `json:"product_id"`
+	CustomerGroupID *int    `json:"customer_group_id,omitempty"`
+	MinQuantity     int     `json:"min_quantity"`
+	DiscountPercent float64 `json:"discount_percent"`
+}
+
+type SetPriceBreaksRequest struct {
+	Breaks []PriceBreakRequest `json:"breaks"`
+}
+
+type PriceBreakRequest struct {
+	CustomerGroupID *int    `json:"customer_group_id,omitempty"`
+	MinQuantity     int     `json:"min_quantity" validate:"required,min=2"`
+	DiscountPercent float64 `json:"discount_percent" validate:"required,gt=0,lt=100"`
+}
+
+// ProductPricing is what a customer's unit price for a product is resolved
+// from: the list price, their group's price list entry, if any, and the
+// quantity price breaks open to them.
+type ProductPricing struct {
+	ProductID  int
+	ListPrice  float64
+	GroupPrice *float64
+	Breaks     []PriceBreak
+}
@@ -5,17 +5,22 @@ import (
 )
 
 type Product struct {
-	ID            int       `json:"id"`
-	Name          string    `json:"name"`
-	Description   string    `json:"description"`
-	Price         float64   `json:"price"`
-	StockQuantity int       `json:"stock_quantity"`
-	CategoryID    int       `json:"category_id"`
-	SKU           string    `json:"sku"`
-	ImageURL      string    `json:"image_url,omitempty"`
-	IsActive      bool      `json:"is_active"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID            int               `json:"id"`
+	Name          string            `json:"name"`
+	Description   string            `json:"description"`
+	Price         float64           `json:"price"`
+	StockQuantity int               `json:"stock_quantity"`
+	CategoryID    int               `json:"category_id"`
+	SKU           string            `json:"sku"`
+	ImageURL      string            `json:"image_url,omitempty"`
+	WeightKg      float64           `json:"weight_kg,omitempty"`
+	LengthCm      float64           `json:"length_cm,omitempty"`
+	WidthCm       float64           `json:"width_cm,omitempty"`
+	HeightCm      float64           `json:"height_cm,omitempty"`
+	IsActive      bool              `json:"is_active"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+	Attributes    map[string]string `json:"attributes,omitempty"`
 }
 
 type Category struct {
@@ -34,6 +39,10 @@ type CreateProductRequest struct {
 	CategoryID    int     `json:"category_id" validate:"required"`
 	SKU           string  `json:"sku" validate:"required"`
 	ImageURL      string  `json:"image_url,omitempty"`
+	WeightKg      float64 `json:"weight_kg,omitempty" validate:"gte=0"`
+	LengthCm      float64 `json:"length_cm,omitempty" validate:"gte=0"`
+	WidthCm       float64 `json:"width_cm,omitempty" validate:"gte=0"`
+	HeightCm      float64 `json:"height_cm,omitempty" validate:"gte=0"`
 }
 
 type UpdateProductRequest struct {
@@ -43,9 +52,17 @@ type UpdateProductRequest struct {
 	StockQuantity *int     `json:"stock_quantity,omitempty"`
 	CategoryID    *int     `json:"category_id,omitempty"`
 	ImageURL      *string  `json:"image_url,omitempty"`
+	WeightKg      *float64 `json:"weight_kg,omitempty"`
+	LengthCm      *float64 `json:"length_cm,omitempty"`
+	WidthCm       *float64 `json:"width_cm,omitempty"`
+	HeightCm      *float64 `json:"height_cm,omitempty"`
 	IsActive      *bool    `json:"is_active,omitempty"`
 }
 
+type UpdateProductAttributesRequest struct {
+	Attributes map[string]string `json:"attributes"`
+}
+
 type ProductFilter struct {
 	CategoryID *int     `json:"category_id,omitempty"`
 	MinPrice   *float64 `json:"min_price,omitempty"`
@@ -55,3 +72,24 @@ type ProductFilter struct {
 	Page       int      `json:"page,omitempty"`
 	Limit      int      `json:"limit,omitempty"`
 }
+
+type ProductComparison struct {
+	Products []Product         `json:"products"`
+	Rows     []ComparisonRow   `json:"rows"`
+	Errors   []ComparisonError `json:"errors,omitempty"`
+}
+
+// ComparisonRow holds one field across all compared products. Values are in
+// the same order as ProductComparison.Products and nil where a product has
+// no value for the field.
+type ComparisonRow struct {
+	Field   string        `json:"field"`
+	Label   string        `json:"label"`
+	Values  []interface{} `json:"values"`
+	Differs bool          `json:"differs"`
+}
+
+type ComparisonError struct {
+	ProductID int    `json:"product_id"`
+	Error     string `json:"error"`
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNoCartCoupon is returned when no coupon is applied to a cart.
+var ErrNoCartCoupon = errors.New("no coupon applied to the cart")
+
+type Coupon struct {
+	ID            int        `json:"id"`
+	Code          string     `json:"code"`
+	Description   string     `json:"description"`
+	DiscountType  string     `json:"discount_type"`
+	DiscountValue float64    `json:"discount_value"`
+	MinSubtotal   float64    `json:"min_subtotal"`
+	ProductIDs    []int      `json:"product_ids"`
+	CategoryIDs   []int      `json:"category_ids"`
+	StartsAt      *time.Time `json:"starts_at,omitempty"`
+	EndsAt        *time.Time `json:"ends_at,omitempty"`
+	UsageLimit    *int       `json:"usage_limit,omitempty"`
+	PerUserLimit  *int       `json:"per_user_limit,omitempty"`
+	IsActive      bool       `json:"is_active"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+type CreateCouponRequest struct {
+	Code          string     `json:"code" validate:"required"`
+	Description   string     `json:"description,omitempty"`
+	DiscountType  string     `json:"discount_type" validate:"required,oneof=percentage fixed"`
+	DiscountValue float64    `json:"discount_value" validate:"required,gt=0"`
+	MinSubtotal   float64    `json:"min_subtotal,omitempty"`
+	ProductIDs    []int      `json:"product_ids,omitempty"`
+	CategoryIDs   []int      `json:"category_ids,omitempty"`
+	StartsAt      *time.Time `json:"starts_at,omitempty"`
+	EndsAt        *time.Time `json:"ends_at,omitempty"`
+	UsageLimit    *int       `json:"usage_limit,omitempty"`
+	PerUserLimit  *int       `json:"per_user_limit,omitempty"`
+}
+
+type ApplyCouponRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// DiscountLine is one discount applied to a cart or order, from a coupon or
+// from an automatic promotion rule that fired.
+type DiscountLine struct {
+	Source       string  `json:"source"`
+	Code         string  `json:"code,omitempty"`
+	RuleID       int     `json:"rule_id,omitempty"`
+	Description  string  `json:"description"`
+	Amount       float64 `json:"amount"`
+	FreeShipping bool    `json:"free_shipping,omitempty"`
+	CouponID     int     `json:"-"`
+}
+
+type PromotionRule struct {
+	ID            int             `json:"id"`
+	Name          string          `json:"name"`
+	Description   string          `json:"description"`
+	RuleType      string          `json:"rule_type"`
+	Priority      int             `json:"priority"`
+	Stackable     bool            `json:"stackable"`
+	ProductIDs    []int           `json:"product_ids"`
+	CategoryIDs   []int           `json:"category_ids"`
+	MinSubtotal   float64         `json:"min_subtotal"`
+	BuyQuantity   int             `json:"buy_quantity,omitempty"`
+	GetQuantity   int             `json:"get_quantity,omitempty"`
+	DiscountValue float64         `json:"discount_value,omitempty"`
+	Tiers         []PromotionTier `json:"tiers,omitempty"`
+	StartsAt      *time.Time      `json:"starts_at,omitempty"`
+	EndsAt        *time.Time      `json:"ends_at,omitempty"`
+	IsActive      bool            `json:"is_active"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+// PromotionTier gives Percentage off once the eligible subtotal reaches
+// Threshold.
+type PromotionTier struct {
+	Threshold  float64 `json:"threshold"`
+	Percentage float64 `json:"percentage"`
+}
+
+type CreatePromotionRuleRequest struct {
+	Name          string          `json:"name" validate:"required"`
+	Description   string          `json:"description,omitempty"`
+	RuleType      string          `json:"rule_type" validate:"required,oneof=buy_x_get_y tiered_percentage bundle free_shipping"`
+	Priority      int             `json:"priority"`
+	Stackable     *bool           `json:"stackable,omitempty"`
+	ProductIDs    []int           `json:"product_ids,omitempty"`
+	CategoryIDs   []int           `json:"category_ids,omitempty"`
+	MinSubtotal   float64         `json:"min_subtotal,omitempty"`
+	BuyQuantity   int             `json:"buy_quantity,omitempty"`
+	GetQuantity   int             `json:"get_quantity,omitempty"`
+	DiscountValue float64         `json:"discount_value,omitempty"`
+	Tiers         []PromotionTier `json:"tiers,omitempty"`
+	StartsAt      *time.Time      `json:"starts_at,omitempty"`
+	EndsAt        *time.Time      `json:"ends_at,omitempty"`
+}
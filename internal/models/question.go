@@ -0,0 +1,63 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrAnswerNotFound is returned when an answer does not exist or is on a
+// question that is not published.
+var ErrAnswerNotFound = errors.New("answer not found")
+
+type ProductQuestion struct {
+	ID        int             `json:"id"`
+	ProductID int             `json:"product_id"`
+	UserID    int             `json:"user_id"`
+	Body      string          `json:"body"`
+	Status    string          `json:"status"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	Author    *QuestionAuthor `json:"author,omitempty"`
+	Answers   []ProductAnswer `json:"answers,omitempty"`
+}
+
+type ProductAnswer struct {
+	ID              int             `json:"id"`
+	QuestionID      int             `json:"question_id"`
+	UserID          int             `json:"user_id"`
+	Body            string          `json:"body"`
+	IsAdmin         bool            `json:"is_admin"`
+	IsVerifiedBuyer bool            `json:"is_verified_buyer"`
+	Upvotes         int             `json:"upvotes"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+	Author          *QuestionAuthor `json:"author,omitempty"`
+}
+
+// QuestionAuthor is the public name shown for whoever asked a question or
+// wrote an answer.
+type QuestionAuthor struct {
+	ID        int    `json:"id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
+
+type CreateQuestionRequest struct {
+	Body string `json:"body" validate:"required"`
+}
+
+type CreateAnswerRequest struct {
+	Body string `json:"body" validate:"required"`
+}
+
+type ModerateQuestionRequest struct {
+	Status string `json:"status" validate:"required,oneof=approved rejected"`
+}
+
+type QuestionFilter struct {
+	ProductID *int   `json:"product_id,omitempty"`
+	Status    string `json:"status,omitempty"`
+	Search    string `json:"search,omitempty"`
+	Page      int    `json:"page,omitempty"`
+	Limit     int    `json:"limit,omitempty"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+type ProductView struct {
+	ProductID    int       `json:"product_id"`
+	ViewCount    int       `json:"view_count"`
+	LastViewedAt time.Time `json:"last_viewed_at"`
+	Product      *Product  `json:"product,omitempty"`
+}
+
+type RecommendedProduct struct {
+	Product
+	Score  float64 `json:"score"`
+	Reason string  `json:"reason"`
+}
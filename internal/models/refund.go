@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Refund is money paid back against a captured payment, either a plain
+// amount or the price of some of the order's items.
+type Refund struct {
+	ID                int          `json:"id"`
+	OrderID           int          `json:"order_id"`
+	PaymentID         int          `json:"payment_id"`
+	Amount            float64      `json:"amount"`
+	Reason            string       `json:"reason,omitempty"`
+	Status            string       `json:"status"`
+	ProviderReference string       `json:"provider_reference,omitempty"`
+	FailureReason     string       `json:"failure_reason,omitempty"`
+	CreatedBy         *int         `json:"created_by,omitempty"`
+	CreatedAt         time.Time    `json:"created_at"`
+	UpdatedAt         time.Time    `json:"updated_at"`
+	Items             []RefundItem `json:"items,omitempty"`
+}
+
+type RefundItem struct {
+	ID          int     `json:"id"`
+	RefundID    int     `json:"refund_id"`
+	OrderItemID int     `json:"order_item_id"`
+	Quantity    int     `json:"quantity"`
+	Amount      float64 `json:"amount"`
+}
+
+// CreateRefundRequest refunds either an amount or the price of some of the
+// order's items. With neither, whatever is left of the payment is refunded.
+// PaymentID can be left out when the order has one payment to refund.
+type CreateRefundRequest struct {
+	PaymentID *int                `json:"payment_id,omitempty"`
+	Amount    *float64            `json:"amount,omitempty"`
+	Items     []RefundItemRequest `json:"items,omitempty"`
+	Reason    string              `json:"reason,omitempty"`
+}
+
+type RefundItemRequest struct {
+	OrderItemID int `json:"order_item_id" validate:"required"`
+	Quantity    int `json:"quantity" validate:"required,min=1"`
+}
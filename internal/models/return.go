@@ -0,0 +1,73 @@
+package models
+
+import "time"
+
+// Return is a return merchandise authorisation (RMA) for some of the items
+// of a delivered order.
+type Return struct {
+	ID              int          `json:"id"`
+	RMANumber       string       `json:"rma_number"`
+	OrderID         int          `json:"order_id"`
+	UserID          int          `json:"user_id"`
+	Status          string       `json:"status"`
+	Resolution      string       `json:"resolution"`
+	LabelReference  string       `json:"label_reference,omitempty"`
+	AdminNote       string       `json:"admin_note,omitempty"`
+	RefundAmount    float64      `json:"refund_amount"`
+	RefundID        *int         `json:"refund_id,omitempty"`
+	ExchangeOrderID *int         `json:"exchange_order_id,omitempty"`
+	CreatedAt       time.Time    `json:"created_at"`
+	UpdatedAt       time.Time    `json:"updated_at"`
+	Items           []ReturnItem `json:"items,omitempty"`
+}
+
+type ReturnItem struct {
+	ID          int     `json:"id"`
+	ReturnID    int     `json:"return_id"`
+	OrderItemID int     `json:"order_item_id"`
+	ProductID   int     `json:"product_id"`
+	Quantity    int     `json:"quantity"`
+	UnitPrice   float64 `json:"unit_price"`
+	Reason      string  `json:"reason"`
+	Condition   string  `json:"condition,omitempty"`
+	Restocked   bool    `json:"restocked"`
+}
+
+type CreateReturnRequest struct {
+	Resolution string              `json:"resolution" validate:"required,oneof=refund exchange"`
+	Items      []ReturnItemRequest `json:"items" validate:"required,min=1"`
+}
+
+type ReturnItemRequest struct {
+	OrderItemID int    `json:"order_item_id" validate:"required"`
+	Quantity    int    `json:"quantity" validate:"required,min=1"`
+	Reason      string `json:"reason" validate:"required"`
+}
+
+type RejectReturnRequest struct {
+	Note string `json:"note"`
+}
+
+// ReceiveReturnRequest records the condition of each returned item once the
+// goods arrive.
+type ReceiveReturnRequest struct {
+	Items []ReturnInspection `json:"items" validate:"required,min=1"`
+}
+
+type ReturnInspection struct {
+	ReturnItemID int    `json:"return_item_id" validate:"required"`
+	Condition    string `json:"condition" validate:"required,oneof=resellable damaged"`
+}
+
+// ReturnTransitionError is returned when a return cannot move to the
+// requested status. Like OrderTransitionError it is written to the client
+// as-is.
+type ReturnTransitionError struct {
+	Message         string   `json:"error"`
+	Status          string   `json:"status"`
+	AllowedStatuses []string `json:"allowed_statuses"`
+}
+
+func (e *ReturnTransitionError) Error() string {
+	return e.Message
+}
@@ -0,0 +1,99 @@
+package models
+
+import "time"
+
+// Shipment is one box of an order. It is pending until it leaves with a
+// carrier and tracking number, then shipped and finally delivered.
+type Shipment struct {
+	ID             int            `json:"id"`
+	OrderID        int            `json:"order_id"`
+	Status         string         `json:"status"`
+	Carrier        string         `json:"carrier,omitempty"`
+	TrackingNumber string         `json:"tracking_number,omitempty"`
+	ShippedAt      *time.Time     `json:"shipped_at,omitempty"`
+	DeliveredAt    *time.Time     `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	Items          []ShipmentItem `json:"items,omitempty"`
+}
+
+type ShipmentItem struct {
+	ID          int `json:"id"`
+	ShipmentID  int `json:"shipment_id"`
+	OrderItemID int `json:"order_item_id"`
+	Quantity    int `json:"quantity"`
+}
+
+// CreateShipmentRequest packs some of an order's items into a shipment. With
+// a carrier and tracking number the shipment is shipped straight away.
+type CreateShipmentRequest struct {
+	Items          []ShipmentItemRequest `json:"items" validate:"required,min=1"`
+	Carrier        string                `json:"carrier,omitempty"`
+	TrackingNumber string                `json:"tracking_number,omitempty"`
+}
+
+type ShipmentItemRequest struct {
+	OrderItemID int `json:"order_item_id" validate:"required"`
+	Quantity    int `json:"quantity" validate:"required,min=1"`
+}
+
+type ShipShipmentRequest struct {
+	Carrier        string `json:"carrier" validate:"required"`
+	TrackingNumber string `json:"tracking_number" validate:"required"`
+}
+
+// Parcel is what a carrier needs to price and label a shipment.
+type Parcel struct {
+	Reference string  `json:"reference"`
+	WeightKg  float64 `json:"weight_kg"`
+	To        Address `json:"to"`
+}
+
+// CarrierRate is a carrier's price for sending a parcel with one of its
+// services.
+type CarrierRate struct {
+	Carrier       string  `json:"carrier"`
+	Service       string  `json:"service"`
+	Amount        float64 `json:"amount"`
+	EstimatedDays int     `json:"estimated_days"`
+}
+
+// ShipmentLabel is a carrier label bought for a shipment. Data holds the
+// printable label in Format, PDF or ZPL, and is only served as a download.
+type ShipmentLabel struct {
+	ID             int       `json:"id"`
+	ShipmentID     int       `json:"shipment_id"`
+	Carrier        string    `json:"carrier"`
+	Service        string    `json:"service"`
+	TrackingNumber string    `json:"tracking_number"`
+	Format         string    `json:"format"`
+	Cost           float64   `json:"cost"`
+	Data           []byte    `json:"-"`
+	CreatedBy      *int      `json:"created_by,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type BuyLabelRequest struct {
+	Carrier string `json:"carrier,omitempty"`
+	Service string `json:"service" validate:"required"`
+	Format  string `json:"format,omitempty" validate:"omitempty,oneof=pdf zpl"`
+}
+
+// TrackingEvent is a step of a parcel's journey as reported by its carrier.
+// EventID is the carrier's own ID for the event.
+type TrackingEvent struct {
+	EventID        string    `json:"event_id"`
+	TrackingNumber string    `json:"tracking_number"`
+	Status         string    `json:"status"`
+	Description    string    `json:"description,omitempty"`
+	Location       string    `json:"location,omitempty"`
+	OccurredAt     time.Time `json:"occurred_at"`
+}
+
+// TrackingInfo is a carrier's current view of a parcel.
+type TrackingInfo struct {
+	Carrier        string          `json:"carrier"`
+	TrackingNumber string          `json:"tracking_number"`
+	Status         string          `json:"status"`
+	Events         []TrackingEvent `json:"events"`
+}
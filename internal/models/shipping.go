@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+)
+
+type ShippingZone struct {
+	ID        int                    `json:"id"`
+	Name      string                 `json:"name"`
+	Locations []ShippingZoneLocation `json:"locations"`
+	CreatedAt time.Time              `json:"created_at"`
+	UpdatedAt time.Time              `json:"updated_at"`
+}
+
+// ShippingZoneLocation is a country, optionally narrowed to the postcodes
+// matching a pattern such as "SW1*" or "9021?".
+type ShippingZoneLocation struct {
+	Country         string `json:"country"`
+	PostcodePattern string `json:"postcode_pattern,omitempty"`
+}
+
+type CreateShippingZoneRequest struct {
+	Name      string                 `json:"name" validate:"required"`
+	Locations []ShippingZoneLocation `json:"locations"`
+}
+
+type ShippingMethod struct {
+	ID                int                  `json:"id"`
+	ZoneID            int                  `json:"zone_id"`
+	Code              string               `json:"code"`
+	Name              string               `json:"name"`
+	RateType          string               `json:"rate_type"`
+	Rate              float64              `json:"rate"`
+	WeightTiers       []ShippingWeightTier `json:"weight_tiers,omitempty"`
+	FreeOver          float64              `json:"free_over,omitempty"`
+	VolumetricDivisor int                  `json:"volumetric_divisor,omitempty"`
+	IsActive          bool                 `json:"is_active"`
+	CreatedAt         time.Time            `json:"created_at"`
+	UpdatedAt         time.Time            `json:"updated_at"`
+}
+
+// ShippingWeightTier prices parcels weighing up to MaxWeight kilograms.
+type ShippingWeightTier struct {
+	MaxWeight float64 `json:"max_weight_kg"`
+	Rate      float64 `json:"rate"`
+}
+
+// CreateShippingMethodRequest adds a method to a zone. Flat methods cost
+// Rate; weight_tiered methods cost the rate of the first tier the parcel
+// fits; free_over_threshold methods cost Rate unless the discounted subtotal
+// reaches FreeOver. With a VolumetricDivisor, each item weighs at least
+// length x width x height (cm) / divisor.
+type CreateShippingMethodRequest struct {
+	ZoneID            int                  `json:"zone_id" validate:"required"`
+	Code              string               `json:"code" validate:"required"`
+	Name              string               `json:"name" validate:"required"`
+	RateType          string               `json:"rate_type" validate:"required,oneof=flat weight_tiered free_over_threshold"`
+	Rate              float64              `json:"rate" validate:"gte=0"`
+	WeightTiers       []ShippingWeightTier `json:"weight_tiers,omitempty"`
+	FreeOver          float64              `json:"free_over,omitempty"`
+	VolumetricDivisor int                  `json:"volumetric_divisor,omitempty"`
+}
+
+// ShippingOption is a shipping method quoted for a set of items and an
+// address.
+type ShippingOption struct {
+	Code     string  `json:"code"`
+	Name     string  `json:"name"`
+	Cost     float64 `json:"cost"`
+	WeightKg float64 `json:"weight_kg"`
+}
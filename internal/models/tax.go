@@ -0,0 +1,92 @@
+package models
+
+import (
+	"time"
+)
+
+type TaxClass struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type CreateTaxClassRequest struct {
+	Name        string `json:"name" validate:"required"`
+	Description string `json:"description,omitempty"`
+}
+
+type SetCategoryTaxClassRequest struct {
+	TaxClassID *int `json:"tax_class_id"`
+}
+
+type TaxJurisdiction struct {
+	ID               int       `json:"id"`
+	Country          string    `json:"country"`
+	Region           string    `json:"region,omitempty"`
+	Name             string    `json:"name"`
+	PricesIncludeTax bool      `json:"prices_include_tax"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+type CreateTaxJurisdictionRequest struct {
+	Country          string `json:"country" validate:"required,len=2"`
+	Region           string `json:"region,omitempty"`
+	Name             string `json:"name" validate:"required"`
+	PricesIncludeTax bool   `json:"prices_include_tax"`
+}
+
+type TaxRate struct {
+	ID             int        `json:"id"`
+	JurisdictionID int        `json:"jurisdiction_id"`
+	TaxClassID     *int       `json:"tax_class_id,omitempty"`
+	Name           string     `json:"name"`
+	Rate           float64    `json:"rate"`
+	EffectiveFrom  time.Time  `json:"effective_from"`
+	EffectiveTo    *time.Time `json:"effective_to,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+
+	// Loaded with the rate when it is applied.
+	Jurisdiction     string `json:"-"`
+	PricesIncludeTax bool   `json:"-"`
+}
+
+// CreateTaxRateRequest adds a rate taking effect on EffectiveFrom
+// (YYYY-MM-DD). An open-ended rate already in place for the same
+// jurisdiction and class ends on that date.
+type CreateTaxRateRequest struct {
+	JurisdictionID int     `json:"jurisdiction_id" validate:"required"`
+	TaxClassID     *int    `json:"tax_class_id,omitempty"`
+	Name           string  `json:"name" validate:"required"`
+	Rate           float64 `json:"rate" validate:"gte=0,lt=100"`
+	EffectiveFrom  string  `json:"effective_from" validate:"required"`
+	EffectiveTo    string  `json:"effective_to,omitempty"`
+}
+
+// TaxLine is the tax charged at one rate. Inclusive taxes are already part
+// of the prices; the others are added on top.
+type TaxLine struct {
+	TaxRateID    int     `json:"tax_rate_id,omitempty"`
+	Name         string  `json:"name"`
+	Jurisdiction string  `json:"jurisdiction"`
+	Rate         float64 `json:"rate"`
+	Inclusive    bool    `json:"inclusive"`
+	Amount       float64 `json:"amount"`
+}
+
+// ItemTax is the combined rate and the tax charged on one product.
+type ItemTax struct {
+	Rate   float64 `json:"rate"`
+	Amount float64 `json:"amount"`
+}
+
+// TaxBreakdown is the tax on a set of items. Added is the part of Total that
+// is not included in the prices.
+type TaxBreakdown struct {
+	Lines []TaxLine
+	Items map[int]ItemTax
+	Total float64
+	Added float64
+}
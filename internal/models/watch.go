@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+type ProductWatch struct {
+	ID               int        `json:"id"`
+	UserID           int        `json:"user_id"`
+	ProductID        int        `json:"product_id"`
+	BackInStock      bool       `json:"back_in_stock"`
+	TargetPrice      *float64   `json:"target_price,omitempty"`
+	UnsubscribeToken string     `json:"-"`
+	NotifiedAt       *time.Time `json:"notified_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	Product          *Product   `json:"product,omitempty"`
+}
+
+type CreateWatchRequest struct {
+	ProductID   int      `json:"product_id" validate:"required"`
+	BackInStock bool     `json:"back_in_stock"`
+	TargetPrice *float64 `json:"target_price,omitempty" validate:"omitempty,gt=0"`
+}
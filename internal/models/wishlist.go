@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+)
+
+type Wishlist struct {
+	ID         int            `json:"id"`
+	UserID     int            `json:"user_id"`
+	Name       string         `json:"name"`
+	ShareToken string         `json:"share_token"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	Items      []WishlistItem `json:"items,omitempty"`
+}
+
+type WishlistItem struct {
+	ID         int       `json:"id"`
+	WishlistID int       `json:"wishlist_id"`
+	ProductID  int       `json:"product_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	Product    *Product  `json:"product,omitempty"`
+}
+
+type CreateWishlistRequest struct {
+	Name string `json:"name" validate:"required"`
+}
+
+type UpdateWishlistRequest struct {
+	Name string `json:"name" validate:"required"`
+}
+
+type AddToWishlistRequest struct {
+	ProductID int `json:"product_id" validate:"required"`
+}
+
+type MoveToCartRequest struct {
+	Quantity int `json:"quantity,omitempty"`
+}
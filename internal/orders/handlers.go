@@ -1,6 +1,7 @@
 package orders
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -40,6 +41,33 @@ func (h *handler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 	json.Write(w, http.StatusCreated, order)
 }
 
+func (h *handler) Checkout(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		json.WriteError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var req models.CheckoutRequest
+	if err := json.Read(r, &req); err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	order, err := h.service.Checkout(r.Context(), claims.UserID, req)
+	if err != nil {
+		var conflict *models.CheckoutConflict
+		if errors.As(err, &conflict) {
+			json.Write(w, http.StatusConflict, conflict)
+			return
+		}
+		json.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusCreated, order)
+}
+
 func (h *handler) GetUserOrders(w http.ResponseWriter, r *http.Request) {
 	claims := auth.GetUserFromContext(r.Context())
 	if claims == nil {
@@ -100,7 +128,12 @@ func (h *handler) UpdateOrderStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.UpdateOrderStatus(r.Context(), orderID, req.Status); err != nil {
+	if err := h.service.UpdateOrderStatus(r.Context(), orderID, req, actor(claims)); err != nil {
+		var transitionErr *models.OrderTransitionError
+		if errors.As(err, &transitionErr) {
+			json.Write(w, http.StatusConflict, transitionErr)
+			return
+		}
 		json.WriteError(w, http.StatusBadRequest, err.Error())
 		return
 	}
@@ -108,6 +141,41 @@ func (h *handler) UpdateOrderStatus(w http.ResponseWriter, r *http.Request) {
 	json.Write(w, http.StatusOK, map[string]string{"message": "Order status updated successfully"})
 }
 
+func (h *handler) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		json.WriteError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	orderID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	var req models.CancelOrderRequest
+	if r.ContentLength != 0 {
+		if err := json.Read(r, &req); err != nil {
+			json.WriteError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	order, err := h.service.CancelOrder(r.Context(), orderID, claims.UserID, req.Reason, actor(claims))
+	if err != nil {
+		var transitionErr *models.OrderTransitionError
+		if errors.As(err, &transitionErr) {
+			json.Write(w, http.StatusConflict, transitionErr)
+			return
+		}
+		json.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, order)
+}
+
 func (h *handler) GetAllOrders(w http.ResponseWriter, r *http.Request) {
 	claims := auth.GetUserFromContext(r.Context())
 	if claims == nil || claims.Role != "admin" {
@@ -140,7 +208,7 @@ func (h *handler) ProcessPayment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	payment, err := h.service.ProcessPayment(r.Context(), req)
+	payment, err := h.service.ProcessPayment(r.Context(), req, actor(claims))
 	if err != nil {
 		json.WriteError(w, http.StatusBadRequest, err.Error())
 		return
@@ -148,3 +216,60 @@ func (h *handler) ProcessPayment(w http.ResponseWriter, r *http.Request) {
 
 	json.Write(w, http.StatusCreated, payment)
 }
+
+func (h *handler) GetOrderForAdmin(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil || claims.Role != "admin" {
+		json.WriteError(w, http.StatusForbidden, "Admin access required")
+		return
+	}
+
+	orderID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	order, err := h.service.GetOrderForAdmin(r.Context(), orderID)
+	if err != nil {
+		json.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, order)
+}
+
+func (h *handler) AddOrderNote(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil || claims.Role != "admin" {
+		json.WriteError(w, http.StatusForbidden, "Admin access required")
+		return
+	}
+
+	orderID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	var req models.CreateOrderNoteRequest
+	if err := json.Read(r, &req); err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	event, err := h.service.AddOrderNote(r.Context(), orderID, req.Message, actor(claims))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusCreated, event)
+}
+
+// actor is the authenticated user as the actor of the order events their
+// request raises.
+func actor(claims *auth.JWTClaims) models.OrderActor {
+	userID := claims.UserID
+	return models.OrderActor{ID: &userID, Role: claims.Role}
+}
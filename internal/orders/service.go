@@ -3,32 +3,123 @@ package orders
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"math"
+	"strings"
 
+	"github.com/VishalHilal/e-commerce-api/internal/cart"
+	"github.com/VishalHilal/e-commerce-api/internal/email"
 	"github.com/VishalHilal/e-commerce-api/internal/models"
 	"github.com/google/uuid"
 )
 
 type Repository interface {
 	CreateOrder(ctx context.Context, order models.CreateOrderRequest, userID int) (*models.Order, error)
+	CreateOrderFromCart(ctx context.Context, order models.CreateOrderRequest, userID int) (*models.Order, error)
+	CreateOrderWithPayment(ctx context.Context, order models.CreateOrderRequest, userID int, paymentMethod string) (*models.Order, *models.Payment, error)
+	CreateReplacementOrder(ctx context.Context, orderID int, items []models.OrderItemRequest, actor models.OrderActor) (*models.Order, error)
 	GetOrdersByUserID(ctx context.Context, userID int) ([]models.Order, error)
 	GetOrderByID(ctx context.Context, id int) (*models.Order, error)
-	UpdateOrderStatus(ctx context.Context, id int, status string) error
+	TransitionOrderStatus(ctx context.Context, id int, from string, req models.UpdateOrderStatusRequest, actor models.OrderActor) (bool, error)
+	AddOrderEvent(ctx context.Context, event models.OrderEvent) (*models.OrderEvent, error)
+	GetOrderEvents(ctx context.Context, orderID int, includeInternal bool) ([]models.OrderEvent, error)
+	GetShipmentsByOrderID(ctx context.Context, orderID int) ([]models.Shipment, error)
 	GetAllOrders(ctx context.Context) ([]models.Order, error)
-	CreatePayment(ctx context.Context, payment models.CreatePaymentRequest) (*models.Payment, error)
-	UpdatePaymentStatus(ctx context.Context, paymentID int, status string) error
+	PayOrder(ctx context.Context, payment models.CreatePaymentRequest, actor models.OrderActor) (*models.Payment, bool, error)
+	GetPaymentsByOrderID(ctx context.Context, orderID int) ([]models.Payment, error)
+	GetUserByID(ctx context.Context, id int) (*models.User, error)
+	GetProductByID(ctx context.Context, id int) (*models.Product, error)
+}
+
+// Pricer sets each item's unit price to the best one the user gets for the
+// item's quantity.
+type Pricer interface {
+	PriceItems(ctx context.Context, userID int, items []models.CartItem) error
+}
+
+// Discounter evaluates the automatic promotions and prices a coupon code
+// against the items of an order.
+type Discounter interface {
+	Discounts(ctx context.Context, userID int, code string, items []models.CartItem) ([]models.DiscountLine, error)
+}
+
+// TaxCalculator works out the tax on the items of an order shipped to an
+// address, after the order's discount.
+type TaxCalculator interface {
+	CartTax(ctx context.Context, address models.Address, items []models.CartItem, discount float64) (*models.TaxBreakdown, error)
+}
+
+// ShippingQuoter prices the shipping methods available for sending the items
+// of an order to an address. subtotal is the discounted item total.
+type ShippingQuoter interface {
+	Quote(ctx context.Context, address models.Address, items []models.CartItem, subtotal float64) ([]models.ShippingOption, error)
+}
+
+// Refunder pays back what is left of an order's captured payments.
+type Refunder interface {
+	RefundOrder(ctx context.Context, orderID int, reason string, actor models.OrderActor) error
+}
+
+// Invoicer issues the invoice of a paid order.
+type Invoicer interface {
+	IssueInvoice(ctx context.Context, orderID int) (*models.Invoice, error)
+}
+
+// RestockListener is told which products a cancelled order put back in
+// stock.
+type RestockListener interface {
+	ProductsRestocked(ctx context.Context, productIDs []int)
 }
 
 type Service struct {
-	repo Repository
+	repo       Repository
+	cartSvc    *cart.Service
+	pricer     Pricer
+	discounter Discounter
+	taxes      TaxCalculator
+	shipping   ShippingQuoter
+	emailSvc   *email.EmailService
+	refunds    Refunder
+	invoices   Invoicer
+	restocks   RestockListener
 }
 
-func NewService(repo Repository) *Service {
-	return &Service{repo: repo}
+// NewService creates the order service. discounter may be nil, in which case
+// no promotions apply and orders carrying a coupon code are rejected; taxes
+// may be nil, in which case orders are not taxed; shipping may be nil, in
+// which case orders are shipped for free and cannot name a method; emailSvc
+// may be nil, in which case status changes are not emailed. Cancelled orders
+// are refunded through refunds; it may be nil, in which case each cancelled
+// order's refund is left on its timeline for an admin. invoices may be nil,
+// in which case paid
+// orders are not invoiced. restocks may be nil, in which case nobody is told
+// about the stock a cancelled order puts back.
+func NewService(repo Repository, cartSvc *cart.Service, pricer Pricer, discounter Discounter, taxes TaxCalculator, shipping ShippingQuoter, emailSvc *email.EmailService, refunds Refunder, invoices Invoicer, restocks RestockListener) *Service {
+	return &Service{
+		repo:       repo,
+		cartSvc:    cartSvc,
+		pricer:     pricer,
+		discounter: discounter,
+		taxes:      taxes,
+		shipping:   shipping,
+		emailSvc:   emailSvc,
+		refunds:    refunds,
+		invoices:   invoices,
+		restocks:   restocks,
+	}
 }
 
 func (s *Service) CreateOrder(ctx context.Context, req models.CreateOrderRequest, userID int) (*models.Order, error) {
 	orderNumber := "ORD-" + uuid.New().String()[:8]
 
+	if !orderAddress(req.ShippingAddress) || !orderAddress(req.BillingAddress) {
+		return nil, fmt.Errorf("shipping and billing addresses with line1, city and country are required")
+	}
+
+	if err := s.price(ctx, userID, &req); err != nil {
+		return nil, err
+	}
+
 	order, err := s.repo.CreateOrder(ctx, req, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create order: %w", err)
@@ -38,6 +129,297 @@ func (s *Service) CreateOrder(ctx context.Context, req models.CreateOrderRequest
 	return order, nil
 }
 
+// orderAddress reports whether POST /orders takes the address. Besides
+// complete addresses it still takes the single-line addresses it accepted
+// before addresses were structured; having no country, those are not taxed.
+func orderAddress(address models.Address) bool {
+	return address.Complete() || address.OneLine()
+}
+
+// Checkout turns the user's cart into an order. Prices and stock are checked
+// against the current catalogue first; any change must be acknowledged on the
+// cart before the order is placed. The cart is cleared in the same
+// transaction that creates the order.
+func (s *Service) Checkout(ctx context.Context, userID int, req models.CheckoutRequest) (*models.Order, error) {
+	if !req.ShippingAddress.Complete() || !req.BillingAddress.Complete() {
+		return nil, fmt.Errorf("shipping and billing addresses with line1, city and country are required")
+	}
+
+	userCart, err := s.cartSvc.GetCart(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(userCart.Items) == 0 {
+		return nil, fmt.Errorf("cart is empty")
+	}
+
+	conflict := &models.CheckoutConflict{}
+	orderReq := models.CreateOrderRequest{
+		ShippingAddress: req.ShippingAddress,
+		BillingAddress:  req.BillingAddress,
+		ShippingMethod:  req.ShippingMethod,
+	}
+
+	for _, item := range userCart.Items {
+		product := item.Product
+		switch {
+		case product == nil || !product.IsActive:
+			conflict.StockIssues = append(conflict.StockIssues, models.CheckoutStockIssue{
+				ProductID: item.ProductID,
+				Name:      productName(product),
+				Requested: item.Quantity,
+				Reason:    "unavailable",
+			})
+			continue
+		case product.StockQuantity < item.Quantity:
+			conflict.StockIssues = append(conflict.StockIssues, models.CheckoutStockIssue{
+				ProductID: item.ProductID,
+				Name:      product.Name,
+				Requested: item.Quantity,
+				Available: product.StockQuantity,
+				Reason:    "insufficient_stock",
+			})
+		}
+
+		if item.UnitPrice != item.AddedPrice {
+			conflict.PriceChanges = append(conflict.PriceChanges, models.CheckoutPriceChange{
+				ProductID: item.ProductID,
+				Name:      product.Name,
+				Quantity:  item.Quantity,
+				OldPrice:  item.AddedPrice,
+				NewPrice:  item.UnitPrice,
+			})
+		}
+
+		orderReq.Items = append(orderReq.Items, models.OrderItemRequest{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+		})
+	}
+
+	if len(conflict.StockIssues) > 0 {
+		conflict.Message = "some items in the cart are no longer available as added; acknowledge the cart changes to continue"
+		return nil, conflict
+	}
+
+	if userCart.RequiresAcknowledgement {
+		conflict.Message = "the cart changed since items were added; acknowledge the cart changes to continue"
+		return nil, conflict
+	}
+
+	orderReq.CouponCode = userCart.CouponCode
+	if err := s.price(ctx, userID, &orderReq); err != nil {
+		return nil, err
+	}
+
+	order, err := s.repo.CreateOrderFromCart(ctx, orderReq, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create order: %w", err)
+	}
+
+	return order, nil
+}
+
+// PlaceOrderWithPayment creates the order from the user's cart together with
+// a pending payment for its total, atomically. The order is confirmed and
+// invoiced once the payment completes through ProcessPayment.
+func (s *Service) PlaceOrderWithPayment(ctx context.Context, req models.CreateOrderRequest, userID int, paymentMethod string) (*models.Order, *models.Payment, error) {
+	if paymentMethod == "" {
+		return nil, nil, fmt.Errorf("payment method is required")
+	}
+
+	if err := s.price(ctx, userID, &req); err != nil {
+		return nil, nil, err
+	}
+
+	order, payment, err := s.repo.CreateOrderWithPayment(ctx, req, userID, paymentMethod)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to place order: %w", err)
+	}
+
+	return order, payment, nil
+}
+
+// PlaceReplacementOrder places a free, pending order for items of an
+// existing order, such as the goods sent out for an exchange. Having nothing
+// to pay, it can be confirmed straight away with UpdateOrderStatus.
+func (s *Service) PlaceReplacementOrder(ctx context.Context, orderID int, items []models.OrderItemRequest, actor models.OrderActor) (*models.Order, error) {
+	if len(items) == 0 {
+		return nil, fmt.Errorf("at least one item is required")
+	}
+
+	order, err := s.repo.CreateReplacementOrder(ctx, orderID, items, actor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to place replacement order: %w", err)
+	}
+
+	return order, nil
+}
+
+// price works out what the order charges on top of its items: the
+// discounts, the shipping cost of the chosen method and the tax. It runs at
+// order creation so the order gets what the cart and checkout showed; the
+// repository records the results in the same transaction as the order.
+func (s *Service) price(ctx context.Context, userID int, req *models.CreateOrderRequest) error {
+	items, err := s.priceItems(ctx, userID, req)
+	if err != nil {
+		return err
+	}
+
+	if err := s.applyDiscounts(ctx, userID, req, items); err != nil {
+		return err
+	}
+
+	if err := s.applyShipping(ctx, req, items); err != nil {
+		return err
+	}
+
+	return s.applyTax(ctx, req, items)
+}
+
+// priceItems resolves the user's unit price of every product in the order,
+// adding up the quantities of repeated products as the repository does. The
+// prices are kept on req for the repository to record.
+func (s *Service) priceItems(ctx context.Context, userID int, req *models.CreateOrderRequest) ([]models.CartItem, error) {
+	items := req.Items
+	quantities := make(map[int]int)
+	var productIDs []int
+	for _, item := range items {
+		if _, ok := quantities[item.ProductID]; !ok {
+			productIDs = append(productIDs, item.ProductID)
+		}
+		quantities[item.ProductID] += item.Quantity
+	}
+
+	priced := make([]models.CartItem, 0, len(productIDs))
+	for _, productID := range productIDs {
+		product, err := s.repo.GetProductByID(ctx, productID)
+		if err != nil {
+			return nil, fmt.Errorf("product %d not found: %w", productID, err)
+		}
+
+		priced = append(priced, models.CartItem{
+			ProductID: productID,
+			Quantity:  quantities[productID],
+			Product:   product,
+		})
+	}
+
+	if err := s.pricer.PriceItems(ctx, userID, priced); err != nil {
+		return nil, fmt.Errorf("failed to price order items: %w", err)
+	}
+
+	req.UnitPrices = make(map[int]float64, len(priced))
+	for _, item := range priced {
+		req.UnitPrices[item.ProductID] = item.UnitPrice
+	}
+
+	return priced, nil
+}
+
+// applyDiscounts re-runs the promotion rules and prices the request's coupon
+// code. The repository records the discounts, and the coupon redemption,
+// with the order.
+func (s *Service) applyDiscounts(ctx context.Context, userID int, req *models.CreateOrderRequest, items []models.CartItem) error {
+	if s.discounter == nil {
+		if req.CouponCode != "" {
+			return fmt.Errorf("coupon codes are not available")
+		}
+		return nil
+	}
+
+	discounts, err := s.discounter.Discounts(ctx, userID, req.CouponCode, items)
+	if err != nil {
+		return fmt.Errorf("discounts cannot be applied: %w", err)
+	}
+
+	req.Discounts = discounts
+	req.DiscountAmount = 0
+	for _, discount := range discounts {
+		req.DiscountAmount += discount.Amount
+	}
+
+	return nil
+}
+
+// applyShipping quotes the chosen shipping method for the order. A method
+// must be chosen whenever the shipping address is served by one, and a free
+// shipping discount makes it free.
+func (s *Service) applyShipping(ctx context.Context, req *models.CreateOrderRequest, items []models.CartItem) error {
+	req.ShippingCost = 0
+	if s.shipping == nil {
+		if req.ShippingMethod != "" {
+			return fmt.Errorf("shipping methods are not available")
+		}
+		return nil
+	}
+
+	var subtotal float64
+	for _, item := range items {
+		subtotal += float64(item.Quantity) * item.UnitPrice
+	}
+
+	options, err := s.shipping.Quote(ctx, req.ShippingAddress, items, math.Max(subtotal-req.DiscountAmount, 0))
+	if err != nil {
+		return fmt.Errorf("shipping cannot be quoted: %w", err)
+	}
+
+	if req.ShippingMethod == "" {
+		if len(options) > 0 {
+			return fmt.Errorf("shipping method is required")
+		}
+		return nil
+	}
+
+	option, ok := findShippingOption(options, req.ShippingMethod)
+	if !ok {
+		return fmt.Errorf("shipping method %s is not available for this address", req.ShippingMethod)
+	}
+	req.ShippingCost = option.Cost
+
+	for _, discount := range req.Discounts {
+		if discount.FreeShipping {
+			req.ShippingCost = 0
+		}
+	}
+
+	return nil
+}
+
+// applyTax works out the order's tax from its shipping address. The
+// repository snapshots the rate of each item and records the tax lines with
+// the order.
+func (s *Service) applyTax(ctx context.Context, req *models.CreateOrderRequest, items []models.CartItem) error {
+	if s.taxes == nil {
+		return nil
+	}
+
+	tax, err := s.taxes.CartTax(ctx, req.ShippingAddress, items, req.DiscountAmount)
+	if err != nil {
+		return fmt.Errorf("tax cannot be calculated: %w", err)
+	}
+
+	req.Tax = tax
+	return nil
+}
+
+func findShippingOption(options []models.ShippingOption, code string) (models.ShippingOption, bool) {
+	for _, option := range options {
+		if option.Code == code {
+			return option, true
+		}
+	}
+	return models.ShippingOption{}, false
+}
+
+func productName(product *models.Product) string {
+	if product == nil {
+		return ""
+	}
+	return product.Name
+}
+
 func (s *Service) GetUserOrders(ctx context.Context, userID int) ([]models.Order, error) {
 	orders, err := s.repo.GetOrdersByUserID(ctx, userID)
 	if err != nil {
@@ -56,28 +438,103 @@ func (s *Service) GetOrder(ctx context.Context, orderID int, userID int) (*model
 		return nil, fmt.Errorf("unauthorized access to order")
 	}
 
+	order.Timeline, err = s.repo.GetOrderEvents(ctx, orderID, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order timeline: %w", err)
+	}
+
+	order.Shipments, err = s.repo.GetShipmentsByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order shipments: %w", err)
+	}
+
 	return order, nil
 }
 
-func (s *Service) UpdateOrderStatus(ctx context.Context, orderID int, status string) error {
-	validStatuses := []string{"pending", "confirmed", "shipped", "delivered", "cancelled"}
-	isValid := false
-	for _, s := range validStatuses {
-		if s == status {
-			isValid = true
-			break
-		}
+// GetOrderForAdmin returns any order with its full timeline, internal notes
+// included.
+func (s *Service) GetOrderForAdmin(ctx context.Context, orderID int) (*models.Order, error) {
+	order, err := s.repo.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("order not found")
+	}
+
+	order.Timeline, err = s.repo.GetOrderEvents(ctx, orderID, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order timeline: %w", err)
 	}
 
-	if !isValid {
-		return fmt.Errorf("invalid order status: %s", status)
+	order.Shipments, err = s.repo.GetShipmentsByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order shipments: %w", err)
 	}
 
-	if err := s.repo.UpdateOrderStatus(ctx, orderID, status); err != nil {
-		return fmt.Errorf("failed to update order status: %w", err)
+	return order, nil
+}
+
+// AddOrderNote adds an internal note to the order's timeline. Notes are only
+// shown to admins.
+func (s *Service) AddOrderNote(ctx context.Context, orderID int, message string, actor models.OrderActor) (*models.OrderEvent, error) {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return nil, fmt.Errorf("message is required")
 	}
 
-	return nil
+	if _, err := s.repo.GetOrderByID(ctx, orderID); err != nil {
+		return nil, fmt.Errorf("order not found")
+	}
+
+	event, err := s.repo.AddOrderEvent(ctx, models.OrderEvent{
+		OrderID:  orderID,
+		Type:     models.OrderEventNote,
+		Actor:    actor,
+		Message:  message,
+		Internal: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to add order note: %w", err)
+	}
+
+	return event, nil
+}
+
+// UpdateOrderStatus moves an order along the status graph in status.go. A
+// move the graph does not allow fails with an *models.OrderTransitionError.
+// The shipping statuses follow the order's shipments and are refused here.
+func (s *Service) UpdateOrderStatus(ctx context.Context, orderID int, req models.UpdateOrderStatusRequest, actor models.OrderActor) error {
+	order, err := s.repo.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("order not found")
+	}
+
+	if contains(shipmentStatuses, req.Status) {
+		return fmt.Errorf("order status %s follows the order's shipments", req.Status)
+	}
+
+	return s.transition(ctx, order, req, actor)
+}
+
+// CancelOrder cancels one of the user's orders before it ships. The items go
+// back in stock and any completed payment is refunded.
+func (s *Service) CancelOrder(ctx context.Context, orderID, userID int, reason string, actor models.OrderActor) (*models.Order, error) {
+	order, err := s.repo.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("order not found")
+	}
+
+	if order.UserID != userID {
+		return nil, fmt.Errorf("unauthorized access to order")
+	}
+
+	err = s.transition(ctx, order, models.UpdateOrderStatusRequest{
+		Status:             statusCancelled,
+		CancellationReason: reason,
+	}, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	return order, nil
 }
 
 func (s *Service) GetAllOrders(ctx context.Context) ([]models.Order, error) {
@@ -88,20 +545,41 @@ func (s *Service) GetAllOrders(ctx context.Context) ([]models.Order, error) {
 	return orders, nil
 }
 
-func (s *Service) ProcessPayment(ctx context.Context, req models.CreatePaymentRequest) (*models.Payment, error) {
-	payment, err := s.repo.CreatePayment(ctx, req)
+func (s *Service) ProcessPayment(ctx context.Context, req models.CreatePaymentRequest, actor models.OrderActor) (*models.Payment, error) {
+	order, err := s.repo.GetOrderByID(ctx, req.OrderID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create payment: %w", err)
+		return nil, fmt.Errorf("order not found")
 	}
 
-	payment.PaymentStatus = "completed"
-	if err := s.repo.UpdatePaymentStatus(ctx, payment.ID, payment.PaymentStatus); err != nil {
-		return nil, fmt.Errorf("failed to update payment status: %w", err)
+	if order.Status != statusPending {
+		return nil, fmt.Errorf("order is not awaiting payment")
 	}
 
-	if err := s.repo.UpdateOrderStatus(ctx, req.OrderID, "confirmed"); err != nil {
-		return nil, fmt.Errorf("failed to update order status: %w", err)
+	// The completed payment satisfies the guard on confirmed, so the payment
+	// and the pending → confirmed move are saved together.
+	payment, paid, err := s.repo.PayOrder(ctx, req, actor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to process payment: %w", err)
+	}
+	if !paid {
+		return nil, fmt.Errorf("order is not awaiting payment")
 	}
 
+	order.Status = statusConfirmed
+	s.notify(ctx, order)
+	s.invoice(ctx, order.ID)
 	return payment, nil
 }
+
+// invoice issues the invoice of an order whose payment has just completed.
+// The payment stands if invoicing fails; an admin can issue the invoice
+// later by rendering it.
+func (s *Service) invoice(ctx context.Context, orderID int) {
+	if s.invoices == nil {
+		return
+	}
+
+	if _, err := s.invoices.IssueInvoice(ctx, orderID); err != nil {
+		slog.Error("failed to issue invoice", "order_id", orderID, "error", err)
+	}
+}
@@ -0,0 +1,224 @@
+package orders
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+)
+
+// Order statuses. An order is placed pending, confirmed once paid, then
+// partially shipped, shipped and delivered as its shipments go out and
+// arrive; it can be cancelled until something ships.
+const (
+	statusPending          = "pending"
+	statusConfirmed        = "confirmed"
+	statusPartiallyShipped = "partially_shipped"
+	statusShipped          = "shipped"
+	statusDelivered        = "delivered"
+	statusCancelled        = "cancelled"
+)
+
+// orderTransitions lists the statuses an order can move to from each status.
+var orderTransitions = map[string][]string{
+	statusPending:          {statusConfirmed, statusCancelled},
+	statusConfirmed:        {statusPartiallyShipped, statusShipped, statusCancelled},
+	statusPartiallyShipped: {statusShipped},
+	statusShipped:          {statusDelivered},
+	statusDelivered:        {},
+	statusCancelled:        {},
+}
+
+// shipmentStatuses follow the order's shipments and cannot be set by hand.
+var shipmentStatuses = []string{statusPartiallyShipped, statusShipped, statusDelivered}
+
+// cancellationReasons are the reason codes an order can be cancelled with.
+var cancellationReasons = []string{
+	"changed_mind",
+	"ordered_by_mistake",
+	"found_cheaper",
+	"delivery_too_slow",
+	"other",
+}
+
+// transition moves the order to the requested status. The move must be an
+// edge of orderTransitions and pass the target status's guard. Stock and the
+// order's timeline change with the status; refunds and notifications follow
+// once the move is saved.
+func (s *Service) transition(ctx context.Context, order *models.Order, req models.UpdateOrderStatusRequest, actor models.OrderActor) error {
+	if _, ok := orderTransitions[req.Status]; !ok {
+		return fmt.Errorf("invalid order status: %s", req.Status)
+	}
+
+	if req.Status != statusCancelled {
+		req.CancellationReason = ""
+	} else if req.CancellationReason != "" && !contains(cancellationReasons, req.CancellationReason) {
+		return fmt.Errorf("invalid cancellation reason: %s", req.CancellationReason)
+	}
+
+	if !canTransition(order.Status, req.Status) {
+		return &models.OrderTransitionError{
+			Message:         fmt.Sprintf("order cannot move from %s to %s", order.Status, req.Status),
+			Status:          order.Status,
+			AllowedStatuses: nextStatuses(order.Status),
+		}
+	}
+
+	if err := s.checkGuard(ctx, order, req); err != nil {
+		return err
+	}
+
+	moved, err := s.repo.TransitionOrderStatus(ctx, order.ID, order.Status, req, actor)
+	if err != nil {
+		return fmt.Errorf("failed to update order status: %w", err)
+	}
+	if !moved {
+		return fmt.Errorf("order was modified concurrently")
+	}
+
+	order.Status = req.Status
+	order.CancellationReason = req.CancellationReason
+
+	// The cancel has committed, so a failed refund is left on the timeline
+	// for an admin to retry rather than failing the request.
+	if order.Status == statusCancelled {
+		if err := s.refund(ctx, order.ID, actor); err != nil {
+			s.recordFailedRefund(ctx, order.ID, err, actor)
+		}
+		s.notifyRestocked(ctx, order)
+	}
+
+	s.notify(ctx, order)
+	return nil
+}
+
+// refund pays back what a cancelled order captured.
+func (s *Service) refund(ctx context.Context, orderID int, actor models.OrderActor) error {
+	if s.refunds == nil {
+		return fmt.Errorf("refunds are not available")
+	}
+	return s.refunds.RefundOrder(ctx, orderID, "order cancelled", actor)
+}
+
+// notifyRestocked tells the restock listener about the products a cancelled
+// order put back in stock.
+func (s *Service) notifyRestocked(ctx context.Context, order *models.Order) {
+	if s.restocks == nil {
+		return
+	}
+
+	seen := make(map[int]bool)
+	var productIDs []int
+	for _, item := range order.OrderItems {
+		if !seen[item.ProductID] {
+			seen[item.ProductID] = true
+			productIDs = append(productIDs, item.ProductID)
+		}
+	}
+	s.restocks.ProductsRestocked(ctx, productIDs)
+}
+
+// recordFailedRefund adds an internal payment event to the timeline of an
+// order whose cancellation refund failed.
+func (s *Service) recordFailedRefund(ctx context.Context, orderID int, refundErr error, actor models.OrderActor) {
+	slog.Error("failed to refund cancelled order", "order_id", orderID, "error", refundErr)
+
+	_, err := s.repo.AddOrderEvent(ctx, models.OrderEvent{
+		OrderID:  orderID,
+		Type:     models.OrderEventPayment,
+		Actor:    actor,
+		Message:  "Refund of cancelled order failed and needs to be retried",
+		Metadata: map[string]interface{}{"error": refundErr.Error()},
+		Internal: true,
+	})
+	if err != nil {
+		slog.Error("failed to record failed refund", "order_id", orderID, "error", err)
+	}
+}
+
+// checkGuard enforces what must be true before an order enters a status:
+// it is confirmed only once paid. The shipping statuses are never set here;
+// the fulfilment package ships only paid orders, and every shipment leaves
+// with a tracking number, so an order is shipped only once paid and tracked.
+func (s *Service) checkGuard(ctx context.Context, order *models.Order, req models.UpdateOrderStatusRequest) error {
+	if req.Status == statusConfirmed {
+		return s.requirePayment(ctx, order, req.Status)
+	}
+	return nil
+}
+
+// requirePayment checks the order has a completed payment. An order with
+// nothing to pay, such as an exchange's replacement, needs none.
+func (s *Service) requirePayment(ctx context.Context, order *models.Order, status string) error {
+	if order.TotalAmount == 0 {
+		return nil
+	}
+
+	payments, err := s.repo.GetPaymentsByOrderID(ctx, order.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get payments: %w", err)
+	}
+
+	for _, payment := range payments {
+		if payment.PaymentStatus == "completed" {
+			return nil
+		}
+	}
+
+	return &models.OrderTransitionError{
+		Message:         fmt.Sprintf("order must have a completed payment to be %s", status),
+		Status:          order.Status,
+		AllowedStatuses: nextStatuses(order.Status),
+	}
+}
+
+// notify emails the customer about the order's new status. A failed email
+// does not undo the transition. Shipping emails go out per shipment from the
+// fulfilment package.
+func (s *Service) notify(ctx context.Context, order *models.Order) {
+	if s.emailSvc == nil {
+		return
+	}
+
+	var send func(user *models.User, order *models.Order) error
+	switch order.Status {
+	case statusConfirmed:
+		send = s.emailSvc.SendOrderConfirmationEmail
+	case statusCancelled:
+		send = s.emailSvc.SendOrderCancellationEmail
+	default:
+		return
+	}
+
+	user, err := s.repo.GetUserByID(ctx, order.UserID)
+	if err != nil {
+		slog.Error("failed to load user for order email", "order_id", order.ID, "error", err)
+		return
+	}
+
+	if err := send(user, order); err != nil {
+		slog.Error("failed to send order email", "order_id", order.ID, "status", order.Status, "error", err)
+	}
+}
+
+func canTransition(from, to string) bool {
+	return contains(orderTransitions[from], to)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func nextStatuses(status string) []string {
+	next := orderTransitions[status]
+	if next == nil {
+		return []string{}
+	}
+	return next
+}
@@ -0,0 +1,232 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+)
+
+// fakeRepo records status moves and order events; the rest of Repository is
+// left unimplemented.
+type fakeRepo struct {
+	Repository
+	payments []models.Payment
+	stale    bool
+	moves    []string
+	events   []models.OrderEvent
+	paid     bool
+}
+
+func (r *fakeRepo) GetPaymentsByOrderID(ctx context.Context, orderID int) ([]models.Payment, error) {
+	return r.payments, nil
+}
+
+func (r *fakeRepo) TransitionOrderStatus(ctx context.Context, id int, from string, req models.UpdateOrderStatusRequest, actor models.OrderActor) (bool, error) {
+	if r.stale {
+		return false, nil
+	}
+	r.moves = append(r.moves, from+" -> "+req.Status)
+	return true, nil
+}
+
+func (r *fakeRepo) AddOrderEvent(ctx context.Context, event models.OrderEvent) (*models.OrderEvent, error) {
+	r.events = append(r.events, event)
+	return &event, nil
+}
+
+func (r *fakeRepo) GetOrderByID(ctx context.Context, id int) (*models.Order, error) {
+	return &models.Order{ID: id, Status: statusPending}, nil
+}
+
+func (r *fakeRepo) PayOrder(ctx context.Context, req models.CreatePaymentRequest, actor models.OrderActor) (*models.Payment, bool, error) {
+	if r.stale {
+		return nil, false, nil
+	}
+	r.paid = true
+	return &models.Payment{OrderID: req.OrderID, PaymentStatus: "completed"}, true, nil
+}
+
+type fakeRefunder struct {
+	err      error
+	refunded []int
+}
+
+func (f *fakeRefunder) RefundOrder(ctx context.Context, orderID int, reason string, actor models.OrderActor) error {
+	f.refunded = append(f.refunded, orderID)
+	return f.err
+}
+
+type fakeRestocks struct {
+	productIDs []int
+}
+
+func (f *fakeRestocks) ProductsRestocked(ctx context.Context, productIDs []int) {
+	f.productIDs = append(f.productIDs, productIDs...)
+}
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{statusPending, statusConfirmed, true},
+		{statusPending, statusCancelled, true},
+		{statusPending, statusShipped, false},
+		{statusConfirmed, statusPartiallyShipped, true},
+		{statusConfirmed, statusShipped, true},
+		{statusConfirmed, statusCancelled, true},
+		{statusConfirmed, statusDelivered, false},
+		{statusConfirmed, statusPending, false},
+		{statusPartiallyShipped, statusShipped, true},
+		{statusPartiallyShipped, statusCancelled, false},
+		{statusShipped, statusDelivered, true},
+		{statusShipped, statusCancelled, false},
+		{statusDelivered, statusCancelled, false},
+		{statusCancelled, statusConfirmed, false},
+		{"unknown", statusConfirmed, false},
+	}
+
+	for _, tt := range tests {
+		if got := canTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("canTransition(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestOrderTransitionsStayInGraph(t *testing.T) {
+	for from, targets := range orderTransitions {
+		for _, to := range targets {
+			if _, ok := orderTransitions[to]; !ok {
+				t.Errorf("%s moves to %s, which has no entry", from, to)
+			}
+		}
+	}
+
+	for _, status := range []string{statusDelivered, statusCancelled, "unknown"} {
+		if next := nextStatuses(status); next == nil || len(next) != 0 {
+			t.Errorf("nextStatuses(%s) = %v, want an empty list", status, next)
+		}
+	}
+}
+
+func TestTransition(t *testing.T) {
+	completed := []models.Payment{{PaymentStatus: "pending"}, {PaymentStatus: "completed"}}
+
+	tests := []struct {
+		name           string
+		from           string
+		total          float64
+		req            models.UpdateOrderStatusRequest
+		repo           *fakeRepo
+		wantMoves      []string
+		wantTransition bool
+		wantErr        bool
+	}{
+		{"confirm a paid order", statusPending, 10, models.UpdateOrderStatusRequest{Status: statusConfirmed}, &fakeRepo{payments: completed}, []string{"pending -> confirmed"}, false, false},
+		{"confirm without a completed payment", statusPending, 10, models.UpdateOrderStatusRequest{Status: statusConfirmed}, &fakeRepo{payments: completed[:1]}, nil, true, true},
+		{"confirm an order with nothing to pay", statusPending, 0, models.UpdateOrderStatusRequest{Status: statusConfirmed}, &fakeRepo{}, []string{"pending -> confirmed"}, false, false},
+		{"move off the graph", statusPending, 10, models.UpdateOrderStatusRequest{Status: statusDelivered}, &fakeRepo{}, nil, true, true},
+		{"unknown status", statusPending, 10, models.UpdateOrderStatusRequest{Status: "lost"}, &fakeRepo{}, nil, false, true},
+		{"unknown cancellation reason", statusPending, 10, models.UpdateOrderStatusRequest{Status: statusCancelled, CancellationReason: "bored"}, &fakeRepo{}, nil, false, true},
+		{"order changed concurrently", statusPending, 10, models.UpdateOrderStatusRequest{Status: statusCancelled}, &fakeRepo{stale: true}, nil, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewService(tt.repo, nil, nil, nil, nil, nil, nil, &fakeRefunder{}, nil, nil)
+			order := &models.Order{ID: 1, Status: tt.from, TotalAmount: tt.total}
+
+			err := svc.transition(context.Background(), order, tt.req, models.OrderActor{Role: "admin"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("transition error = %v, want error %v", err, tt.wantErr)
+			}
+
+			var transitionErr *models.OrderTransitionError
+			if errors.As(err, &transitionErr) != tt.wantTransition {
+				t.Errorf("transition error = %v, want a transition error %v", err, tt.wantTransition)
+			}
+			if !reflect.DeepEqual(tt.repo.moves, tt.wantMoves) {
+				t.Errorf("transition saved %v, want %v", tt.repo.moves, tt.wantMoves)
+			}
+			if err == nil && order.Status != tt.req.Status {
+				t.Errorf("order status = %s, want %s", order.Status, tt.req.Status)
+			}
+		})
+	}
+}
+
+func TestCancelRefundsAndRestocks(t *testing.T) {
+	order := func() *models.Order {
+		return &models.Order{ID: 7, Status: statusConfirmed, OrderItems: []models.OrderItem{{ProductID: 3}, {ProductID: 4}, {ProductID: 3}}}
+	}
+	cancel := models.UpdateOrderStatusRequest{Status: statusCancelled, CancellationReason: "changed_mind"}
+
+	tests := []struct {
+		name           string
+		refunds        *fakeRefunder
+		wantFailedNote bool
+	}{
+		{"refund succeeds", &fakeRefunder{}, false},
+		{"refund fails", &fakeRefunder{err: errors.New("provider down")}, true},
+		{"no refunder", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepo{}
+			restocks := &fakeRestocks{}
+			var refunds Refunder
+			if tt.refunds != nil {
+				refunds = tt.refunds
+			}
+			svc := NewService(repo, nil, nil, nil, nil, nil, nil, refunds, nil, restocks)
+
+			if err := svc.transition(context.Background(), order(), cancel, models.OrderActor{Role: "customer"}); err != nil {
+				t.Fatalf("transition: %v", err)
+			}
+
+			if tt.refunds != nil && !reflect.DeepEqual(tt.refunds.refunded, []int{7}) {
+				t.Errorf("refunded orders = %v, want [7]", tt.refunds.refunded)
+			}
+			failedNote := len(repo.events) == 1 && repo.events[0].Internal && repo.events[0].Type == models.OrderEventPayment
+			if failedNote != tt.wantFailedNote {
+				t.Errorf("timeline = %+v, want a failed refund note %v", repo.events, tt.wantFailedNote)
+			}
+			if !reflect.DeepEqual(restocks.productIDs, []int{3, 4}) {
+				t.Errorf("restocked products = %v, want [3 4]", restocks.productIDs)
+			}
+		})
+	}
+}
+
+func TestProcessPayment(t *testing.T) {
+	tests := []struct {
+		name     string
+		repo     *fakeRepo
+		wantPaid bool
+		wantErr  bool
+	}{
+		{"pending order", &fakeRepo{}, true, false},
+		{"order no longer pending", &fakeRepo{stale: true}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewService(tt.repo, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+			payment, err := svc.ProcessPayment(context.Background(), models.CreatePaymentRequest{OrderID: 9, PaymentMethod: "card"}, models.OrderActor{Role: "customer"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ProcessPayment error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.repo.paid != tt.wantPaid {
+				t.Errorf("ProcessPayment paid = %v, want %v", tt.repo.paid, tt.wantPaid)
+			}
+			if err == nil && payment.PaymentStatus != "completed" {
+				t.Errorf("payment status = %s, want completed", payment.PaymentStatus)
+			}
+		})
+	}
+}
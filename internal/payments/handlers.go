@@ -0,0 +1,75 @@
+package payments
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/VishalHilal/e-commerce-api/internal/auth"
+	"github.com/VishalHilal/e-commerce-api/internal/json"
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+	"github.com/go-chi/chi/v5"
+)
+
+type handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *handler {
+	return &handler{service: service}
+}
+
+func (h *handler) CreateRefund(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil || claims.Role != "admin" {
+		json.WriteError(w, http.StatusForbidden, "Admin access required")
+		return
+	}
+
+	orderID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	var req models.CreateRefundRequest
+	if r.ContentLength != 0 {
+		if err := json.Read(r, &req); err != nil {
+			json.WriteError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	userID := claims.UserID
+	refund, err := h.service.Refund(r.Context(), orderID, req, models.OrderActor{ID: &userID, Role: claims.Role})
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusCreated, refund)
+}
+
+func (h *handler) GetRefunds(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil || claims.Role != "admin" {
+		json.WriteError(w, http.StatusForbidden, "Admin access required")
+		return
+	}
+
+	orderID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	refunds, err := h.service.GetRefunds(r.Context(), orderID)
+	if err != nil {
+		json.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, map[string]interface{}{
+		"refunds": refunds,
+		"count":   len(refunds),
+	})
+}
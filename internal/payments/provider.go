@@ -0,0 +1,29 @@
+package payments
+
+import (
+	"context"
+	"strings"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+	"github.com/google/uuid"
+)
+
+// Provider is the payment service that moves the money. Refund pays amount
+// back against a captured payment and returns the provider's reference for
+// the refund.
+type Provider interface {
+	Refund(ctx context.Context, payment models.Payment, amount float64) (string, error)
+}
+
+// ManualProvider is the provider for payments taken outside the store, where
+// staff pay refunds back by hand. It accepts every refund and issues a
+// reference for it.
+type ManualProvider struct{}
+
+func NewManualProvider() *ManualProvider {
+	return &ManualProvider{}
+}
+
+func (p *ManualProvider) Refund(ctx context.Context, payment models.Payment, amount float64) (string, error) {
+	return "MANUAL-" + strings.ToUpper(uuid.New().String()[:8]), nil
+}
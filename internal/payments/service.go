@@ -0,0 +1,305 @@
+package payments
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+)
+
+type Repository interface {
+	CreatePendingRefund(ctx context.Context, refund models.Refund) (*models.Refund, error)
+	RecordRefundReference(ctx context.Context, id int, providerReference string) error
+	CompleteRefund(ctx context.Context, id int, providerReference string, actor models.OrderActor) (*models.Refund, error)
+	GetPaidPendingRefunds(ctx context.Context) ([]models.Refund, error)
+	FailRefund(ctx context.Context, id int, reason string) error
+	GetRefundsByOrderID(ctx context.Context, orderID int) ([]models.Refund, error)
+	GetRefundedAmounts(ctx context.Context, orderID int) (map[int]float64, error)
+	GetPaymentsByOrderID(ctx context.Context, orderID int) ([]models.Payment, error)
+	GetOrderByID(ctx context.Context, id int) (*models.Order, error)
+}
+
+// CreditNoter issues the credit note for a completed refund against the
+// invoice of its order.
+type CreditNoter interface {
+	IssueCreditNote(ctx context.Context, refund *models.Refund) (*models.CreditNote, error)
+}
+
+type Service struct {
+	repo        Repository
+	provider    Provider
+	creditNotes CreditNoter
+}
+
+// NewService creates the payment service. creditNotes may be nil, in which
+// case refunds are not credited on invoices.
+func NewService(repo Repository, provider Provider, creditNotes CreditNoter) *Service {
+	return &Service{
+		repo:        repo,
+		provider:    provider,
+		creditNotes: creditNotes,
+	}
+}
+
+// Refund pays back part or all of one of the order's captured payments
+// through the payment provider: a given amount, the price paid for some of
+// the order's items, or with neither, whatever is left of the payment.
+func (s *Service) Refund(ctx context.Context, orderID int, req models.CreateRefundRequest, actor models.OrderActor) (*models.Refund, error) {
+	order, err := s.repo.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("order not found")
+	}
+
+	refund := models.Refund{
+		OrderID:   order.ID,
+		Reason:    strings.TrimSpace(req.Reason),
+		CreatedBy: actor.ID,
+	}
+
+	switch {
+	case req.Amount != nil && len(req.Items) > 0:
+		return nil, fmt.Errorf("give either an amount or items to refund, not both")
+	case req.Amount != nil:
+		if *req.Amount <= 0 {
+			return nil, fmt.Errorf("amount must be greater than 0")
+		}
+		refund.Amount = roundCents(*req.Amount)
+	case len(req.Items) > 0:
+		refund.Items, err = refundItems(order, req.Items)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range refund.Items {
+			refund.Amount += item.Amount
+		}
+		refund.Amount = roundCents(refund.Amount)
+	}
+
+	payment, err := s.refundablePayment(ctx, order.ID, req.PaymentID)
+	if err != nil {
+		return nil, err
+	}
+	refund.PaymentID = payment.ID
+
+	return s.payOut(ctx, refund, payment, actor)
+}
+
+// RefundOrder refunds whatever is left of each of the order's captured
+// payments, as when the order is cancelled.
+func (s *Service) RefundOrder(ctx context.Context, orderID int, reason string, actor models.OrderActor) error {
+	payments, err := s.repo.GetPaymentsByOrderID(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("failed to get payments: %w", err)
+	}
+
+	refunded, err := s.repo.GetRefundedAmounts(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("failed to get refunds: %w", err)
+	}
+
+	for i := range payments {
+		payment := &payments[i]
+		if payment.PaymentStatus != "completed" || roundCents(payment.Amount-refunded[payment.ID]) <= 0 {
+			continue
+		}
+
+		refund := models.Refund{
+			OrderID:   orderID,
+			PaymentID: payment.ID,
+			Reason:    reason,
+			CreatedBy: actor.ID,
+		}
+		if _, err := s.payOut(ctx, refund, payment, actor); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) GetRefunds(ctx context.Context, orderID int) ([]models.Refund, error) {
+	if _, err := s.repo.GetOrderByID(ctx, orderID); err != nil {
+		return nil, fmt.Errorf("order not found")
+	}
+
+	refunds, err := s.repo.GetRefundsByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refunds: %w", err)
+	}
+	return refunds, nil
+}
+
+// payOut records the refund as pending, which holds its amount against the
+// payment, and has the provider pay it. A refund the provider turns down is
+// recorded as failed and its amount released. The reference of a paid
+// refund is kept on it before it is completed, so a refund that cannot be
+// completed is left for ReconcileRefunds rather than lost.
+func (s *Service) payOut(ctx context.Context, refund models.Refund, payment *models.Payment, actor models.OrderActor) (*models.Refund, error) {
+	pending, err := s.repo.CreatePendingRefund(ctx, refund)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create refund: %w", err)
+	}
+
+	reference, err := s.provider.Refund(ctx, *payment, pending.Amount)
+	if err != nil {
+		if failErr := s.repo.FailRefund(ctx, pending.ID, err.Error()); failErr != nil {
+			return nil, fmt.Errorf("failed to record failed refund: %w", failErr)
+		}
+		return nil, fmt.Errorf("payment provider refused the refund: %w", err)
+	}
+
+	if err := s.repo.RecordRefundReference(ctx, pending.ID, reference); err != nil {
+		slog.Error("failed to record refund reference", "refund_id", pending.ID, "provider_reference", reference, "error", err)
+	}
+
+	pending.ProviderReference = reference
+	completed, err := s.complete(ctx, pending, actor)
+	if err != nil {
+		return nil, fmt.Errorf("refund %d was paid out as %s but could not be completed: %w", pending.ID, reference, err)
+	}
+
+	return completed, nil
+}
+
+// ReconcileRefunds completes the refunds the provider paid that were left
+// pending because completing them failed.
+func (s *Service) ReconcileRefunds(ctx context.Context) error {
+	refunds, err := s.repo.GetPaidPendingRefunds(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get pending refunds: %w", err)
+	}
+
+	actor := models.OrderActor{Role: "system"}
+	var failed int
+	for i := range refunds {
+		if _, err := s.complete(ctx, &refunds[i], actor); err != nil {
+			slog.Error("failed to complete paid refund", "refund_id", refunds[i].ID, "error", err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d paid refunds could not be completed", failed, len(refunds))
+	}
+	return nil
+}
+
+// StartRefundReconcileJob runs ReconcileRefunds right away and then on every
+// interval until ctx is cancelled.
+func (s *Service) StartRefundReconcileJob(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := s.ReconcileRefunds(ctx); err != nil {
+				slog.Error("refund reconcile job failed", "error", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// complete records a paid refund as completed and credits it on the order's
+// invoice; the refund stands if that fails.
+func (s *Service) complete(ctx context.Context, refund *models.Refund, actor models.OrderActor) (*models.Refund, error) {
+	completed, err := s.repo.CompleteRefund(ctx, refund.ID, refund.ProviderReference, actor)
+	if err != nil {
+		return nil, err
+	}
+	completed.Items = refund.Items
+
+	if s.creditNotes != nil {
+		if _, err := s.creditNotes.IssueCreditNote(ctx, completed); err != nil {
+			slog.Error("failed to issue credit note", "refund_id", completed.ID, "error", err)
+		}
+	}
+
+	return completed, nil
+}
+
+// refundablePayment picks the payment to refund: the one asked for, or the
+// only captured payment of the order with something left to refund.
+func (s *Service) refundablePayment(ctx context.Context, orderID int, paymentID *int) (*models.Payment, error) {
+	payments, err := s.repo.GetPaymentsByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payments: %w", err)
+	}
+
+	refunded, err := s.repo.GetRefundedAmounts(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refunds: %w", err)
+	}
+
+	var candidates []*models.Payment
+	for i := range payments {
+		payment := &payments[i]
+		if paymentID != nil {
+			if payment.ID == *paymentID {
+				return payment, nil
+			}
+			continue
+		}
+		if payment.PaymentStatus == "completed" && roundCents(payment.Amount-refunded[payment.ID]) > 0 {
+			candidates = append(candidates, payment)
+		}
+	}
+
+	if paymentID != nil {
+		return nil, fmt.Errorf("payment %d is not part of the order", *paymentID)
+	}
+
+	switch len(candidates) {
+	case 0:
+		return nil, fmt.Errorf("order has no captured payment left to refund")
+	case 1:
+		return candidates[0], nil
+	default:
+		return nil, fmt.Errorf("payment_id is required when the order has several payments")
+	}
+}
+
+// refundItems prices the items to refund at what was paid for them.
+func refundItems(order *models.Order, items []models.RefundItemRequest) ([]models.RefundItem, error) {
+	orderItems := make(map[int]models.OrderItem)
+	for _, item := range order.OrderItems {
+		orderItems[item.ID] = item
+	}
+
+	seen := make(map[int]bool)
+	refundItems := make([]models.RefundItem, 0, len(items))
+	for _, item := range items {
+		orderItem, ok := orderItems[item.OrderItemID]
+		if !ok {
+			return nil, fmt.Errorf("order item %d is not part of the order", item.OrderItemID)
+		}
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("quantity must be greater than 0")
+		}
+		if seen[item.OrderItemID] {
+			return nil, fmt.Errorf("order item %d is listed more than once", item.OrderItemID)
+		}
+		seen[item.OrderItemID] = true
+
+		refundItems = append(refundItems, models.RefundItem{
+			OrderItemID: item.OrderItemID,
+			Quantity:    item.Quantity,
+			Amount:      roundCents(float64(item.Quantity) * orderItem.UnitPrice),
+		})
+	}
+
+	return refundItems, nil
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,79 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+)
+
+type fakeRepo struct {
+	Repository
+	refunds      map[int]*models.Refund
+	completeFail bool
+}
+
+func (r *fakeRepo) CreatePendingRefund(ctx context.Context, refund models.Refund) (*models.Refund, error) {
+	refund.ID = len(r.refunds) + 1
+	refund.Status = "pending"
+	r.refunds[refund.ID] = &refund
+	created := refund
+	return &created, nil
+}
+
+func (r *fakeRepo) RecordRefundReference(ctx context.Context, id int, providerReference string) error {
+	r.refunds[id].ProviderReference = providerReference
+	return nil
+}
+
+func (r *fakeRepo) CompleteRefund(ctx context.Context, id int, providerReference string, actor models.OrderActor) (*models.Refund, error) {
+	if r.completeFail {
+		return nil, errors.New("connection lost")
+	}
+	refund := r.refunds[id]
+	refund.Status = "completed"
+	refund.ProviderReference = providerReference
+	completed := *refund
+	return &completed, nil
+}
+
+func (r *fakeRepo) GetPaidPendingRefunds(ctx context.Context) ([]models.Refund, error) {
+	var refunds []models.Refund
+	for _, refund := range r.refunds {
+		if refund.Status == "pending" && refund.ProviderReference != "" {
+			refunds = append(refunds, *refund)
+		}
+	}
+	return refunds, nil
+}
+
+type fakeProvider struct{}
+
+func (fakeProvider) Refund(ctx context.Context, payment models.Payment, amount float64) (string, error) {
+	return "REF-1", nil
+}
+
+func TestPayOutLeavesPaidRefundsToReconcile(t *testing.T) {
+	repo := &fakeRepo{refunds: make(map[int]*models.Refund), completeFail: true}
+	svc := NewService(repo, fakeProvider{}, nil)
+	ctx := context.Background()
+
+	refund := models.Refund{OrderID: 1, PaymentID: 2, Amount: 10}
+	if _, err := svc.payOut(ctx, refund, &models.Payment{ID: 2}, models.OrderActor{Role: "admin"}); err == nil {
+		t.Fatal("payOut succeeded although the refund could not be completed")
+	}
+
+	pending := repo.refunds[1]
+	if pending.Status != "pending" || pending.ProviderReference != "REF-1" {
+		t.Fatalf("refund after a failed completion = %s with reference %q, want pending with REF-1", pending.Status, pending.ProviderReference)
+	}
+
+	repo.completeFail = false
+	if err := svc.ReconcileRefunds(ctx); err != nil {
+		t.Fatalf("ReconcileRefunds: %v", err)
+	}
+	if got := repo.refunds[1]; got.Status != "completed" || got.ProviderReference != "REF-1" {
+		t.Errorf("refund after reconciling = %s with reference %q, want completed with REF-1", got.Status, got.ProviderReference)
+	}
+}
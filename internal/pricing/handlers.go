@@ -0,0 +1,221 @@
+package pricing
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/VishalHilal/e-commerce-api/internal/auth"
+	"github.com/VishalHilal/e-commerce-api/internal/json"
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+	"github.com/go-chi/chi/v5"
+)
+
+type handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *handler {
+	return &handler{service: service}
+}
+
+func (h *handler) GetPriceBreaks(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	userID := 0
+	if claims := auth.GetUserFromContext(r.Context()); claims != nil {
+		userID = claims.UserID
+	}
+
+	breaks, err := h.service.GetPriceBreaks(r.Context(), productID, userID)
+	if err != nil {
+		json.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, map[string]interface{}{
+		"price_breaks": breaks,
+		"count":        len(breaks),
+	})
+}
+
+func (h *handler) SetPriceBreaks(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil || claims.Role != "admin" {
+		json.WriteError(w, http.StatusForbidden, "Admin access required")
+		return
+	}
+
+	productID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	var req models.SetPriceBreaksRequest
+	if err := json.Read(r, &req); err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	breaks, err := h.service.SetPriceBreaks(r.Context(), productID, req)
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, map[string]interface{}{
+		"price_breaks": breaks,
+		"count":        len(breaks),
+	})
+}
+
+func (h *handler) CreateCustomerGroup(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil || claims.Role != "admin" {
+		json.WriteError(w, http.StatusForbidden, "Admin access required")
+		return
+	}
+
+	var req models.CreateCustomerGroupRequest
+	if err := json.Read(r, &req); err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	group, err := h.service.CreateCustomerGroup(r.Context(), req)
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusCreated, group)
+}
+
+func (h *handler) GetCustomerGroups(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil || claims.Role != "admin" {
+		json.WriteError(w, http.StatusForbidden, "Admin access required")
+		return
+	}
+
+	groups, err := h.service.GetCustomerGroups(r.Context())
+	if err != nil {
+		json.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, map[string]interface{}{
+		"customer_groups": groups,
+		"count":           len(groups),
+	})
+}
+
+func (h *handler) AssignCustomerGroup(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil || claims.Role != "admin" {
+		json.WriteError(w, http.StatusForbidden, "Admin access required")
+		return
+	}
+
+	userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	var req models.AssignCustomerGroupRequest
+	if err := json.Read(r, &req); err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.service.AssignCustomerGroup(r.Context(), userID, req.CustomerGroupID); err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, map[string]string{"message": "Customer group updated successfully"})
+}
+
+func (h *handler) GetPriceList(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil || claims.Role != "admin" {
+		json.WriteError(w, http.StatusForbidden, "Admin access required")
+		return
+	}
+
+	groupID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid customer group ID")
+		return
+	}
+
+	items, err := h.service.GetPriceList(r.Context(), groupID)
+	if err != nil {
+		json.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, map[string]interface{}{
+		"prices": items,
+		"count":  len(items),
+	})
+}
+
+func (h *handler) SetPriceList(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil || claims.Role != "admin" {
+		json.WriteError(w, http.StatusForbidden, "Admin access required")
+		return
+	}
+
+	groupID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid customer group ID")
+		return
+	}
+
+	var req models.SetPriceListRequest
+	if err := json.Read(r, &req); err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.service.SetPriceList(r.Context(), groupID, req); err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, map[string]string{"message": "Price list updated successfully"})
+}
+
+func (h *handler) RemovePriceListItem(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil || claims.Role != "admin" {
+		json.WriteError(w, http.StatusForbidden, "Admin access required")
+		return
+	}
+
+	groupID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid customer group ID")
+		return
+	}
+
+	productID, err := strconv.Atoi(chi.URLParam(r, "product_id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	if err := h.service.RemovePriceListItem(r.Context(), groupID, productID); err != nil {
+		json.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, map[string]string{"message": "Price removed successfully"})
+}
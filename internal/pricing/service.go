@@ -0,0 +1,245 @@
+package pricing
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+)
+
+type Repository interface {
+	CreateCustomerGroup(ctx context.Context, req models.CreateCustomerGroupRequest) (*models.CustomerGroup, error)
+	GetCustomerGroups(ctx context.Context) ([]models.CustomerGroup, error)
+	GetCustomerGroupByID(ctx context.Context, id int) (*models.CustomerGroup, error)
+	SetUserCustomerGroup(ctx context.Context, userID int, groupID *int) error
+	GetPriceList(ctx context.Context, groupID int) ([]models.PriceListItem, error)
+	SetPriceListItems(ctx context.Context, groupID int, entries []models.PriceListEntry) error
+	RemovePriceListItem(ctx context.Context, groupID, productID int) error
+	GetPriceBreaks(ctx context.Context, productID int) ([]models.PriceBreak, error)
+	GetPublicPriceBreaks(ctx context.Context, productID int, groupID *int) ([]models.PriceBreak, error)
+	GetProductPricing(ctx context.Context, productIDs []int, groupID *int) ([]models.ProductPricing, error)
+	SetPriceBreaks(ctx context.Context, productID int, breaks []models.PriceBreakRequest) error
+	GetUserByID(ctx context.Context, id int) (*models.User, error)
+	GetProductByID(ctx context.Context, id int) (*models.Product, error)
+}
+
+// Service manages the inputs of price resolution and resolves the best price
+// from them. Carts and orders are priced through PriceItems whenever they are
+// read or created, so the cart, checkout and order totals always agree.
+type Service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) *Service {
+	return &Service{repo: repo}
+}
+
+func (s *Service) CreateCustomerGroup(ctx context.Context, req models.CreateCustomerGroupRequest) (*models.CustomerGroup, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return nil, fmt.Errorf("customer group name is required")
+	}
+
+	group, err := s.repo.CreateCustomerGroup(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create customer group: %w", err)
+	}
+
+	return group, nil
+}
+
+func (s *Service) GetCustomerGroups(ctx context.Context) ([]models.CustomerGroup, error) {
+	groups, err := s.repo.GetCustomerGroups(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer groups: %w", err)
+	}
+	return groups, nil
+}
+
+// AssignCustomerGroup puts the user in a group, or takes them out of their
+// group when groupID is nil.
+func (s *Service) AssignCustomerGroup(ctx context.Context, userID int, groupID *int) error {
+	if _, err := s.repo.GetUserByID(ctx, userID); err != nil {
+		return fmt.Errorf("user not found")
+	}
+
+	if groupID != nil {
+		if _, err := s.repo.GetCustomerGroupByID(ctx, *groupID); err != nil {
+			return fmt.Errorf("customer group not found")
+		}
+	}
+
+	if err := s.repo.SetUserCustomerGroup(ctx, userID, groupID); err != nil {
+		return fmt.Errorf("failed to assign customer group: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) GetPriceList(ctx context.Context, groupID int) ([]models.PriceListItem, error) {
+	if _, err := s.repo.GetCustomerGroupByID(ctx, groupID); err != nil {
+		return nil, fmt.Errorf("customer group not found")
+	}
+
+	items, err := s.repo.GetPriceList(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price list: %w", err)
+	}
+	return items, nil
+}
+
+func (s *Service) SetPriceList(ctx context.Context, groupID int, req models.SetPriceListRequest) error {
+	if len(req.Items) == 0 {
+		return fmt.Errorf("at least one price is required")
+	}
+
+	if _, err := s.repo.GetCustomerGroupByID(ctx, groupID); err != nil {
+		return fmt.Errorf("customer group not found")
+	}
+
+	for _, entry := range req.Items {
+		if entry.Price < 0 {
+			return fmt.Errorf("price for product %d cannot be negative", entry.ProductID)
+		}
+		if _, err := s.repo.GetProductByID(ctx, entry.ProductID); err != nil {
+			return fmt.Errorf("product %d not found", entry.ProductID)
+		}
+	}
+
+	if err := s.repo.SetPriceListItems(ctx, groupID, req.Items); err != nil {
+		return fmt.Errorf("failed to update price list: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) RemovePriceListItem(ctx context.Context, groupID, productID int) error {
+	if err := s.repo.RemovePriceListItem(ctx, groupID, productID); err != nil {
+		return fmt.Errorf("failed to remove price: %w", err)
+	}
+	return nil
+}
+
+// GetPriceBreaks returns the product's price breaks the user can get: those
+// open to everyone and those of the user's customer group. userID 0 is a
+// guest, who only sees the former.
+func (s *Service) GetPriceBreaks(ctx context.Context, productID, userID int) ([]models.PriceBreak, error) {
+	groupID, err := s.customerGroup(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	breaks, err := s.repo.GetPublicPriceBreaks(ctx, productID, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price breaks: %w", err)
+	}
+	return breaks, nil
+}
+
+// SetPriceBreaks replaces the product's quantity price breaks. An empty list
+// removes them all.
+func (s *Service) SetPriceBreaks(ctx context.Context, productID int, req models.SetPriceBreaksRequest) ([]models.PriceBreak, error) {
+	if _, err := s.repo.GetProductByID(ctx, productID); err != nil {
+		return nil, fmt.Errorf("product not found: %w", err)
+	}
+
+	for _, priceBreak := range req.Breaks {
+		if priceBreak.MinQuantity < 2 {
+			return nil, fmt.Errorf("minimum quantity must be at least 2")
+		}
+		if priceBreak.DiscountPercent <= 0 || priceBreak.DiscountPercent >= 100 {
+			return nil, fmt.Errorf("discount percent must be between 0 and 100")
+		}
+		if priceBreak.CustomerGroupID != nil {
+			if _, err := s.repo.GetCustomerGroupByID(ctx, *priceBreak.CustomerGroupID); err != nil {
+				return nil, fmt.Errorf("customer group not found")
+			}
+		}
+	}
+
+	if err := s.repo.SetPriceBreaks(ctx, productID, req.Breaks); err != nil {
+		return nil, fmt.Errorf("failed to update price breaks: %w", err)
+	}
+
+	breaks, err := s.repo.GetPriceBreaks(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price breaks: %w", err)
+	}
+	return breaks, nil
+}
+
+// PriceItems sets each item's unit price to the best one the user gets for
+// the item's quantity, and its list price to the product's. userID 0 prices
+// for a guest. It implements cart.Pricer and orders.Pricer.
+func (s *Service) PriceItems(ctx context.Context, userID int, items []models.CartItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	groupID, err := s.customerGroup(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	productIDs := make([]int, 0, len(items))
+	for _, item := range items {
+		productIDs = append(productIDs, item.ProductID)
+	}
+
+	pricing, err := s.repo.GetProductPricing(ctx, productIDs, groupID)
+	if err != nil {
+		return fmt.Errorf("failed to get product pricing: %w", err)
+	}
+
+	byProduct := make(map[int]models.ProductPricing, len(pricing))
+	for _, product := range pricing {
+		byProduct[product.ProductID] = product
+	}
+
+	for i := range items {
+		product, ok := byProduct[items[i].ProductID]
+		if !ok {
+			return fmt.Errorf("product %d not found", items[i].ProductID)
+		}
+		items[i].UnitPrice = bestPrice(product, items[i].Quantity)
+		items[i].ListPrice = product.ListPrice
+	}
+
+	return nil
+}
+
+// customerGroup returns the user's customer group, or nil for a guest or a
+// user in no group.
+func (s *Service) customerGroup(ctx context.Context, userID int) (*int, error) {
+	if userID == 0 {
+		return nil, nil
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user.CustomerGroupID, nil
+}
+
+// bestPrice is the lowest of the list price, the group price and the list
+// price less the largest price break the quantity reaches, rounded to cents.
+// The breaks must already be limited to those open to the customer.
+func bestPrice(product models.ProductPricing, quantity int) float64 {
+	price := product.ListPrice
+	if product.GroupPrice != nil {
+		price = math.Min(price, *product.GroupPrice)
+	}
+
+	var discount float64
+	for _, priceBreak := range product.Breaks {
+		if priceBreak.MinQuantity <= quantity {
+			discount = math.Max(discount, priceBreak.DiscountPercent)
+		}
+	}
+	price = math.Min(price, product.ListPrice*(1-discount/100))
+
+	return math.Round(price*100) / 100
+}
@@ -0,0 +1,40 @@
+package pricing
+
+import (
+	"testing"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+)
+
+func TestBestPrice(t *testing.T) {
+	groupPrice := 85.0
+	cheapGroupPrice := 60.0
+	breaks := []models.PriceBreak{
+		{MinQuantity: 5, DiscountPercent: 10},
+		{MinQuantity: 10, DiscountPercent: 25},
+		{MinQuantity: 3, DiscountPercent: 5},
+	}
+
+	tests := []struct {
+		name     string
+		product  models.ProductPricing
+		quantity int
+		want     float64
+	}{
+		{"list price", models.ProductPricing{ListPrice: 100}, 1, 100},
+		{"group price below list", models.ProductPricing{ListPrice: 100, GroupPrice: &groupPrice}, 1, 85},
+		{"break not reached", models.ProductPricing{ListPrice: 100, Breaks: breaks}, 2, 100},
+		{"largest break reached", models.ProductPricing{ListPrice: 100, Breaks: breaks}, 7, 90},
+		{"break beats group price", models.ProductPricing{ListPrice: 100, GroupPrice: &groupPrice, Breaks: breaks}, 10, 75},
+		{"group price beats break", models.ProductPricing{ListPrice: 100, GroupPrice: &cheapGroupPrice, Breaks: breaks}, 10, 60},
+		{"rounded to cents", models.ProductPricing{ListPrice: 9.99, Breaks: breaks}, 3, 9.49},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bestPrice(tt.product, tt.quantity); got != tt.want {
+				t.Errorf("bestPrice = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,8 +1,10 @@
 package products
 
 import (
+	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/VishalHilal/e-commerce-api/internal/auth"
 	"github.com/VishalHilal/e-commerce-api/internal/json"
@@ -56,9 +58,40 @@ func (h *handler) GetProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if claims := auth.GetUserFromContext(r.Context()); claims != nil {
+		if err := h.service.RecordView(r.Context(), claims.UserID, product.ID); err != nil {
+			slog.Error("failed to record product view", "product_id", product.ID, "error", err)
+		}
+	}
+
 	json.Write(w, http.StatusOK, product)
 }
 
+func (h *handler) CompareProducts(w http.ResponseWriter, r *http.Request) {
+	var ids []int
+	for _, raw := range strings.Split(r.URL.Query().Get("ids"), ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+
+		id, err := strconv.Atoi(raw)
+		if err != nil {
+			json.WriteError(w, http.StatusBadRequest, "Invalid product ID: "+raw)
+			return
+		}
+		ids = append(ids, id)
+	}
+
+	comparison, err := h.service.CompareProducts(r.Context(), ids)
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, comparison)
+}
+
 func (h *handler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 	claims := auth.GetUserFromContext(r.Context())
 	if claims == nil || claims.Role != "admin" {
@@ -108,6 +141,33 @@ func (h *handler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 	json.Write(w, http.StatusOK, map[string]string{"message": "Product updated successfully"})
 }
 
+func (h *handler) UpdateProductAttributes(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil || claims.Role != "admin" {
+		json.WriteError(w, http.StatusForbidden, "Admin access required")
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	var req models.UpdateProductAttributesRequest
+	if err := json.Read(r, &req); err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.service.SetAttributes(r.Context(), id, req); err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, map[string]string{"message": "Product attributes updated successfully"})
+}
+
 func (h *handler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 	claims := auth.GetUserFromContext(r.Context())
 	if claims == nil || claims.Role != "admin" {
@@ -3,6 +3,7 @@ package products
 import (
 	"context"
 	"fmt"
+	"sort"
 
 	"github.com/VishalHilal/e-commerce-api/internal/models"
 )
@@ -13,14 +14,30 @@ type Repository interface {
 	GetProductByID(ctx context.Context, id int) (*models.Product, error)
 	UpdateProduct(ctx context.Context, id int, product models.UpdateProductRequest) error
 	DeleteProduct(ctx context.Context, id int) error
+	RecordProductView(ctx context.Context, userID, productID int) error
+	GetProductAttributes(ctx context.Context, productID int) (map[string]string, error)
+	SetProductAttributes(ctx context.Context, productID int, attributes map[string]string) error
+	GetProductAverageRating(ctx context.Context, productID int) (float64, int, error)
+}
+
+const maxCompareProducts = 4
+
+// UpdateListener is notified after a product has been updated, so features
+// that depend on price or stock can react to the change.
+type UpdateListener interface {
+	ProductUpdated(ctx context.Context, product *models.Product)
 }
 
 type Service struct {
-	repo Repository
+	repo      Repository
+	listeners []UpdateListener
 }
 
-func NewService(repo Repository) *Service {
-	return &Service{repo: repo}
+func NewService(repo Repository, listeners ...UpdateListener) *Service {
+	return &Service{
+		repo:      repo,
+		listeners: listeners,
+	}
 }
 
 func (s *Service) ListProducts(ctx context.Context, filter models.ProductFilter) ([]models.Product, error) {
@@ -32,9 +49,118 @@ func (s *Service) GetProduct(ctx context.Context, id int) (*models.Product, erro
 	if err != nil {
 		return nil, fmt.Errorf("failed to get product: %w", err)
 	}
+
+	attributes, err := s.repo.GetProductAttributes(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product attributes: %w", err)
+	}
+	product.Attributes = attributes
+
 	return product, nil
 }
 
+func (s *Service) RecordView(ctx context.Context, userID, productID int) error {
+	if err := s.repo.RecordProductView(ctx, userID, productID); err != nil {
+		return fmt.Errorf("failed to record product view: %w", err)
+	}
+	return nil
+}
+
+// CompareProducts builds a side-by-side comparison of up to four products.
+// Unknown or inactive products are reported in Errors instead of failing the
+// whole comparison.
+func (s *Service) CompareProducts(ctx context.Context, ids []int) (*models.ProductComparison, error) {
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("at least one product ID is required")
+	}
+
+	if len(ids) > maxCompareProducts {
+		return nil, fmt.Errorf("at most %d products can be compared", maxCompareProducts)
+	}
+
+	comparison := &models.ProductComparison{
+		Products: []models.Product{},
+		Rows:     []models.ComparisonRow{},
+	}
+
+	var ratings []float64
+	var reviewCounts []int
+	seen := make(map[int]bool)
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		product, err := s.GetProduct(ctx, id)
+		if err != nil {
+			comparison.Errors = append(comparison.Errors, models.ComparisonError{ProductID: id, Error: "product not found"})
+			continue
+		}
+
+		if !product.IsActive {
+			comparison.Errors = append(comparison.Errors, models.ComparisonError{ProductID: id, Error: "product is not available"})
+			continue
+		}
+
+		avgRating, reviewCount, err := s.repo.GetProductAverageRating(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get product rating: %w", err)
+		}
+
+		comparison.Products = append(comparison.Products, *product)
+		ratings = append(ratings, avgRating)
+		reviewCounts = append(reviewCounts, reviewCount)
+	}
+
+	products := comparison.Products
+	comparison.Rows = append(comparison.Rows,
+		comparisonRow("price", "Price", len(products), func(i int) interface{} { return products[i].Price }),
+		comparisonRow("stock_quantity", "Stock", len(products), func(i int) interface{} { return products[i].StockQuantity }),
+		comparisonRow("average_rating", "Average rating", len(products), func(i int) interface{} { return ratings[i] }),
+		comparisonRow("review_count", "Reviews", len(products), func(i int) interface{} { return reviewCounts[i] }),
+	)
+
+	var names []string
+	for _, product := range products {
+		for name := range product.Attributes {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for n, name := range names {
+		if n > 0 && names[n-1] == name {
+			continue
+		}
+		comparison.Rows = append(comparison.Rows, comparisonRow("attributes."+name, name, len(products), func(i int) interface{} {
+			if value, ok := products[i].Attributes[name]; ok {
+				return value
+			}
+			return nil
+		}))
+	}
+
+	return comparison, nil
+}
+
+func comparisonRow(field, label string, count int, value func(i int) interface{}) models.ComparisonRow {
+	row := models.ComparisonRow{
+		Field:  field,
+		Label:  label,
+		Values: make([]interface{}, count),
+	}
+
+	for i := 0; i < count; i++ {
+		row.Values[i] = value(i)
+		if i > 0 && row.Values[i] != row.Values[0] {
+			row.Differs = true
+		}
+	}
+
+	return row
+}
+
 func (s *Service) CreateProduct(ctx context.Context, req models.CreateProductRequest) (*models.Product, error) {
 	product, err := s.repo.CreateProduct(ctx, req)
 	if err != nil {
@@ -52,6 +178,48 @@ func (s *Service) UpdateProduct(ctx context.Context, id int, req models.UpdatePr
 	if err := s.repo.UpdateProduct(ctx, id, req); err != nil {
 		return fmt.Errorf("failed to update product: %w", err)
 	}
+
+	s.notifyUpdated(ctx, id)
+	return nil
+}
+
+// ProductsRestocked tells the update listeners about products whose stock
+// was put back by a cancelled order or a received return.
+func (s *Service) ProductsRestocked(ctx context.Context, productIDs []int) {
+	for _, id := range productIDs {
+		s.notifyUpdated(ctx, id)
+	}
+}
+
+func (s *Service) notifyUpdated(ctx context.Context, id int) {
+	if len(s.listeners) == 0 {
+		return
+	}
+
+	updated, err := s.repo.GetProductByID(ctx, id)
+	if err != nil {
+		return
+	}
+	for _, listener := range s.listeners {
+		listener.ProductUpdated(ctx, updated)
+	}
+}
+
+func (s *Service) SetAttributes(ctx context.Context, id int, req models.UpdateProductAttributesRequest) error {
+	_, err := s.repo.GetProductByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("product not found: %w", err)
+	}
+
+	for name := range req.Attributes {
+		if name == "" {
+			return fmt.Errorf("attribute name must not be empty")
+		}
+	}
+
+	if err := s.repo.SetProductAttributes(ctx, id, req.Attributes); err != nil {
+		return fmt.Errorf("failed to update product attributes: %w", err)
+	}
 	return nil
 }
 
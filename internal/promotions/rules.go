@@ -0,0 +1,200 @@
+package promotions
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+)
+
+// evaluateRules runs the active rules over the items, highest priority first.
+// Every rule that fires adds a discount line explaining what it did. The
+// stacking policy is decided by the non-stackable rules: one only fires when
+// nothing fired before it, and once it fires no further rules are evaluated
+// and no coupon is applied on top (see couponAllowed).
+func evaluateRules(rules []models.PromotionRule, items []models.CartItem) []models.DiscountLine {
+	var lines []models.DiscountLine
+
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.Stackable && len(lines) > 0 {
+			continue
+		}
+
+		line, fired := evaluateRule(rule, items)
+		if !fired {
+			continue
+		}
+
+		lines = append(lines, line)
+		if !rule.Stackable {
+			break
+		}
+	}
+
+	return lines
+}
+
+// couponAllowed reports whether a coupon may be applied on top of the lines
+// the rules fired: it may not when one of them came from a non-stackable
+// rule.
+func couponAllowed(rules []models.PromotionRule, lines []models.DiscountLine) bool {
+	exclusive := make(map[int]bool)
+	for _, rule := range rules {
+		if !rule.Stackable {
+			exclusive[rule.ID] = true
+		}
+	}
+
+	for _, line := range lines {
+		if exclusive[line.RuleID] {
+			return false
+		}
+	}
+	return true
+}
+
+func evaluateRule(rule *models.PromotionRule, items []models.CartItem) (models.DiscountLine, bool) {
+	var eligible []models.CartItem
+	var eligibleSubtotal float64
+	for _, item := range items {
+		if item.Product == nil || !matches(rule.ProductIDs, rule.CategoryIDs, item.Product) {
+			continue
+		}
+		eligible = append(eligible, item)
+		eligibleSubtotal += float64(item.Quantity) * item.UnitPrice
+	}
+
+	if len(eligible) == 0 || eligibleSubtotal < rule.MinSubtotal {
+		return models.DiscountLine{}, false
+	}
+
+	line := models.DiscountLine{
+		Source: "promotion",
+		RuleID: rule.ID,
+	}
+
+	switch rule.RuleType {
+	case "buy_x_get_y":
+		free := freeUnits(rule, eligible)
+		if len(free) == 0 {
+			return line, false
+		}
+		for _, price := range free {
+			line.Amount += price
+		}
+		line.Description = fmt.Sprintf("%s: buy %d get %d free, %d item(s) free", rule.Name, rule.BuyQuantity, rule.GetQuantity, len(free))
+
+	case "tiered_percentage":
+		tier, ok := reachedTier(rule.Tiers, eligibleSubtotal)
+		if !ok {
+			return line, false
+		}
+		line.Amount = eligibleSubtotal * tier.Percentage / 100
+		line.Description = fmt.Sprintf("%s: %.0f%% off for spending %.2f or more", rule.Name, tier.Percentage, tier.Threshold)
+
+	case "bundle":
+		bundles, bundleSubtotal := completeBundles(rule.ProductIDs, eligible)
+		if bundles == 0 {
+			return line, false
+		}
+		line.Amount = math.Min(rule.DiscountValue*float64(bundles), bundleSubtotal)
+		line.Description = fmt.Sprintf("%s: %.2f off each complete bundle, %d bundle(s)", rule.Name, rule.DiscountValue, bundles)
+
+	case "free_shipping":
+		line.FreeShipping = true
+		line.Description = fmt.Sprintf("%s: free shipping for spending %.2f or more", rule.Name, rule.MinSubtotal)
+
+	default:
+		return line, false
+	}
+
+	line.Amount = math.Round(line.Amount*100) / 100
+	return line, true
+}
+
+// freeUnits returns the prices of the units given away: for every
+// BuyQuantity+GetQuantity eligible units, the GetQuantity cheapest are free.
+func freeUnits(rule *models.PromotionRule, items []models.CartItem) []float64 {
+	groupSize := rule.BuyQuantity + rule.GetQuantity
+	if rule.BuyQuantity <= 0 || rule.GetQuantity <= 0 {
+		return nil
+	}
+
+	var prices []float64
+	for _, item := range items {
+		for n := 0; n < item.Quantity; n++ {
+			prices = append(prices, item.UnitPrice)
+		}
+	}
+
+	count := len(prices) / groupSize * rule.GetQuantity
+	if count == 0 {
+		return nil
+	}
+
+	sort.Float64s(prices)
+	return prices[:count]
+}
+
+// reachedTier returns the highest tier whose threshold the subtotal reaches.
+func reachedTier(tiers []models.PromotionTier, subtotal float64) (models.PromotionTier, bool) {
+	var best models.PromotionTier
+	found := false
+	for _, tier := range tiers {
+		if subtotal >= tier.Threshold && (!found || tier.Threshold > best.Threshold) {
+			best = tier
+			found = true
+		}
+	}
+	return best, found
+}
+
+// completeBundles counts how many full sets of the bundle's products the
+// items contain, and the price of those sets.
+func completeBundles(productIDs []int, items []models.CartItem) (int, float64) {
+	if len(productIDs) == 0 {
+		return 0, 0
+	}
+
+	quantities := make(map[int]int)
+	prices := make(map[int]float64)
+	for _, item := range items {
+		quantities[item.ProductID] += item.Quantity
+		prices[item.ProductID] = item.UnitPrice
+	}
+
+	bundles := -1
+	var bundlePrice float64
+	for _, id := range productIDs {
+		if bundles == -1 || quantities[id] < bundles {
+			bundles = quantities[id]
+		}
+		bundlePrice += prices[id]
+	}
+
+	return bundles, float64(bundles) * bundlePrice
+}
+
+// matches reports whether the product falls within a product and category
+// restriction. No restriction at all matches every product.
+func matches(productIDs, categoryIDs []int, product *models.Product) bool {
+	if len(productIDs) == 0 && len(categoryIDs) == 0 {
+		return true
+	}
+
+	for _, id := range productIDs {
+		if id == product.ID {
+			return true
+		}
+	}
+
+	for _, id := range categoryIDs {
+		if id == product.CategoryID {
+			return true
+		}
+	}
+
+	return false
+}
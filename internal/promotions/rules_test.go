@@ -0,0 +1,137 @@
+package promotions
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+)
+
+func cartItem(productID, categoryID, quantity int, unitPrice float64) models.CartItem {
+	return models.CartItem{
+		ProductID: productID,
+		Quantity:  quantity,
+		UnitPrice: unitPrice,
+		Product:   &models.Product{ID: productID, CategoryID: categoryID},
+	}
+}
+
+func TestEvaluateRules(t *testing.T) {
+	items := []models.CartItem{
+		cartItem(1, 10, 3, 10),
+		cartItem(2, 20, 1, 50),
+	}
+
+	buyTwoGetOne := models.PromotionRule{ID: 1, Name: "3 for 2", RuleType: "buy_x_get_y", Stackable: true, ProductIDs: []int{1}, BuyQuantity: 2, GetQuantity: 1}
+	tiered := models.PromotionRule{ID: 2, Name: "Spend more", RuleType: "tiered_percentage", Stackable: true, Tiers: []models.PromotionTier{{Threshold: 50, Percentage: 10}, {Threshold: 75, Percentage: 20}}}
+	freeShipping := models.PromotionRule{ID: 3, Name: "Free shipping", RuleType: "free_shipping", MinSubtotal: 50}
+	bundle := models.PromotionRule{ID: 4, Name: "Bundle", RuleType: "bundle", Stackable: true, ProductIDs: []int{1, 2}, DiscountValue: 5}
+	bigSpender := models.PromotionRule{ID: 5, Name: "Big spender", RuleType: "free_shipping", Stackable: true, MinSubtotal: 100}
+	category := models.PromotionRule{ID: 6, Name: "Category", RuleType: "tiered_percentage", Stackable: true, CategoryIDs: []int{20}, Tiers: []models.PromotionTier{{Threshold: 0, Percentage: 10}}}
+	unknown := models.PromotionRule{ID: 7, Name: "Unknown", RuleType: "mystery", Stackable: true}
+
+	tests := []struct {
+		name    string
+		rules   []models.PromotionRule
+		want    []int
+		amounts []float64
+	}{
+		{"stackable rules all fire", []models.PromotionRule{buyTwoGetOne, tiered}, []int{1, 2}, []float64{10, 16}},
+		{"non-stackable rule stops the rest", []models.PromotionRule{freeShipping, buyTwoGetOne}, []int{3}, []float64{0}},
+		{"non-stackable rule skipped after another fired", []models.PromotionRule{buyTwoGetOne, freeShipping, tiered}, []int{1, 2}, []float64{10, 16}},
+		{"bundle", []models.PromotionRule{bundle}, []int{4}, []float64{5}},
+		{"minimum subtotal not reached", []models.PromotionRule{bigSpender}, nil, nil},
+		{"category restriction", []models.PromotionRule{category}, []int{6}, []float64{5}},
+		{"unknown rule type", []models.PromotionRule{unknown}, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := evaluateRules(tt.rules, items)
+
+			var ids []int
+			var amounts []float64
+			for _, line := range lines {
+				ids = append(ids, line.RuleID)
+				amounts = append(amounts, line.Amount)
+			}
+			if !reflect.DeepEqual(ids, tt.want) || !reflect.DeepEqual(amounts, tt.amounts) {
+				t.Errorf("evaluateRules fired %v with %v, want %v with %v", ids, amounts, tt.want, tt.amounts)
+			}
+		})
+	}
+}
+
+func TestCouponAllowed(t *testing.T) {
+	stackable := models.PromotionRule{ID: 1, Stackable: true}
+	exclusive := models.PromotionRule{ID: 2}
+	rules := []models.PromotionRule{stackable, exclusive}
+
+	tests := []struct {
+		name  string
+		lines []models.DiscountLine
+		want  bool
+	}{
+		{"nothing fired", nil, true},
+		{"stackable rule fired", []models.DiscountLine{{RuleID: 1}}, true},
+		{"non-stackable rule fired", []models.DiscountLine{{RuleID: 2}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := couponAllowed(rules, tt.lines); got != tt.want {
+				t.Errorf("couponAllowed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFreeUnits(t *testing.T) {
+	tests := []struct {
+		name  string
+		buy   int
+		get   int
+		items []models.CartItem
+		want  []float64
+	}{
+		{"one group", 2, 1, []models.CartItem{cartItem(1, 0, 3, 10)}, []float64{10}},
+		{"cheapest are free", 2, 1, []models.CartItem{cartItem(1, 0, 3, 10), cartItem(2, 0, 3, 4)}, []float64{4, 4}},
+		{"incomplete group", 2, 1, []models.CartItem{cartItem(1, 0, 2, 10)}, nil},
+		{"partial second group", 1, 1, []models.CartItem{cartItem(1, 0, 3, 10)}, []float64{10}},
+		{"no buy quantity", 0, 1, []models.CartItem{cartItem(1, 0, 3, 10)}, nil},
+		{"no get quantity", 2, 0, []models.CartItem{cartItem(1, 0, 3, 10)}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := &models.PromotionRule{BuyQuantity: tt.buy, GetQuantity: tt.get}
+			if got := freeUnits(rule, tt.items); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("freeUnits = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompleteBundles(t *testing.T) {
+	tests := []struct {
+		name       string
+		productIDs []int
+		items      []models.CartItem
+		wantCount  int
+		wantPrice  float64
+	}{
+		{"one bundle", []int{1, 2}, []models.CartItem{cartItem(1, 0, 3, 10), cartItem(2, 0, 1, 50)}, 1, 60},
+		{"two bundles", []int{1, 2}, []models.CartItem{cartItem(1, 0, 2, 10), cartItem(2, 0, 2, 50)}, 2, 120},
+		{"missing product", []int{1, 2}, []models.CartItem{cartItem(1, 0, 3, 10)}, 0, 0},
+		{"no products", nil, []models.CartItem{cartItem(1, 0, 3, 10)}, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, price := completeBundles(tt.productIDs, tt.items)
+			if count != tt.wantCount || price != tt.wantPrice {
+				t.Errorf("completeBundles = %d, %v, want %d, %v", count, price, tt.wantCount, tt.wantPrice)
+			}
+		})
+	}
+}
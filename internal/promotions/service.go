@@ -0,0 +1,401 @@
+package promotions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+)
+
+type Repository interface {
+	CreateCoupon(ctx context.Context, req models.CreateCouponRequest) (*models.Coupon, error)
+	GetCoupons(ctx context.Context) ([]models.Coupon, error)
+	GetCouponByCode(ctx context.Context, code string) (*models.Coupon, error)
+	DeactivateCoupon(ctx context.Context, id int) error
+	CountCouponRedemptions(ctx context.Context, couponID, userID int) (int, int, error)
+	// GetCartCoupon returns models.ErrNoCartCoupon when the cart has no
+	// coupon.
+	GetCartCoupon(ctx context.Context, userID int) (*models.Coupon, error)
+	SetCartCoupon(ctx context.Context, userID, couponID int) error
+	RemoveCartCoupon(ctx context.Context, userID int) error
+	CreatePromotionRule(ctx context.Context, rule models.PromotionRule) (*models.PromotionRule, error)
+	GetPromotionRules(ctx context.Context) ([]models.PromotionRule, error)
+	GetActivePromotionRules(ctx context.Context) ([]models.PromotionRule, error)
+	DeactivatePromotionRule(ctx context.Context, id int) error
+	GetCartItems(ctx context.Context, userID int) ([]models.CartItem, error)
+}
+
+// Pricer sets each item's unit price to the best one the user gets for the
+// item's quantity.
+type Pricer interface {
+	PriceItems(ctx context.Context, userID int, items []models.CartItem) error
+}
+
+type Service struct {
+	repo   Repository
+	pricer Pricer
+}
+
+// NewService creates the promotion service. Carts are priced through pricer
+// before a coupon is checked against them.
+func NewService(repo Repository, pricer Pricer) *Service {
+	return &Service{repo: repo, pricer: pricer}
+}
+
+func (s *Service) CreateCoupon(ctx context.Context, req models.CreateCouponRequest) (*models.Coupon, error) {
+	req.Code = normalizeCode(req.Code)
+	if req.Code == "" {
+		return nil, fmt.Errorf("coupon code is required")
+	}
+
+	switch req.DiscountType {
+	case "percentage":
+		if req.DiscountValue <= 0 || req.DiscountValue > 100 {
+			return nil, fmt.Errorf("percentage discount must be between 0 and 100")
+		}
+	case "fixed":
+		if req.DiscountValue <= 0 {
+			return nil, fmt.Errorf("fixed discount must be greater than 0")
+		}
+	default:
+		return nil, fmt.Errorf("invalid discount type: %s", req.DiscountType)
+	}
+
+	if req.StartsAt != nil && req.EndsAt != nil && !req.EndsAt.After(*req.StartsAt) {
+		return nil, fmt.Errorf("coupon must end after it starts")
+	}
+
+	coupon, err := s.repo.CreateCoupon(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create coupon: %w", err)
+	}
+
+	return coupon, nil
+}
+
+func (s *Service) GetCoupons(ctx context.Context) ([]models.Coupon, error) {
+	coupons, err := s.repo.GetCoupons(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get coupons: %w", err)
+	}
+	return coupons, nil
+}
+
+func (s *Service) DeactivateCoupon(ctx context.Context, id int) error {
+	if err := s.repo.DeactivateCoupon(ctx, id); err != nil {
+		return fmt.Errorf("failed to deactivate coupon: %w", err)
+	}
+	return nil
+}
+
+// ApplyToCart validates a code against the user's current cart and attaches
+// it to the cart. A cart holds at most one code; applying another replaces it.
+func (s *Service) ApplyToCart(ctx context.Context, userID int, code string) (*models.DiscountLine, error) {
+	coupon, err := s.findCoupon(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.repo.GetCartItems(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cart items: %w", err)
+	}
+
+	if err := s.pricer.PriceItems(ctx, userID, items); err != nil {
+		return nil, fmt.Errorf("failed to price cart items: %w", err)
+	}
+
+	_, allowCoupon, err := s.ruleDiscounts(ctx, items)
+	if err != nil {
+		return nil, err
+	}
+	if !allowCoupon {
+		return nil, fmt.Errorf("coupon codes cannot be combined with the promotions applied")
+	}
+
+	line, err := s.evaluate(ctx, coupon, userID, items)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SetCartCoupon(ctx, userID, coupon.ID); err != nil {
+		return nil, fmt.Errorf("failed to apply coupon: %w", err)
+	}
+
+	return line, nil
+}
+
+func (s *Service) RemoveFromCart(ctx context.Context, userID int) error {
+	if err := s.repo.RemoveCartCoupon(ctx, userID); err != nil {
+		return fmt.Errorf("failed to remove coupon: %w", err)
+	}
+	return nil
+}
+
+// AdjustCart adds a line for every promotion rule that fires on the cart,
+// then one for the coupon applied to a user's cart. A coupon that no longer
+// applies, or that a non-stackable rule excludes, stays attached but adds no
+// discount. It implements cart.Adjuster.
+func (s *Service) AdjustCart(ctx context.Context, userID int, cart *models.CartResponse) error {
+	lines, allowCoupon, err := s.ruleDiscounts(ctx, cart.Items)
+	if err != nil {
+		return err
+	}
+
+	if userID != 0 && allowCoupon {
+		coupon, err := s.repo.GetCartCoupon(ctx, userID)
+		if err != nil && !errors.Is(err, models.ErrNoCartCoupon) {
+			return fmt.Errorf("failed to get cart coupon: %w", err)
+		}
+
+		if coupon != nil {
+			line, err := s.evaluate(ctx, coupon, userID, cart.Items)
+			var notApplicable *ineligibleError
+			switch {
+			case err == nil:
+				cart.CouponCode = coupon.Code
+				lines = append(lines, *line)
+			case !errors.As(err, &notApplicable):
+				return err
+			}
+		}
+	}
+
+	for _, line := range lines {
+		cart.Discounts = append(cart.Discounts, line)
+		cart.FreeShipping = cart.FreeShipping || line.FreeShipping
+	}
+	return nil
+}
+
+// Discounts runs the promotion rules over the items and prices the code, if
+// one is given. Unlike AdjustCart, a code that does not apply, or that a
+// non-stackable rule excludes, is an error. It implements
+// checkout.Discounter.
+func (s *Service) Discounts(ctx context.Context, userID int, code string, items []models.CartItem) ([]models.DiscountLine, error) {
+	lines, allowCoupon, err := s.ruleDiscounts(ctx, items)
+	if err != nil {
+		return nil, err
+	}
+
+	if code == "" {
+		return lines, nil
+	}
+
+	if !allowCoupon {
+		return nil, fmt.Errorf("coupon codes cannot be combined with the promotions applied")
+	}
+
+	coupon, err := s.findCoupon(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	line, err := s.evaluate(ctx, coupon, userID, items)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(lines, *line), nil
+}
+
+func (s *Service) CreatePromotionRule(ctx context.Context, req models.CreatePromotionRuleRequest) (*models.PromotionRule, error) {
+	if req.Name == "" {
+		return nil, fmt.Errorf("promotion name is required")
+	}
+
+	switch req.RuleType {
+	case "buy_x_get_y":
+		if req.BuyQuantity <= 0 || req.GetQuantity <= 0 {
+			return nil, fmt.Errorf("buy and get quantities must be greater than 0")
+		}
+	case "tiered_percentage":
+		if len(req.Tiers) == 0 {
+			return nil, fmt.Errorf("at least one tier is required")
+		}
+		for _, tier := range req.Tiers {
+			if tier.Threshold < 0 || tier.Percentage <= 0 || tier.Percentage > 100 {
+				return nil, fmt.Errorf("tier percentage must be between 0 and 100")
+			}
+		}
+	case "bundle":
+		if len(req.ProductIDs) < 2 {
+			return nil, fmt.Errorf("a bundle needs at least two products")
+		}
+		if req.DiscountValue <= 0 {
+			return nil, fmt.Errorf("bundle discount must be greater than 0")
+		}
+	case "free_shipping":
+	default:
+		return nil, fmt.Errorf("invalid rule type: %s", req.RuleType)
+	}
+
+	if req.StartsAt != nil && req.EndsAt != nil && !req.EndsAt.After(*req.StartsAt) {
+		return nil, fmt.Errorf("promotion must end after it starts")
+	}
+
+	stackable := true
+	if req.Stackable != nil {
+		stackable = *req.Stackable
+	}
+
+	rule, err := s.repo.CreatePromotionRule(ctx, models.PromotionRule{
+		Name:          req.Name,
+		Description:   req.Description,
+		RuleType:      req.RuleType,
+		Priority:      req.Priority,
+		Stackable:     stackable,
+		ProductIDs:    req.ProductIDs,
+		CategoryIDs:   req.CategoryIDs,
+		MinSubtotal:   req.MinSubtotal,
+		BuyQuantity:   req.BuyQuantity,
+		GetQuantity:   req.GetQuantity,
+		DiscountValue: req.DiscountValue,
+		Tiers:         req.Tiers,
+		StartsAt:      req.StartsAt,
+		EndsAt:        req.EndsAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create promotion: %w", err)
+	}
+
+	return rule, nil
+}
+
+func (s *Service) GetPromotionRules(ctx context.Context) ([]models.PromotionRule, error) {
+	rules, err := s.repo.GetPromotionRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get promotions: %w", err)
+	}
+	return rules, nil
+}
+
+func (s *Service) DeactivatePromotionRule(ctx context.Context, id int) error {
+	if err := s.repo.DeactivatePromotionRule(ctx, id); err != nil {
+		return fmt.Errorf("failed to deactivate promotion: %w", err)
+	}
+	return nil
+}
+
+// ruleDiscounts runs the active promotion rules over the items and reports
+// whether a coupon may be applied on top of what fired.
+func (s *Service) ruleDiscounts(ctx context.Context, items []models.CartItem) ([]models.DiscountLine, bool, error) {
+	if len(items) == 0 {
+		return nil, true, nil
+	}
+
+	rules, err := s.repo.GetActivePromotionRules(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get promotions: %w", err)
+	}
+
+	lines := evaluateRules(rules, items)
+	return lines, couponAllowed(rules, lines), nil
+}
+
+func (s *Service) findCoupon(ctx context.Context, code string) (*models.Coupon, error) {
+	code = normalizeCode(code)
+	if code == "" {
+		return nil, fmt.Errorf("coupon code is required")
+	}
+
+	coupon, err := s.repo.GetCouponByCode(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("invalid coupon code")
+	}
+
+	return coupon, nil
+}
+
+// evaluate checks every condition on the coupon and returns the discount it
+// gives on items. Restrictions limit which items the discount is computed
+// from; the minimum subtotal always applies to the whole cart. A condition
+// that is not met is reported as an *ineligibleError.
+func (s *Service) evaluate(ctx context.Context, coupon *models.Coupon, userID int, items []models.CartItem) (*models.DiscountLine, error) {
+	now := time.Now()
+	if !coupon.IsActive {
+		return nil, ineligible("coupon is no longer active")
+	}
+	if coupon.StartsAt != nil && now.Before(*coupon.StartsAt) {
+		return nil, ineligible("coupon is not valid yet")
+	}
+	if coupon.EndsAt != nil && now.After(*coupon.EndsAt) {
+		return nil, ineligible("coupon has expired")
+	}
+
+	if coupon.UsageLimit != nil || coupon.PerUserLimit != nil {
+		total, byUser, err := s.repo.CountCouponRedemptions(ctx, coupon.ID, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check coupon usage: %w", err)
+		}
+		if coupon.UsageLimit != nil && total >= *coupon.UsageLimit {
+			return nil, ineligible("coupon usage limit reached")
+		}
+		if coupon.PerUserLimit != nil && byUser >= *coupon.PerUserLimit {
+			return nil, ineligible("coupon already used the maximum number of times")
+		}
+	}
+
+	var subtotal, eligible float64
+	for _, item := range items {
+		if item.Product == nil {
+			continue
+		}
+		lineTotal := float64(item.Quantity) * item.UnitPrice
+		subtotal += lineTotal
+		if matches(coupon.ProductIDs, coupon.CategoryIDs, item.Product) {
+			eligible += lineTotal
+		}
+	}
+
+	if subtotal < coupon.MinSubtotal {
+		return nil, ineligible("coupon requires a minimum subtotal of %.2f", coupon.MinSubtotal)
+	}
+
+	if eligible == 0 {
+		return nil, ineligible("coupon does not apply to any items in the cart")
+	}
+
+	var amount float64
+	switch coupon.DiscountType {
+	case "percentage":
+		amount = eligible * coupon.DiscountValue / 100
+	default:
+		amount = math.Min(coupon.DiscountValue, eligible)
+	}
+
+	description := coupon.Description
+	if description == "" {
+		description = fmt.Sprintf("Coupon %s", coupon.Code)
+	}
+
+	return &models.DiscountLine{
+		Source:      "coupon",
+		Code:        coupon.Code,
+		Description: description,
+		Amount:      math.Round(amount*100) / 100,
+		CouponID:    coupon.ID,
+	}, nil
+}
+
+// ineligibleError explains why a coupon gives no discount on the items, as
+// opposed to a failure to find out.
+type ineligibleError struct {
+	reason string
+}
+
+func (e *ineligibleError) Error() string {
+	return e.reason
+}
+
+func ineligible(format string, args ...interface{}) error {
+	return &ineligibleError{reason: fmt.Sprintf(format, args...)}
+}
+
+func normalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
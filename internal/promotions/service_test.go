@@ -0,0 +1,64 @@
+package promotions
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+)
+
+// fakeRepo serves the cart coupon and the redemption counts; the rest of
+// Repository is left unimplemented.
+type fakeRepo struct {
+	Repository
+	coupon    *models.Coupon
+	couponErr error
+	countErr  error
+}
+
+func (r *fakeRepo) GetActivePromotionRules(ctx context.Context) ([]models.PromotionRule, error) {
+	return nil, nil
+}
+
+func (r *fakeRepo) GetCartCoupon(ctx context.Context, userID int) (*models.Coupon, error) {
+	return r.coupon, r.couponErr
+}
+
+func (r *fakeRepo) CountCouponRedemptions(ctx context.Context, couponID, userID int) (int, int, error) {
+	return 0, 0, r.countErr
+}
+
+func TestAdjustCartCoupon(t *testing.T) {
+	limit := 1
+	tenOff := &models.Coupon{ID: 1, Code: "TENOFF", DiscountType: "fixed", DiscountValue: 10, IsActive: true}
+	inactive := &models.Coupon{ID: 2, Code: "OLD", DiscountType: "fixed", DiscountValue: 10}
+	limited := &models.Coupon{ID: 3, Code: "ONCE", DiscountType: "fixed", DiscountValue: 10, IsActive: true, UsageLimit: &limit}
+	failure := errors.New("connection reset")
+
+	tests := []struct {
+		name     string
+		repo     *fakeRepo
+		wantCode string
+		wantErr  bool
+	}{
+		{"coupon applies", &fakeRepo{coupon: tenOff}, "TENOFF", false},
+		{"no coupon", &fakeRepo{couponErr: models.ErrNoCartCoupon}, "", false},
+		{"coupon no longer applies", &fakeRepo{coupon: inactive}, "", false},
+		{"coupon lookup fails", &fakeRepo{couponErr: failure}, "", true},
+		{"usage check fails", &fakeRepo{coupon: limited, countErr: failure}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cart := &models.CartResponse{Items: []models.CartItem{cartItem(1, 1, 2, 25)}}
+			err := NewService(tt.repo, nil).AdjustCart(context.Background(), 7, cart)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AdjustCart error = %v, want error %v", err, tt.wantErr)
+			}
+			if cart.CouponCode != tt.wantCode {
+				t.Errorf("AdjustCart coupon = %q, want %q", cart.CouponCode, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package questions
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/VishalHilal/e-commerce-api/internal/auth"
+	"github.com/VishalHilal/e-commerce-api/internal/json"
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+	"github.com/go-chi/chi/v5"
+)
+
+type handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *handler {
+	return &handler{service: service}
+}
+
+func (h *handler) GetProductQuestions(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(chi.URLParam(r, "product_id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	questions, err := h.service.GetProductQuestions(r.Context(), productID, questionFilter(r))
+	if err != nil {
+		json.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, map[string]interface{}{
+		"questions": questions,
+		"count":     len(questions),
+	})
+}
+
+func (h *handler) AskQuestion(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		json.WriteError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	productID, err := strconv.Atoi(chi.URLParam(r, "product_id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	var req models.CreateQuestionRequest
+	if err := json.Read(r, &req); err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	question, err := h.service.AskQuestion(r.Context(), productID, claims.UserID, req)
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusCreated, question)
+}
+
+func (h *handler) AnswerQuestion(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		json.WriteError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	questionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid question ID")
+		return
+	}
+
+	var req models.CreateAnswerRequest
+	if err := json.Read(r, &req); err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	answer, err := h.service.AnswerQuestion(r.Context(), questionID, claims.UserID, claims.Role, req)
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusCreated, answer)
+}
+
+func (h *handler) UpvoteAnswer(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		json.WriteError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	answerID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid answer ID")
+		return
+	}
+
+	if err := h.service.UpvoteAnswer(r.Context(), answerID, claims.UserID); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, models.ErrAnswerNotFound) {
+			status = http.StatusNotFound
+		}
+		json.WriteError(w, status, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, map[string]string{"message": "Answer upvoted successfully"})
+}
+
+func (h *handler) GetModerationQueue(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil || claims.Role != "admin" {
+		json.WriteError(w, http.StatusForbidden, "Admin access required")
+		return
+	}
+
+	filter := questionFilter(r)
+	filter.Status = r.URL.Query().Get("status")
+
+	questions, err := h.service.GetModerationQueue(r.Context(), filter)
+	if err != nil {
+		json.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, map[string]interface{}{
+		"questions": questions,
+		"count":     len(questions),
+	})
+}
+
+func (h *handler) ModerateQuestion(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil || claims.Role != "admin" {
+		json.WriteError(w, http.StatusForbidden, "Admin access required")
+		return
+	}
+
+	questionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid question ID")
+		return
+	}
+
+	var req models.ModerateQuestionRequest
+	if err := json.Read(r, &req); err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.service.ModerateQuestion(r.Context(), questionID, req); err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, map[string]string{"message": "Question status updated successfully"})
+}
+
+func questionFilter(r *http.Request) models.QuestionFilter {
+	filter := models.QuestionFilter{
+		Search: r.URL.Query().Get("search"),
+		Page:   1,
+		Limit:  20,
+	}
+
+	if page, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil && page > 0 {
+		filter.Page = page
+	}
+	if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && limit > 0 && limit <= 100 {
+		filter.Limit = limit
+	}
+
+	return filter
+}
@@ -0,0 +1,162 @@
+package questions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+)
+
+type Repository interface {
+	CreateQuestion(ctx context.Context, productID, userID int, body string) (*models.ProductQuestion, error)
+	GetQuestionByID(ctx context.Context, id int) (*models.ProductQuestion, error)
+	GetQuestions(ctx context.Context, filter models.QuestionFilter) ([]models.ProductQuestion, error)
+	UpdateQuestionStatus(ctx context.Context, id int, status string) error
+	CreateAnswer(ctx context.Context, answer models.ProductAnswer) (*models.ProductAnswer, error)
+	GetAnswersByQuestionIDs(ctx context.Context, questionIDs []int) ([]models.ProductAnswer, error)
+	UpvoteAnswer(ctx context.Context, answerID, userID int) (bool, error)
+	HasDeliveredOrderForProduct(ctx context.Context, userID, productID int) (bool, error)
+	GetProductByID(ctx context.Context, id int) (*models.Product, error)
+}
+
+type Service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// AskQuestion creates a question in the moderation queue. It only becomes
+// visible on the product once an admin approves it.
+func (s *Service) AskQuestion(ctx context.Context, productID, userID int, req models.CreateQuestionRequest) (*models.ProductQuestion, error) {
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		return nil, fmt.Errorf("question body is required")
+	}
+
+	if _, err := s.repo.GetProductByID(ctx, productID); err != nil {
+		return nil, fmt.Errorf("product not found: %w", err)
+	}
+
+	question, err := s.repo.CreateQuestion(ctx, productID, userID, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create question: %w", err)
+	}
+
+	return question, nil
+}
+
+func (s *Service) GetProductQuestions(ctx context.Context, productID int, filter models.QuestionFilter) ([]models.ProductQuestion, error) {
+	filter.ProductID = &productID
+	filter.Status = "approved"
+
+	return s.listWithAnswers(ctx, filter)
+}
+
+func (s *Service) GetModerationQueue(ctx context.Context, filter models.QuestionFilter) ([]models.ProductQuestion, error) {
+	if filter.Status == "" {
+		filter.Status = "pending"
+	}
+
+	return s.listWithAnswers(ctx, filter)
+}
+
+func (s *Service) ModerateQuestion(ctx context.Context, questionID int, req models.ModerateQuestionRequest) error {
+	if req.Status != "approved" && req.Status != "rejected" {
+		return fmt.Errorf("invalid question status: %s", req.Status)
+	}
+
+	if _, err := s.repo.GetQuestionByID(ctx, questionID); err != nil {
+		return fmt.Errorf("question not found")
+	}
+
+	if err := s.repo.UpdateQuestionStatus(ctx, questionID, req.Status); err != nil {
+		return fmt.Errorf("failed to update question status: %w", err)
+	}
+	return nil
+}
+
+// AnswerQuestion lets admins and verified buyers, i.e. users with a delivered
+// order containing the product, answer an approved question.
+func (s *Service) AnswerQuestion(ctx context.Context, questionID, userID int, role string, req models.CreateAnswerRequest) (*models.ProductAnswer, error) {
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		return nil, fmt.Errorf("answer body is required")
+	}
+
+	question, err := s.repo.GetQuestionByID(ctx, questionID)
+	if err != nil || question.Status != "approved" {
+		return nil, fmt.Errorf("question not found")
+	}
+
+	isAdmin := role == "admin"
+	isVerifiedBuyer, err := s.repo.HasDeliveredOrderForProduct(ctx, userID, question.ProductID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check purchase history: %w", err)
+	}
+
+	if !isAdmin && !isVerifiedBuyer {
+		return nil, fmt.Errorf("only admins and verified buyers can answer questions")
+	}
+
+	answer, err := s.repo.CreateAnswer(ctx, models.ProductAnswer{
+		QuestionID:      questionID,
+		UserID:          userID,
+		Body:            body,
+		IsAdmin:         isAdmin,
+		IsVerifiedBuyer: isVerifiedBuyer,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create answer: %w", err)
+	}
+
+	return answer, nil
+}
+
+func (s *Service) UpvoteAnswer(ctx context.Context, answerID, userID int) error {
+	upvoted, err := s.repo.UpvoteAnswer(ctx, answerID, userID)
+	if errors.Is(err, models.ErrAnswerNotFound) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to upvote answer: %w", err)
+	}
+
+	if !upvoted {
+		return fmt.Errorf("user has already upvoted this answer")
+	}
+	return nil
+}
+
+func (s *Service) listWithAnswers(ctx context.Context, filter models.QuestionFilter) ([]models.ProductQuestion, error) {
+	questions, err := s.repo.GetQuestions(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get questions: %w", err)
+	}
+
+	if len(questions) == 0 {
+		return questions, nil
+	}
+
+	ids := make([]int, len(questions))
+	byID := make(map[int]*models.ProductQuestion, len(questions))
+	for i := range questions {
+		ids[i] = questions[i].ID
+		byID[questions[i].ID] = &questions[i]
+	}
+
+	answers, err := s.repo.GetAnswersByQuestionIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get answers: %w", err)
+	}
+
+	for _, answer := range answers {
+		question := byID[answer.QuestionID]
+		question.Answers = append(question.Answers, answer)
+	}
+
+	return questions, nil
+}
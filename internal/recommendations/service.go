@@ -0,0 +1,173 @@
+package recommendations
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/VishalHilal/e-commerce-api/internal/cache"
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+)
+
+const (
+	defaultLimit = 10
+	maxLimit     = 50
+
+	// Cross-sell lists are served from cache for up to a day, but anything
+	// older than crossSellRefreshAfter is recomputed in the background.
+	crossSellCacheTTL     = 24 * time.Hour
+	crossSellRefreshAfter = 15 * time.Minute
+	crossSellLockTTL      = time.Minute
+)
+
+type cachedProducts struct {
+	Products    []models.RecommendedProduct `json:"products"`
+	RefreshedAt time.Time                   `json:"refreshed_at"`
+}
+
+type Repository interface {
+	GetRecentlyViewedProducts(ctx context.Context, userID, limit int) ([]models.ProductView, error)
+	GetRecommendedProducts(ctx context.Context, userID, limit int) ([]models.RecommendedProduct, error)
+	RefreshProductCoPurchases(ctx context.Context) error
+	GetRelatedProducts(ctx context.Context, productID, limit int) ([]models.RecommendedProduct, error)
+	GetBoughtTogetherProducts(ctx context.Context, productID, limit int) ([]models.RecommendedProduct, error)
+}
+
+type Service struct {
+	repo  Repository
+	cache cache.Cache
+}
+
+func NewService(repo Repository, cache cache.Cache) *Service {
+	return &Service{
+		repo:  repo,
+		cache: cache,
+	}
+}
+
+func (s *Service) GetRecentlyViewed(ctx context.Context, userID, limit int) ([]models.ProductView, error) {
+	views, err := s.repo.GetRecentlyViewedProducts(ctx, userID, clampLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recently viewed products: %w", err)
+	}
+	return views, nil
+}
+
+func (s *Service) GetRecommendations(ctx context.Context, userID, limit int) ([]models.RecommendedProduct, error) {
+	products, err := s.repo.GetRecommendedProducts(ctx, userID, clampLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recommendations: %w", err)
+	}
+	return products, nil
+}
+
+func (s *Service) GetRelated(ctx context.Context, productID, limit int) ([]models.RecommendedProduct, error) {
+	key := fmt.Sprintf("products:%d:related", productID)
+	return s.getCrossSell(ctx, key, limit, func(ctx context.Context) ([]models.RecommendedProduct, error) {
+		return s.repo.GetRelatedProducts(ctx, productID, maxLimit)
+	})
+}
+
+func (s *Service) GetBoughtTogether(ctx context.Context, productID, limit int) ([]models.RecommendedProduct, error) {
+	key := fmt.Sprintf("products:%d:bought-together", productID)
+	return s.getCrossSell(ctx, key, limit, func(ctx context.Context) ([]models.RecommendedProduct, error) {
+		return s.repo.GetBoughtTogetherProducts(ctx, productID, maxLimit)
+	})
+}
+
+// getCrossSell serves a cached product list, computing it synchronously only
+// on a cache miss. Stale entries are returned as-is while a single background
+// refresh, guarded by a cache lock, recomputes them.
+func (s *Service) getCrossSell(ctx context.Context, key string, limit int, load func(ctx context.Context) ([]models.RecommendedProduct, error)) ([]models.RecommendedProduct, error) {
+	limit = clampLimit(limit)
+
+	var cached cachedProducts
+	if err := s.cache.Get(ctx, key, &cached); err == nil {
+		if time.Since(cached.RefreshedAt) > crossSellRefreshAfter {
+			s.refreshInBackground(key, load)
+		}
+		return truncate(cached.Products, limit), nil
+	}
+
+	products, err := load(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load products: %w", err)
+	}
+
+	s.store(ctx, key, products)
+	return truncate(products, limit), nil
+}
+
+func (s *Service) refreshInBackground(key string, load func(ctx context.Context) ([]models.RecommendedProduct, error)) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		locked, err := s.cache.SetNX(ctx, key+":refreshing", true, crossSellLockTTL)
+		if err != nil || !locked {
+			return
+		}
+		defer s.cache.Delete(ctx, key+":refreshing")
+
+		products, err := load(ctx)
+		if err != nil {
+			slog.Error("failed to refresh cross-sell cache", "key", key, "error", err)
+			return
+		}
+
+		s.store(ctx, key, products)
+	}()
+}
+
+func (s *Service) store(ctx context.Context, key string, products []models.RecommendedProduct) {
+	entry := cachedProducts{Products: products, RefreshedAt: time.Now()}
+	if err := s.cache.Set(ctx, key, entry, crossSellCacheTTL); err != nil {
+		slog.Error("failed to cache cross-sell products", "key", key, "error", err)
+	}
+}
+
+func (s *Service) RefreshCoPurchases(ctx context.Context) error {
+	if err := s.repo.RefreshProductCoPurchases(ctx); err != nil {
+		return fmt.Errorf("failed to refresh co-purchases: %w", err)
+	}
+	return nil
+}
+
+// StartCoPurchaseJob rebuilds the co-purchase table right away and then on
+// every interval until ctx is cancelled.
+func (s *Service) StartCoPurchaseJob(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := s.RefreshCoPurchases(ctx); err != nil {
+				slog.Error("co-purchase job failed", "error", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func truncate(products []models.RecommendedProduct, limit int) []models.RecommendedProduct {
+	if len(products) > limit {
+		return products[:limit]
+	}
+	return products
+}
+
+func clampLimit(limit int) int {
+	if limit <= 0 {
+		return defaultLimit
+	}
+	if limit > maxLimit {
+		return maxLimit
+	}
+	return limit
+}
@@ -0,0 +1,63 @@
+package recovery
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/VishalHilal/e-commerce-api/internal/auth"
+	"github.com/VishalHilal/e-commerce-api/internal/json"
+)
+
+const dateLayout = "2006-01-02"
+
+type handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *handler {
+	return &handler{service: service}
+}
+
+// GetReport reports on the carts abandoned from the `from` date through the
+// `to` date, both inclusive. The last 30 days are reported by default.
+func (h *handler) GetReport(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil || claims.Role != "admin" {
+		json.WriteError(w, http.StatusForbidden, "Admin access required")
+		return
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	to, ok := parseDate(r, "to", today)
+	if !ok {
+		json.WriteError(w, http.StatusBadRequest, "Invalid to date")
+		return
+	}
+
+	from, ok := parseDate(r, "from", to.AddDate(0, 0, -29))
+	if !ok {
+		json.WriteError(w, http.StatusBadRequest, "Invalid from date")
+		return
+	}
+
+	report, err := h.service.GetReport(r.Context(), from, to.AddDate(0, 0, 1))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, report)
+}
+
+func parseDate(r *http.Request, key string, defaultValue time.Time) (time.Time, bool) {
+	val := r.URL.Query().Get(key)
+	if val == "" {
+		return defaultValue, true
+	}
+
+	date, err := time.Parse(dateLayout, val)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return date, true
+}
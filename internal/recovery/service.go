@@ -0,0 +1,253 @@
+package recovery
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/VishalHilal/e-commerce-api/internal/email"
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+	"github.com/google/uuid"
+)
+
+// Carts left for longer than reminderMaxAge are not flagged or reminded; a
+// reminder that late is more likely to annoy than to recover the sale.
+const reminderMaxAge = 7 * 24 * time.Hour
+
+// Reasons an abandonment was skipped without a reminder.
+const (
+	skipCartChanged  = "cart_changed"
+	skipFrequencyCap = "frequency_cap"
+)
+
+type Repository interface {
+	FlagAbandonedCarts(ctx context.Context, inactiveSince, activeAfter time.Time) (int, error)
+	GetPendingAbandonedCarts(ctx context.Context, activeAfter time.Time) ([]models.AbandonedCart, error)
+	CountCartReminders(ctx context.Context, userID int, since time.Time) (int, error)
+	ClaimAbandonedCart(ctx context.Context, id int) (bool, error)
+	ReleaseAbandonedCart(ctx context.Context, id int) error
+	SkipAbandonedCart(ctx context.Context, id int, reason string) error
+	SetAbandonedCartCoupon(ctx context.Context, id, couponID int) error
+	GetAbandonedCartReport(ctx context.Context, from, to time.Time) (*models.AbandonedCartReport, error)
+	GetCartItems(ctx context.Context, userID int) ([]models.CartItem, error)
+	GetUserByID(ctx context.Context, id int) (*models.User, error)
+}
+
+// CouponIssuer creates and withdraws the one-time coupons sent with
+// reminders.
+type CouponIssuer interface {
+	CreateCoupon(ctx context.Context, req models.CreateCouponRequest) (*models.Coupon, error)
+	DeactivateCoupon(ctx context.Context, id int) error
+}
+
+// Config controls when a cart counts as abandoned and how often its owner
+// is reminded.
+type Config struct {
+	// AbandonAfter is how long a cart must go without an update.
+	AbandonAfter time.Duration
+	// JobInterval is how often the background job looks for abandoned carts.
+	JobInterval time.Duration
+	// CouponPercent is the discount of the one-time coupon sent with each
+	// reminder; 0 sends reminders without a coupon.
+	CouponPercent float64
+	// CouponValidFor is how long the coupon can be redeemed.
+	CouponValidFor time.Duration
+	// MaxReminders caps the reminders sent to one user in ReminderWindow.
+	MaxReminders   int
+	ReminderWindow time.Duration
+}
+
+type Service struct {
+	repo     Repository
+	emailSvc *email.EmailService
+	coupons  CouponIssuer
+	config   Config
+}
+
+// NewService creates the abandoned cart service. coupons may be nil, in
+// which case reminders go out without a coupon.
+func NewService(repo Repository, emailSvc *email.EmailService, coupons CouponIssuer, config Config) *Service {
+	return &Service{
+		repo:     repo,
+		emailSvc: emailSvc,
+		coupons:  coupons,
+		config:   config,
+	}
+}
+
+// ProcessAbandonedCarts flags the carts that have gone quiet and sends a
+// reminder for each pending abandonment. Each one is claimed before its
+// email goes out, so it is reminded at most once even if runs overlap.
+func (s *Service) ProcessAbandonedCarts(ctx context.Context) error {
+	now := time.Now()
+	activeAfter := now.Add(-reminderMaxAge)
+
+	if _, err := s.repo.FlagAbandonedCarts(ctx, now.Add(-s.config.AbandonAfter), activeAfter); err != nil {
+		return fmt.Errorf("failed to flag abandoned carts: %w", err)
+	}
+
+	carts, err := s.repo.GetPendingAbandonedCarts(ctx, activeAfter)
+	if err != nil {
+		return fmt.Errorf("failed to get abandoned carts: %w", err)
+	}
+
+	for i := range carts {
+		if err := s.remind(ctx, &carts[i]); err != nil {
+			slog.Error("failed to send abandoned cart reminder", "abandoned_cart_id", carts[i].ID, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// StartAbandonedCartJob processes abandoned carts right away and then on
+// every interval until ctx is cancelled.
+func (s *Service) StartAbandonedCartJob(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := s.ProcessAbandonedCarts(ctx); err != nil {
+				slog.Error("abandoned cart job failed", "error", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// GetReport sums up the carts abandoned in [from, to) and works out the
+// share of them that were recovered.
+func (s *Service) GetReport(ctx context.Context, from, to time.Time) (*models.AbandonedCartReport, error) {
+	if !to.After(from) {
+		return nil, fmt.Errorf("to must be after from")
+	}
+
+	report, err := s.repo.GetAbandonedCartReport(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get abandoned cart report: %w", err)
+	}
+
+	report.RecoveryRate = percentage(report.Recovered, report.Abandoned)
+	report.ReminderRecoveryRate = percentage(report.RecoveredAfterReminder, report.Reminded)
+	return report, nil
+}
+
+func (s *Service) remind(ctx context.Context, cart *models.AbandonedCart) error {
+	items, err := s.repo.GetCartItems(ctx, cart.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get cart: %w", err)
+	}
+
+	if !lastActivity(items).Equal(cart.LastActivityAt) {
+		return s.repo.SkipAbandonedCart(ctx, cart.ID, skipCartChanged)
+	}
+
+	if s.config.MaxReminders > 0 {
+		sent, err := s.repo.CountCartReminders(ctx, cart.UserID, time.Now().Add(-s.config.ReminderWindow))
+		if err != nil {
+			return fmt.Errorf("failed to count reminders: %w", err)
+		}
+		if sent >= s.config.MaxReminders {
+			return s.repo.SkipAbandonedCart(ctx, cart.ID, skipFrequencyCap)
+		}
+	}
+
+	claimed, err := s.repo.ClaimAbandonedCart(ctx, cart.ID)
+	if err != nil || !claimed {
+		return err
+	}
+
+	user, err := s.repo.GetUserByID(ctx, cart.UserID)
+	if err != nil {
+		return s.release(ctx, cart, nil, fmt.Errorf("failed to get user: %w", err))
+	}
+
+	coupon, err := s.issueCoupon(ctx, cart)
+	if err != nil {
+		return s.release(ctx, cart, nil, err)
+	}
+
+	if err := s.emailSvc.SendAbandonedCartEmail(user, items, coupon); err != nil {
+		return s.release(ctx, cart, coupon, err)
+	}
+
+	return nil
+}
+
+// issueCoupon creates the one-time coupon for a reminder, if reminders
+// carry one.
+func (s *Service) issueCoupon(ctx context.Context, cart *models.AbandonedCart) (*models.Coupon, error) {
+	if s.coupons == nil || s.config.CouponPercent <= 0 {
+		return nil, nil
+	}
+
+	once := 1
+	endsAt := time.Now().Add(s.config.CouponValidFor)
+	coupon, err := s.coupons.CreateCoupon(ctx, models.CreateCouponRequest{
+		Code:          "COMEBACK-" + strings.ToUpper(uuid.New().String()[:8]),
+		Description:   fmt.Sprintf("Abandoned cart reminder for user %d", cart.UserID),
+		DiscountType:  "percentage",
+		DiscountValue: s.config.CouponPercent,
+		EndsAt:        &endsAt,
+		UsageLimit:    &once,
+		PerUserLimit:  &once,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reminder coupon: %w", err)
+	}
+
+	if err := s.repo.SetAbandonedCartCoupon(ctx, cart.ID, coupon.ID); err != nil {
+		s.withdrawCoupon(ctx, coupon)
+		return nil, fmt.Errorf("failed to record reminder coupon: %w", err)
+	}
+
+	return coupon, nil
+}
+
+// release puts the abandonment back to pending so the next run retries it,
+// withdrawing the coupon that was never sent.
+func (s *Service) release(ctx context.Context, cart *models.AbandonedCart, coupon *models.Coupon, cause error) error {
+	if coupon != nil {
+		s.withdrawCoupon(ctx, coupon)
+	}
+
+	if err := s.repo.ReleaseAbandonedCart(ctx, cart.ID); err != nil {
+		slog.Error("failed to release abandoned cart", "abandoned_cart_id", cart.ID, "error", err)
+	}
+
+	return cause
+}
+
+func (s *Service) withdrawCoupon(ctx context.Context, coupon *models.Coupon) {
+	if err := s.coupons.DeactivateCoupon(ctx, coupon.ID); err != nil {
+		slog.Error("failed to deactivate reminder coupon", "coupon_id", coupon.ID, "error", err)
+	}
+}
+
+// lastActivity is the latest update to any item in the cart, or the zero
+// time for an empty cart.
+func lastActivity(items []models.CartItem) time.Time {
+	var latest time.Time
+	for _, item := range items {
+		if item.UpdatedAt.After(latest) {
+			latest = item.UpdatedAt
+		}
+	}
+	return latest
+}
+
+func percentage(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return math.Round(float64(part)/float64(whole)*10000) / 100
+}
@@ -0,0 +1,221 @@
+package returns
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/VishalHilal/e-commerce-api/internal/auth"
+	"github.com/VishalHilal/e-commerce-api/internal/json"
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+	"github.com/go-chi/chi/v5"
+)
+
+type handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *handler {
+	return &handler{service: service}
+}
+
+func (h *handler) RequestReturn(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		json.WriteError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	orderID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	var req models.CreateReturnRequest
+	if err := json.Read(r, &req); err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	ret, err := h.service.RequestReturn(r.Context(), orderID, claims.UserID, req)
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusCreated, ret)
+}
+
+func (h *handler) GetUserReturns(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		json.WriteError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	returns, err := h.service.GetUserReturns(r.Context(), claims.UserID)
+	if err != nil {
+		json.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, map[string]interface{}{
+		"returns": returns,
+		"count":   len(returns),
+	})
+}
+
+func (h *handler) GetUserReturn(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		json.WriteError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	returnID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid return ID")
+		return
+	}
+
+	ret, err := h.service.GetUserReturn(r.Context(), returnID, claims.UserID)
+	if err != nil {
+		json.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, ret)
+}
+
+func (h *handler) GetReturns(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil || claims.Role != "admin" {
+		json.WriteError(w, http.StatusForbidden, "Admin access required")
+		return
+	}
+
+	returns, err := h.service.GetReturns(r.Context(), r.URL.Query().Get("status"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, map[string]interface{}{
+		"returns": returns,
+		"count":   len(returns),
+	})
+}
+
+func (h *handler) GetReturn(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil || claims.Role != "admin" {
+		json.WriteError(w, http.StatusForbidden, "Admin access required")
+		return
+	}
+
+	returnID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid return ID")
+		return
+	}
+
+	ret, err := h.service.GetReturn(r.Context(), returnID)
+	if err != nil {
+		json.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, ret)
+}
+
+func (h *handler) ApproveReturn(w http.ResponseWriter, r *http.Request) {
+	claims, returnID, ok := adminReturn(w, r)
+	if !ok {
+		return
+	}
+
+	ret, err := h.service.ApproveReturn(r.Context(), returnID, actor(claims))
+	writeReturn(w, ret, err)
+}
+
+func (h *handler) RejectReturn(w http.ResponseWriter, r *http.Request) {
+	claims, returnID, ok := adminReturn(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.RejectReturnRequest
+	if r.ContentLength != 0 {
+		if err := json.Read(r, &req); err != nil {
+			json.WriteError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	ret, err := h.service.RejectReturn(r.Context(), returnID, req.Note, actor(claims))
+	writeReturn(w, ret, err)
+}
+
+func (h *handler) ReceiveReturn(w http.ResponseWriter, r *http.Request) {
+	claims, returnID, ok := adminReturn(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.ReceiveReturnRequest
+	if err := json.Read(r, &req); err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	ret, err := h.service.ReceiveReturn(r.Context(), returnID, req, actor(claims))
+	writeReturn(w, ret, err)
+}
+
+func (h *handler) ResolveReturn(w http.ResponseWriter, r *http.Request) {
+	claims, returnID, ok := adminReturn(w, r)
+	if !ok {
+		return
+	}
+
+	ret, err := h.service.ResolveReturn(r.Context(), returnID, actor(claims))
+	writeReturn(w, ret, err)
+}
+
+// adminReturn checks that the request comes from an admin and parses the
+// return ID, writing the error response if either fails.
+func adminReturn(w http.ResponseWriter, r *http.Request) (*auth.JWTClaims, int, bool) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil || claims.Role != "admin" {
+		json.WriteError(w, http.StatusForbidden, "Admin access required")
+		return nil, 0, false
+	}
+
+	returnID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid return ID")
+		return nil, 0, false
+	}
+
+	return claims, returnID, true
+}
+
+func writeReturn(w http.ResponseWriter, ret *models.Return, err error) {
+	if err != nil {
+		var transitionErr *models.ReturnTransitionError
+		if errors.As(err, &transitionErr) {
+			json.Write(w, http.StatusConflict, transitionErr)
+			return
+		}
+		json.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, ret)
+}
+
+func actor(claims *auth.JWTClaims) models.OrderActor {
+	userID := claims.UserID
+	return models.OrderActor{ID: &userID, Role: claims.Role}
+}
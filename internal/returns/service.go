@@ -0,0 +1,384 @@
+package returns
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+	"github.com/google/uuid"
+)
+
+// Return statuses. A return is requested by the customer, approved or
+// rejected by an admin, received when the goods arrive, and closed by a
+// refund or an exchange order, as the customer asked. A return is refunding
+// while its refund is paid out.
+const (
+	statusRequested = "requested"
+	statusApproved  = "approved"
+	statusRejected  = "rejected"
+	statusReceived  = "received"
+	statusRefunding = "refunding"
+	statusRefunded  = "refunded"
+	statusExchanged = "exchanged"
+)
+
+// returnTransitions lists the statuses a return can move to from each status.
+var returnTransitions = map[string][]string{
+	statusRequested: {statusApproved, statusRejected},
+	statusApproved:  {statusReceived},
+	statusReceived:  {statusRefunding, statusExchanged},
+	statusRefunding: {statusRefunded, statusReceived},
+	statusRejected:  {},
+	statusRefunded:  {},
+	statusExchanged: {},
+}
+
+// returnReasons are the reasons an item can be returned for.
+var returnReasons = []string{
+	"damaged",
+	"defective",
+	"wrong_item",
+	"not_as_described",
+	"no_longer_needed",
+	"other",
+}
+
+type Repository interface {
+	CreateReturn(ctx context.Context, orderID, userID int, rmaNumber string, req models.CreateReturnRequest) (*models.Return, error)
+	GetReturnByID(ctx context.Context, id int) (*models.Return, error)
+	GetReturnsByUserID(ctx context.Context, userID int) ([]models.Return, error)
+	GetReturns(ctx context.Context, status string) ([]models.Return, error)
+	ApproveReturn(ctx context.Context, id int, labelReference string, actor models.OrderActor) (bool, error)
+	RejectReturn(ctx context.Context, id int, note string, actor models.OrderActor) (bool, error)
+	ReceiveReturn(ctx context.Context, id int, inspections []models.ReturnInspection, actor models.OrderActor) (bool, error)
+	ClaimReturnRefund(ctx context.Context, id int, actor models.OrderActor) (bool, error)
+	ReleaseReturnRefund(ctx context.Context, id int, reason string, actor models.OrderActor) (bool, error)
+	RefundReturn(ctx context.Context, id, refundID int, actor models.OrderActor) (bool, error)
+	ExchangeReturn(ctx context.Context, id, exchangeOrderID int, actor models.OrderActor) (bool, error)
+	GetOrderByID(ctx context.Context, id int) (*models.Order, error)
+}
+
+// Refunder pays back the price of some of an order's items.
+type Refunder interface {
+	Refund(ctx context.Context, orderID int, req models.CreateRefundRequest, actor models.OrderActor) (*models.Refund, error)
+}
+
+// Exchanger places the replacement order of an exchange and moves it along
+// the order status graph.
+type Exchanger interface {
+	PlaceReplacementOrder(ctx context.Context, orderID int, items []models.OrderItemRequest, actor models.OrderActor) (*models.Order, error)
+	UpdateOrderStatus(ctx context.Context, orderID int, req models.UpdateOrderStatusRequest, actor models.OrderActor) error
+}
+
+// RestockListener is told which products a received return put back in
+// stock.
+type RestockListener interface {
+	ProductsRestocked(ctx context.Context, productIDs []int)
+}
+
+type Service struct {
+	repo      Repository
+	refunds   Refunder
+	exchanges Exchanger
+	restocks  RestockListener
+}
+
+// NewService creates the return service. restocks may be nil, in which case
+// nobody is told about the stock a received return puts back.
+func NewService(repo Repository, refunds Refunder, exchanges Exchanger, restocks RestockListener) *Service {
+	return &Service{
+		repo:      repo,
+		refunds:   refunds,
+		exchanges: exchanges,
+		restocks:  restocks,
+	}
+}
+
+// RequestReturn opens a return for items of one of the user's delivered
+// orders. Items can be returned in part, and over several returns, up to the
+// quantity ordered.
+func (s *Service) RequestReturn(ctx context.Context, orderID, userID int, req models.CreateReturnRequest) (*models.Return, error) {
+	if req.Resolution != "refund" && req.Resolution != "exchange" {
+		return nil, fmt.Errorf("resolution must be refund or exchange")
+	}
+
+	if len(req.Items) == 0 {
+		return nil, fmt.Errorf("at least one item is required")
+	}
+
+	seen := make(map[int]bool)
+	for _, item := range req.Items {
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("quantity must be greater than 0")
+		}
+		if !contains(returnReasons, item.Reason) {
+			return nil, fmt.Errorf("invalid return reason: %s", item.Reason)
+		}
+		if seen[item.OrderItemID] {
+			return nil, fmt.Errorf("order item %d is listed more than once", item.OrderItemID)
+		}
+		seen[item.OrderItemID] = true
+	}
+
+	order, err := s.repo.GetOrderByID(ctx, orderID)
+	if err != nil || order.UserID != userID {
+		return nil, fmt.Errorf("order not found")
+	}
+
+	if order.Status != "delivered" {
+		return nil, fmt.Errorf("only delivered orders can be returned")
+	}
+
+	rmaNumber := "RMA-" + strings.ToUpper(uuid.New().String()[:8])
+	ret, err := s.repo.CreateReturn(ctx, orderID, userID, rmaNumber, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create return: %w", err)
+	}
+
+	return ret, nil
+}
+
+func (s *Service) GetUserReturns(ctx context.Context, userID int) ([]models.Return, error) {
+	returns, err := s.repo.GetReturnsByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get returns: %w", err)
+	}
+	return returns, nil
+}
+
+func (s *Service) GetUserReturn(ctx context.Context, returnID, userID int) (*models.Return, error) {
+	ret, err := s.repo.GetReturnByID(ctx, returnID)
+	if err != nil || ret.UserID != userID {
+		return nil, fmt.Errorf("return not found")
+	}
+	return ret, nil
+}
+
+func (s *Service) GetReturns(ctx context.Context, status string) ([]models.Return, error) {
+	if _, ok := returnTransitions[status]; status != "" && !ok {
+		return nil, fmt.Errorf("invalid return status: %s", status)
+	}
+
+	returns, err := s.repo.GetReturns(ctx, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get returns: %w", err)
+	}
+	return returns, nil
+}
+
+func (s *Service) GetReturn(ctx context.Context, returnID int) (*models.Return, error) {
+	ret, err := s.repo.GetReturnByID(ctx, returnID)
+	if err != nil {
+		return nil, fmt.Errorf("return not found")
+	}
+	return ret, nil
+}
+
+// ApproveReturn approves a requested return and issues the reference of the
+// label the customer ships the goods back with.
+func (s *Service) ApproveReturn(ctx context.Context, returnID int, actor models.OrderActor) (*models.Return, error) {
+	ret, err := s.GetReturn(ctx, returnID)
+	if err != nil {
+		return nil, err
+	}
+
+	labelReference := "RL-" + strings.ToUpper(strings.ReplaceAll(uuid.New().String(), "-", "")[:12])
+	return s.transition(ctx, ret, statusApproved, func() (bool, error) {
+		return s.repo.ApproveReturn(ctx, ret.ID, labelReference, actor)
+	})
+}
+
+func (s *Service) RejectReturn(ctx context.Context, returnID int, note string, actor models.OrderActor) (*models.Return, error) {
+	ret, err := s.GetReturn(ctx, returnID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.transition(ctx, ret, statusRejected, func() (bool, error) {
+		return s.repo.RejectReturn(ctx, ret.ID, strings.TrimSpace(note), actor)
+	})
+}
+
+// ReceiveReturn records the arrival of an approved return. Every item must
+// be inspected: resellable items are restocked and damaged ones written off.
+func (s *Service) ReceiveReturn(ctx context.Context, returnID int, req models.ReceiveReturnRequest, actor models.OrderActor) (*models.Return, error) {
+	ret, err := s.GetReturn(ctx, returnID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkInspections(ret, req.Items); err != nil {
+		return nil, err
+	}
+
+	received, err := s.transition(ctx, ret, statusReceived, func() (bool, error) {
+		return s.repo.ReceiveReturn(ctx, ret.ID, req.Items, actor)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.restocks != nil {
+		if productIDs := restockedProducts(received); len(productIDs) > 0 {
+			s.restocks.ProductsRestocked(ctx, productIDs)
+		}
+	}
+
+	return received, nil
+}
+
+// restockedProducts lists the products of a received return's resellable
+// items, which went back in stock.
+func restockedProducts(ret *models.Return) []int {
+	seen := make(map[int]bool)
+	var productIDs []int
+	for _, item := range ret.Items {
+		if item.Restocked && !seen[item.ProductID] {
+			seen[item.ProductID] = true
+			productIDs = append(productIDs, item.ProductID)
+		}
+	}
+	return productIDs
+}
+
+// ResolveReturn closes a received return the way the customer asked: by
+// refunding the returned items or by placing an exchange order for them. A
+// refund return is claimed as refunding before the refund is paid out, so
+// it is never refunded twice, and goes back to received if the refund fails.
+func (s *Service) ResolveReturn(ctx context.Context, returnID int, actor models.OrderActor) (*models.Return, error) {
+	ret, err := s.GetReturn(ctx, returnID)
+	if err != nil {
+		return nil, err
+	}
+
+	if ret.Resolution == "exchange" {
+		return s.transition(ctx, ret, statusExchanged, func() (bool, error) {
+			return s.exchange(ctx, ret, actor)
+		})
+	}
+
+	ret, err = s.transition(ctx, ret, statusRefunding, func() (bool, error) {
+		return s.repo.ClaimReturnRefund(ctx, ret.ID, actor)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req := models.CreateRefundRequest{Reason: "return " + ret.RMANumber}
+	for _, item := range ret.Items {
+		req.Items = append(req.Items, models.RefundItemRequest{OrderItemID: item.OrderItemID, Quantity: item.Quantity})
+	}
+
+	refund, err := s.refunds.Refund(ctx, ret.OrderID, req, actor)
+	if err != nil {
+		if _, releaseErr := s.repo.ReleaseReturnRefund(ctx, ret.ID, err.Error(), actor); releaseErr != nil {
+			slog.Error("failed to release refunding return", "return_id", ret.ID, "error", releaseErr)
+		}
+		return nil, fmt.Errorf("failed to refund return: %w", err)
+	}
+
+	return s.transition(ctx, ret, statusRefunded, func() (bool, error) {
+		return s.repo.RefundReturn(ctx, ret.ID, refund.ID, actor)
+	})
+}
+
+// exchange places a free replacement order for the returned items through
+// the order service and closes the return with it. The replacement is
+// confirmed only once the return is exchanged, and cancelled if the return
+// moved on in the meantime.
+func (s *Service) exchange(ctx context.Context, ret *models.Return, actor models.OrderActor) (bool, error) {
+	var items []models.OrderItemRequest
+	for _, item := range ret.Items {
+		items = append(items, models.OrderItemRequest{ProductID: item.ProductID, Quantity: item.Quantity})
+	}
+
+	order, err := s.exchanges.PlaceReplacementOrder(ctx, ret.OrderID, items, actor)
+	if err != nil {
+		return false, err
+	}
+
+	exchanged, err := s.repo.ExchangeReturn(ctx, ret.ID, order.ID, actor)
+	if err != nil || !exchanged {
+		cancel := models.UpdateOrderStatusRequest{Status: "cancelled", CancellationReason: "other"}
+		if cancelErr := s.exchanges.UpdateOrderStatus(ctx, order.ID, cancel, actor); cancelErr != nil {
+			slog.Error("failed to cancel replacement order", "order_id", order.ID, "error", cancelErr)
+		}
+		return false, err
+	}
+
+	// The return is closed either way; a replacement left pending can be
+	// confirmed from the admin order screen.
+	confirm := models.UpdateOrderStatusRequest{Status: "confirmed"}
+	if err := s.exchanges.UpdateOrderStatus(ctx, order.ID, confirm, actor); err != nil {
+		slog.Error("failed to confirm replacement order", "order_id", order.ID, "error", err)
+	}
+
+	return true, nil
+}
+
+// transition moves a return to the given status with move, which must only
+// apply if the return is still in its loaded status. A move the status graph
+// does not allow fails with a *models.ReturnTransitionError.
+func (s *Service) transition(ctx context.Context, ret *models.Return, status string, move func() (bool, error)) (*models.Return, error) {
+	if !contains(returnTransitions[ret.Status], status) {
+		return nil, &models.ReturnTransitionError{
+			Message:         fmt.Sprintf("return cannot move from %s to %s", ret.Status, status),
+			Status:          ret.Status,
+			AllowedStatuses: nextStatuses(ret.Status),
+		}
+	}
+
+	moved, err := move()
+	if err != nil {
+		return nil, fmt.Errorf("failed to update return: %w", err)
+	}
+	if !moved {
+		return nil, fmt.Errorf("return was modified concurrently")
+	}
+
+	return s.GetReturn(ctx, ret.ID)
+}
+
+func checkInspections(ret *models.Return, inspections []models.ReturnInspection) error {
+	inspected := make(map[int]bool)
+	for _, inspection := range inspections {
+		if inspection.Condition != "resellable" && inspection.Condition != "damaged" {
+			return fmt.Errorf("condition must be resellable or damaged")
+		}
+		if inspected[inspection.ReturnItemID] {
+			return fmt.Errorf("return item %d is inspected more than once", inspection.ReturnItemID)
+		}
+		inspected[inspection.ReturnItemID] = true
+	}
+
+	for _, item := range ret.Items {
+		if !inspected[item.ID] {
+			return fmt.Errorf("return item %d has not been inspected", item.ID)
+		}
+	}
+
+	if len(inspected) != len(ret.Items) {
+		return fmt.Errorf("inspections must only cover the items of the return")
+	}
+
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func nextStatuses(status string) []string {
+	next := returnTransitions[status]
+	if next == nil {
+		return []string{}
+	}
+	return next
+}
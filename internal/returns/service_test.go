@@ -0,0 +1,130 @@
+package returns
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+)
+
+func TestReturnTransitions(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{statusRequested, statusApproved, true},
+		{statusRequested, statusRejected, true},
+		{statusRequested, statusReceived, false},
+		{statusApproved, statusReceived, true},
+		{statusApproved, statusRefunded, false},
+		{statusReceived, statusRefunding, true},
+		{statusReceived, statusExchanged, true},
+		{statusReceived, statusRefunded, false},
+		{statusRefunding, statusRefunded, true},
+		{statusRefunding, statusReceived, true},
+		{statusRefunding, statusExchanged, false},
+		{statusRejected, statusApproved, false},
+		{statusRefunded, statusRefunding, false},
+		{statusExchanged, statusRefunding, false},
+	}
+
+	for _, tt := range tests {
+		if got := contains(returnTransitions[tt.from], tt.to); got != tt.want {
+			t.Errorf("%s -> %s allowed = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+
+	for from, targets := range returnTransitions {
+		for _, to := range targets {
+			if _, ok := returnTransitions[to]; !ok {
+				t.Errorf("%s moves to %s, which has no entry", from, to)
+			}
+		}
+	}
+}
+
+func TestRestockedProducts(t *testing.T) {
+	ret := &models.Return{Items: []models.ReturnItem{
+		{ProductID: 1, Restocked: true},
+		{ProductID: 2, Restocked: false},
+		{ProductID: 1, Restocked: true},
+		{ProductID: 3, Restocked: true},
+	}}
+
+	if got, want := restockedProducts(ret), []int{1, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("restockedProducts = %v, want %v", got, want)
+	}
+	if got := restockedProducts(&models.Return{}); len(got) != 0 {
+		t.Errorf("restockedProducts of an empty return = %v, want none", got)
+	}
+}
+
+type fakeRepo struct {
+	Repository
+	stale     bool
+	exchanged []int
+}
+
+func (r *fakeRepo) ExchangeReturn(ctx context.Context, id, exchangeOrderID int, actor models.OrderActor) (bool, error) {
+	if r.stale {
+		return false, nil
+	}
+	r.exchanged = append(r.exchanged, exchangeOrderID)
+	return true, nil
+}
+
+type fakeExchanger struct {
+	items []models.OrderItemRequest
+	moves []string
+}
+
+func (e *fakeExchanger) PlaceReplacementOrder(ctx context.Context, orderID int, items []models.OrderItemRequest, actor models.OrderActor) (*models.Order, error) {
+	e.items = items
+	return &models.Order{ID: 9, Status: "pending"}, nil
+}
+
+func (e *fakeExchanger) UpdateOrderStatus(ctx context.Context, orderID int, req models.UpdateOrderStatusRequest, actor models.OrderActor) error {
+	e.moves = append(e.moves, req.Status)
+	return nil
+}
+
+func TestExchange(t *testing.T) {
+	ret := &models.Return{ID: 1, OrderID: 5, Status: statusReceived, Items: []models.ReturnItem{
+		{ProductID: 3, Quantity: 2},
+		{ProductID: 4, Quantity: 1},
+	}}
+
+	tests := []struct {
+		name          string
+		repo          *fakeRepo
+		wantExchanged bool
+		wantMoves     []string
+	}{
+		{"replacement is confirmed once the return is exchanged", &fakeRepo{}, true, []string{"confirmed"}},
+		{"replacement is cancelled when the return moved on", &fakeRepo{stale: true}, false, []string{"cancelled"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exchanges := &fakeExchanger{}
+			svc := NewService(tt.repo, nil, exchanges, nil)
+
+			exchanged, err := svc.exchange(context.Background(), ret, models.OrderActor{Role: "admin"})
+			if err != nil {
+				t.Fatalf("exchange: %v", err)
+			}
+			if exchanged != tt.wantExchanged {
+				t.Errorf("exchange = %v, want %v", exchanged, tt.wantExchanged)
+			}
+
+			wantItems := []models.OrderItemRequest{{ProductID: 3, Quantity: 2}, {ProductID: 4, Quantity: 1}}
+			if !reflect.DeepEqual(exchanges.items, wantItems) {
+				t.Errorf("replacement items = %v, want %v", exchanges.items, wantItems)
+			}
+			if !reflect.DeepEqual(exchanges.moves, tt.wantMoves) {
+				t.Errorf("replacement moved to %v, want %v", exchanges.moves, tt.wantMoves)
+			}
+		})
+	}
+}
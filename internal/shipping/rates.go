@@ -0,0 +1,119 @@
+package shipping
+
+import (
+	"math"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+)
+
+// matchZone picks the zone serving the address. A location matching the
+// postcode beats one covering the whole country, which beats a zone without
+// locations; ties go to the zone created first.
+func matchZone(zones []models.ShippingZone, address models.Address) *models.ShippingZone {
+	country := strings.ToUpper(address.Country)
+	postcode := normalizePostcode(address.PostalCode)
+
+	var best *models.ShippingZone
+	bestScore := -1
+	for i := range zones {
+		zone := &zones[i]
+
+		score := -1
+		if len(zone.Locations) == 0 {
+			score = 0
+		}
+		for _, location := range zone.Locations {
+			if strings.ToUpper(location.Country) != country {
+				continue
+			}
+			if location.PostcodePattern == "" {
+				score = max(score, 1)
+				continue
+			}
+			// Patterns are checked when the zone is created.
+			if matched, _ := path.Match(normalizePostcode(location.PostcodePattern), postcode); matched && postcode != "" {
+				score = 2
+			}
+		}
+
+		if score > bestScore {
+			best, bestScore = zone, score
+		}
+	}
+
+	if bestScore < 0 {
+		return nil
+	}
+	return best
+}
+
+// quote prices the method for the items. It reports false when the method
+// cannot carry them, which only happens when they weigh more than the
+// heaviest weight tier.
+func quote(method models.ShippingMethod, items []models.CartItem, subtotal float64) (models.ShippingOption, bool) {
+	weight := parcelWeight(items, method.VolumetricDivisor)
+	option := models.ShippingOption{
+		Code:     method.Code,
+		Name:     method.Name,
+		WeightKg: math.Round(weight*1000) / 1000,
+	}
+
+	switch method.RateType {
+	case "flat":
+		option.Cost = method.Rate
+
+	case "weight_tiered":
+		tiers := append([]models.ShippingWeightTier(nil), method.WeightTiers...)
+		sort.Slice(tiers, func(i, j int) bool { return tiers[i].MaxWeight < tiers[j].MaxWeight })
+
+		found := false
+		for _, tier := range tiers {
+			if weight <= tier.MaxWeight {
+				option.Cost = tier.Rate
+				found = true
+				break
+			}
+		}
+		if !found {
+			return option, false
+		}
+
+	case "free_over_threshold":
+		option.Cost = method.Rate
+		if subtotal >= method.FreeOver {
+			option.Cost = 0
+		}
+
+	default:
+		return option, false
+	}
+
+	option.Cost = math.Round(option.Cost*100) / 100
+	return option, true
+}
+
+// parcelWeight adds up the weight of the items in kilograms. With a
+// volumetric divisor, bulky items count at their dimensional weight.
+func parcelWeight(items []models.CartItem, volumetricDivisor int) float64 {
+	var total float64
+	for _, item := range items {
+		if item.Product == nil {
+			continue
+		}
+
+		weight := item.Product.WeightKg
+		if volumetricDivisor > 0 {
+			volumetric := item.Product.LengthCm * item.Product.WidthCm * item.Product.HeightCm / float64(volumetricDivisor)
+			weight = math.Max(weight, volumetric)
+		}
+		total += weight * float64(item.Quantity)
+	}
+	return total
+}
+
+func normalizePostcode(postcode string) string {
+	return strings.ToUpper(strings.ReplaceAll(postcode, " ", ""))
+}
@@ -0,0 +1,100 @@
+package shipping
+
+import (
+	"context"
+	"testing"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+)
+
+func TestMatchZone(t *testing.T) {
+	zones := []models.ShippingZone{
+		{ID: 1, Name: "Rest of world"},
+		{ID: 2, Name: "UK", Locations: []models.ShippingZoneLocation{{Country: "GB"}}},
+		{ID: 3, Name: "London", Locations: []models.ShippingZoneLocation{{Country: "GB", PostcodePattern: "SW*"}, {Country: "GB", PostcodePattern: "EC?*"}}},
+		{ID: 4, Name: "UK again", Locations: []models.ShippingZoneLocation{{Country: "GB"}}},
+		{ID: 5, Name: "Germany", Locations: []models.ShippingZoneLocation{{Country: "DE"}}},
+	}
+
+	tests := []struct {
+		name    string
+		zones   []models.ShippingZone
+		address models.Address
+		want    int
+	}{
+		{"postcode beats country", zones, models.Address{Country: "GB", PostalCode: "SW1A 1AA"}, 3},
+		{"postcode is normalised", zones, models.Address{Country: "gb", PostalCode: "ec1a 1bb"}, 3},
+		{"country beats no locations, first zone wins", zones, models.Address{Country: "GB", PostalCode: "M1 1AE"}, 2},
+		{"country without postcode", zones, models.Address{Country: "GB"}, 2},
+		{"other country", zones, models.Address{Country: "DE", PostalCode: "10115"}, 5},
+		{"zone without locations serves the rest", zones, models.Address{Country: "FR", PostalCode: "75001"}, 1},
+		{"no zone serves the address", zones[1:], models.Address{Country: "FR"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zone := matchZone(tt.zones, tt.address)
+			got := 0
+			if zone != nil {
+				got = zone.ID
+			}
+			if got != tt.want {
+				t.Errorf("matchZone = zone %d, want zone %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuote(t *testing.T) {
+	light := &models.Product{WeightKg: 0.5}
+	bulky := &models.Product{WeightKg: 1, LengthCm: 50, WidthCm: 40, HeightCm: 30}
+	tiers := []models.ShippingWeightTier{{MaxWeight: 5, Rate: 9.5}, {MaxWeight: 2, Rate: 4.5}}
+
+	tests := []struct {
+		name       string
+		method     models.ShippingMethod
+		items      []models.CartItem
+		subtotal   float64
+		wantCost   float64
+		wantWeight float64
+		wantOK     bool
+	}{
+		{"flat", models.ShippingMethod{RateType: "flat", Rate: 3.99}, []models.CartItem{{Quantity: 2, Product: light}}, 20, 3.99, 1, true},
+		{"lightest tier that fits", models.ShippingMethod{RateType: "weight_tiered", WeightTiers: tiers}, []models.CartItem{{Quantity: 3, Product: light}}, 20, 4.5, 1.5, true},
+		{"heavier tier", models.ShippingMethod{RateType: "weight_tiered", WeightTiers: tiers}, []models.CartItem{{Quantity: 3, Product: bulky}}, 20, 9.5, 3, true},
+		{"heavier than every tier", models.ShippingMethod{RateType: "weight_tiered", WeightTiers: tiers}, []models.CartItem{{Quantity: 6, Product: bulky}}, 20, 0, 6, false},
+		{"volumetric weight", models.ShippingMethod{RateType: "weight_tiered", WeightTiers: tiers, VolumetricDivisor: 5000}, []models.CartItem{{Quantity: 1, Product: bulky}}, 20, 9.5, 12, false},
+		{"below free threshold", models.ShippingMethod{RateType: "free_over_threshold", Rate: 4.99, FreeOver: 50}, []models.CartItem{{Quantity: 1, Product: light}}, 49.99, 4.99, 0.5, true},
+		{"free over threshold", models.ShippingMethod{RateType: "free_over_threshold", Rate: 4.99, FreeOver: 50}, []models.CartItem{{Quantity: 1, Product: light}}, 50, 0, 0.5, true},
+		{"unknown rate type", models.ShippingMethod{RateType: "bartered"}, []models.CartItem{{Quantity: 1, Product: light}}, 20, 0, 0.5, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			option, ok := quote(tt.method, tt.items, tt.subtotal)
+			if ok != tt.wantOK {
+				t.Fatalf("quote ok = %v, want %v", ok, tt.wantOK)
+			}
+			if option.WeightKg != tt.wantWeight {
+				t.Errorf("quote weight = %v, want %v", option.WeightKg, tt.wantWeight)
+			}
+			if ok && option.Cost != tt.wantCost {
+				t.Errorf("quote cost = %v, want %v", option.Cost, tt.wantCost)
+			}
+		})
+	}
+}
+
+func TestCreateZoneRejectsBadPostcodePatterns(t *testing.T) {
+	svc := NewService(nil)
+
+	for _, pattern := range []string{"SW[", "SW[1-", `SW\`} {
+		req := models.CreateShippingZoneRequest{
+			Name:      "London",
+			Locations: []models.ShippingZoneLocation{{Country: "GB", PostcodePattern: pattern}},
+		}
+		if _, err := svc.CreateZone(context.Background(), req); err == nil {
+			t.Errorf("CreateZone accepted postcode pattern %q", pattern)
+		}
+	}
+}
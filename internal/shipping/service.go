@@ -0,0 +1,159 @@
+package shipping
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+)
+
+type Repository interface {
+	CreateShippingZone(ctx context.Context, req models.CreateShippingZoneRequest) (*models.ShippingZone, error)
+	GetShippingZones(ctx context.Context) ([]models.ShippingZone, error)
+	GetShippingZoneByID(ctx context.Context, id int) (*models.ShippingZone, error)
+	DeleteShippingZone(ctx context.Context, id int) error
+	CreateShippingMethod(ctx context.Context, req models.CreateShippingMethodRequest) (*models.ShippingMethod, error)
+	GetShippingMethods(ctx context.Context, zoneID int, activeOnly bool) ([]models.ShippingMethod, error)
+	DeactivateShippingMethod(ctx context.Context, id int) error
+}
+
+type Service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// Quote prices every active method of the zone serving the address for the
+// items. subtotal is the discounted item total free_over_threshold methods
+// are checked against. No zone serving the address means no options.
+func (s *Service) Quote(ctx context.Context, address models.Address, items []models.CartItem, subtotal float64) ([]models.ShippingOption, error) {
+	zones, err := s.repo.GetShippingZones(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shipping zones: %w", err)
+	}
+
+	zone := matchZone(zones, address)
+	if zone == nil {
+		return nil, nil
+	}
+
+	methods, err := s.repo.GetShippingMethods(ctx, zone.ID, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shipping methods: %w", err)
+	}
+
+	var options []models.ShippingOption
+	for _, method := range methods {
+		if option, ok := quote(method, items, subtotal); ok {
+			options = append(options, option)
+		}
+	}
+
+	return options, nil
+}
+
+func (s *Service) CreateZone(ctx context.Context, req models.CreateShippingZoneRequest) (*models.ShippingZone, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return nil, fmt.Errorf("zone name is required")
+	}
+
+	for i, location := range req.Locations {
+		location.Country = strings.ToUpper(strings.TrimSpace(location.Country))
+		if len(location.Country) != 2 {
+			return nil, fmt.Errorf("country must be a two-letter country code")
+		}
+		location.PostcodePattern = strings.TrimSpace(location.PostcodePattern)
+		if _, err := path.Match(normalizePostcode(location.PostcodePattern), ""); errors.Is(err, path.ErrBadPattern) {
+			return nil, fmt.Errorf("invalid postcode pattern: %s", location.PostcodePattern)
+		}
+		req.Locations[i] = location
+	}
+
+	zone, err := s.repo.CreateShippingZone(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create shipping zone: %w", err)
+	}
+
+	return zone, nil
+}
+
+func (s *Service) GetZones(ctx context.Context) ([]models.ShippingZone, error) {
+	zones, err := s.repo.GetShippingZones(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shipping zones: %w", err)
+	}
+	return zones, nil
+}
+
+func (s *Service) DeleteZone(ctx context.Context, id int) error {
+	if err := s.repo.DeleteShippingZone(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete shipping zone: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) CreateMethod(ctx context.Context, req models.CreateShippingMethodRequest) (*models.ShippingMethod, error) {
+	req.Code = strings.ToLower(strings.TrimSpace(req.Code))
+	if req.Code == "" || req.Name == "" {
+		return nil, fmt.Errorf("method code and name are required")
+	}
+
+	if req.Rate < 0 {
+		return nil, fmt.Errorf("rate cannot be negative")
+	}
+
+	if req.VolumetricDivisor < 0 {
+		return nil, fmt.Errorf("volumetric divisor cannot be negative")
+	}
+
+	switch req.RateType {
+	case "flat":
+	case "weight_tiered":
+		if len(req.WeightTiers) == 0 {
+			return nil, fmt.Errorf("at least one weight tier is required")
+		}
+		for _, tier := range req.WeightTiers {
+			if tier.MaxWeight <= 0 || tier.Rate < 0 {
+				return nil, fmt.Errorf("weight tiers need a positive maximum weight and a rate of 0 or more")
+			}
+		}
+	case "free_over_threshold":
+		if req.FreeOver <= 0 {
+			return nil, fmt.Errorf("free_over must be greater than 0")
+		}
+	default:
+		return nil, fmt.Errorf("invalid rate type: %s", req.RateType)
+	}
+
+	if _, err := s.repo.GetShippingZoneByID(ctx, req.ZoneID); err != nil {
+		return nil, fmt.Errorf("shipping zone not found")
+	}
+
+	method, err := s.repo.CreateShippingMethod(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create shipping method: %w", err)
+	}
+
+	return method, nil
+}
+
+func (s *Service) GetMethods(ctx context.Context, zoneID int) ([]models.ShippingMethod, error) {
+	methods, err := s.repo.GetShippingMethods(ctx, zoneID, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shipping methods: %w", err)
+	}
+	return methods, nil
+}
+
+func (s *Service) DeactivateMethod(ctx context.Context, id int) error {
+	if err := s.repo.DeactivateShippingMethod(ctx, id); err != nil {
+		return fmt.Errorf("failed to deactivate shipping method: %w", err)
+	}
+	return nil
+}
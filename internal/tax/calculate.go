@@ -0,0 +1,93 @@
+package tax
+
+import (
+	"math"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+)
+
+// calculate applies the rates to the items. Each item takes the rates for its
+// category's tax class, or the rates without a class when its category has
+// none. The discount is spread over the items in proportion to their price.
+//
+// Rates of jurisdictions whose prices include tax are taken out of the price;
+// the others are charged on the price without those, and added on top.
+func calculate(rates []models.TaxRate, classes map[int]int, items []models.CartItem, discount float64) *models.TaxBreakdown {
+	breakdown := &models.TaxBreakdown{Items: make(map[int]models.ItemTax)}
+
+	var subtotal float64
+	for _, item := range items {
+		subtotal += float64(item.Quantity) * item.UnitPrice
+	}
+	discount = math.Min(discount, subtotal)
+
+	amounts := make(map[int]float64)
+	lines := make(map[int]*models.TaxLine)
+	var order []int
+
+	for _, item := range items {
+		amount := float64(item.Quantity) * item.UnitPrice
+		if subtotal > 0 {
+			amount -= discount * amount / subtotal
+		}
+
+		classID, hasClass := 0, false
+		if item.Product != nil {
+			classID, hasClass = classes[item.Product.CategoryID]
+		}
+
+		var applicable []models.TaxRate
+		var inclusiveRate float64
+		for _, rate := range rates {
+			if (rate.TaxClassID == nil) == hasClass || (hasClass && *rate.TaxClassID != classID) {
+				continue
+			}
+			applicable = append(applicable, rate)
+			if rate.PricesIncludeTax {
+				inclusiveRate += rate.Rate
+			}
+		}
+
+		net := amount / (1 + inclusiveRate/100)
+
+		var itemTax models.ItemTax
+		for _, rate := range applicable {
+			tax := net * rate.Rate / 100
+			itemTax.Rate += rate.Rate
+			itemTax.Amount += tax
+
+			if _, ok := lines[rate.ID]; !ok {
+				lines[rate.ID] = &models.TaxLine{
+					TaxRateID:    rate.ID,
+					Name:         rate.Name,
+					Jurisdiction: rate.Jurisdiction,
+					Rate:         rate.Rate,
+					Inclusive:    rate.PricesIncludeTax,
+				}
+				order = append(order, rate.ID)
+			}
+			amounts[rate.ID] += tax
+		}
+
+		itemTax.Amount = roundPrice(itemTax.Amount)
+		breakdown.Items[item.ProductID] = itemTax
+	}
+
+	for _, id := range order {
+		line := lines[id]
+		line.Amount = roundPrice(amounts[id])
+		breakdown.Lines = append(breakdown.Lines, *line)
+		breakdown.Total += line.Amount
+		if !line.Inclusive {
+			breakdown.Added += line.Amount
+		}
+	}
+	breakdown.Total = roundPrice(breakdown.Total)
+	breakdown.Added = roundPrice(breakdown.Added)
+
+	return breakdown
+}
+
+func roundPrice(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
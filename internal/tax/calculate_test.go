@@ -0,0 +1,100 @@
+package tax
+
+import (
+	"testing"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+)
+
+func taxItem(productID, categoryID, quantity int, unitPrice float64) models.CartItem {
+	return models.CartItem{
+		ProductID: productID,
+		Quantity:  quantity,
+		UnitPrice: unitPrice,
+		Product:   &models.Product{ID: productID, CategoryID: categoryID},
+	}
+}
+
+func TestCalculate(t *testing.T) {
+	reduced := 1
+	salesTax := models.TaxRate{ID: 1, Name: "Sales tax", Rate: 10, Jurisdiction: "US-CA"}
+	vat := models.TaxRate{ID: 2, Name: "VAT", Rate: 20, Jurisdiction: "GB", PricesIncludeTax: true}
+	levy := models.TaxRate{ID: 3, Name: "Levy", Rate: 5, Jurisdiction: "GB-LDN"}
+	reducedVAT := models.TaxRate{ID: 4, TaxClassID: &reduced, Name: "Reduced VAT", Rate: 5, Jurisdiction: "GB", PricesIncludeTax: true}
+
+	tests := []struct {
+		name      string
+		rates     []models.TaxRate
+		classes   map[int]int
+		items     []models.CartItem
+		discount  float64
+		wantTotal float64
+		wantAdded float64
+		wantItems map[int]float64
+	}{
+		{
+			name:      "exclusive rate is added on top",
+			rates:     []models.TaxRate{salesTax},
+			items:     []models.CartItem{taxItem(1, 1, 2, 50)},
+			wantTotal: 10, wantAdded: 10,
+			wantItems: map[int]float64{1: 10},
+		},
+		{
+			name:      "inclusive rate is taken out of the price",
+			rates:     []models.TaxRate{vat},
+			items:     []models.CartItem{taxItem(1, 1, 1, 120)},
+			wantTotal: 20, wantAdded: 0,
+			wantItems: map[int]float64{1: 20},
+		},
+		{
+			name:      "exclusive rate is charged on the price without inclusive tax",
+			rates:     []models.TaxRate{vat, levy},
+			items:     []models.CartItem{taxItem(1, 1, 1, 120)},
+			wantTotal: 25, wantAdded: 5,
+			wantItems: map[int]float64{1: 25},
+		},
+		{
+			name:      "discount is spread over the items",
+			rates:     []models.TaxRate{salesTax},
+			items:     []models.CartItem{taxItem(1, 1, 1, 60), taxItem(2, 1, 1, 40)},
+			discount:  20,
+			wantTotal: 8, wantAdded: 8,
+			wantItems: map[int]float64{1: 4.8, 2: 3.2},
+		},
+		{
+			name:      "discount beyond the subtotal leaves nothing to tax",
+			rates:     []models.TaxRate{salesTax},
+			items:     []models.CartItem{taxItem(1, 1, 1, 60)},
+			discount:  100,
+			wantTotal: 0, wantAdded: 0,
+			wantItems: map[int]float64{1: 0},
+		},
+		{
+			name:      "classed category takes only its class's rates",
+			rates:     []models.TaxRate{vat, reducedVAT},
+			classes:   map[int]int{5: reduced},
+			items:     []models.CartItem{taxItem(1, 5, 1, 105), taxItem(2, 6, 1, 120)},
+			wantTotal: 25, wantAdded: 0,
+			wantItems: map[int]float64{1: 5, 2: 20},
+		},
+		{
+			name:      "no rates",
+			items:     []models.CartItem{taxItem(1, 1, 1, 100)},
+			wantItems: map[int]float64{1: 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breakdown := calculate(tt.rates, tt.classes, tt.items, tt.discount)
+			if breakdown.Total != tt.wantTotal || breakdown.Added != tt.wantAdded {
+				t.Errorf("calculate total = %v added %v, want %v added %v", breakdown.Total, breakdown.Added, tt.wantTotal, tt.wantAdded)
+			}
+			for productID, want := range tt.wantItems {
+				if got := breakdown.Items[productID].Amount; got != want {
+					t.Errorf("calculate product %d tax = %v, want %v", productID, got, want)
+				}
+			}
+		})
+	}
+}
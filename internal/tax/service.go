@@ -0,0 +1,190 @@
+package tax
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+)
+
+const dateLayout = "2006-01-02"
+
+type Repository interface {
+	CreateTaxClass(ctx context.Context, req models.CreateTaxClassRequest) (*models.TaxClass, error)
+	GetTaxClasses(ctx context.Context) ([]models.TaxClass, error)
+	GetTaxClassByID(ctx context.Context, id int) (*models.TaxClass, error)
+	SetCategoryTaxClass(ctx context.Context, categoryID int, classID *int) (bool, error)
+	GetCategoryTaxClasses(ctx context.Context, categoryIDs []int) (map[int]int, error)
+	CreateTaxJurisdiction(ctx context.Context, req models.CreateTaxJurisdictionRequest) (*models.TaxJurisdiction, error)
+	GetTaxJurisdictions(ctx context.Context) ([]models.TaxJurisdiction, error)
+	GetTaxJurisdictionByID(ctx context.Context, id int) (*models.TaxJurisdiction, error)
+	CreateTaxRate(ctx context.Context, rate models.TaxRate) (*models.TaxRate, error)
+	GetTaxRates(ctx context.Context, jurisdictionID int) ([]models.TaxRate, error)
+	GetApplicableTaxRates(ctx context.Context, country, region string, on time.Time) ([]models.TaxRate, error)
+}
+
+type Service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// CartTax works out the tax on items shipped to the address, after the
+// discount is spread over them. An address without a country is not taxed.
+func (s *Service) CartTax(ctx context.Context, address models.Address, items []models.CartItem, discount float64) (*models.TaxBreakdown, error) {
+	if address.Country == "" || len(items) == 0 {
+		return &models.TaxBreakdown{}, nil
+	}
+
+	rates, err := s.repo.GetApplicableTaxRates(ctx, address.Country, address.Region, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tax rates: %w", err)
+	}
+
+	var categoryIDs []int
+	for _, item := range items {
+		if item.Product != nil {
+			categoryIDs = append(categoryIDs, item.Product.CategoryID)
+		}
+	}
+
+	classes, err := s.repo.GetCategoryTaxClasses(ctx, categoryIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tax classes: %w", err)
+	}
+
+	return calculate(rates, classes, items, discount), nil
+}
+
+func (s *Service) CreateTaxClass(ctx context.Context, req models.CreateTaxClassRequest) (*models.TaxClass, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return nil, fmt.Errorf("tax class name is required")
+	}
+
+	class, err := s.repo.CreateTaxClass(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tax class: %w", err)
+	}
+
+	return class, nil
+}
+
+func (s *Service) GetTaxClasses(ctx context.Context) ([]models.TaxClass, error) {
+	classes, err := s.repo.GetTaxClasses(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tax classes: %w", err)
+	}
+	return classes, nil
+}
+
+// SetCategoryTaxClass sets the tax class of the products in a category.
+// A nil classID puts them back on the rates without a class.
+func (s *Service) SetCategoryTaxClass(ctx context.Context, categoryID int, classID *int) error {
+	if classID != nil {
+		if _, err := s.repo.GetTaxClassByID(ctx, *classID); err != nil {
+			return fmt.Errorf("tax class not found")
+		}
+	}
+
+	found, err := s.repo.SetCategoryTaxClass(ctx, categoryID, classID)
+	if err != nil {
+		return fmt.Errorf("failed to update category tax class: %w", err)
+	}
+	if !found {
+		return fmt.Errorf("category not found")
+	}
+
+	return nil
+}
+
+func (s *Service) CreateJurisdiction(ctx context.Context, req models.CreateTaxJurisdictionRequest) (*models.TaxJurisdiction, error) {
+	req.Country = strings.ToUpper(strings.TrimSpace(req.Country))
+	req.Region = strings.TrimSpace(req.Region)
+	req.Name = strings.TrimSpace(req.Name)
+
+	if len(req.Country) != 2 {
+		return nil, fmt.Errorf("country must be a two-letter country code")
+	}
+	if req.Name == "" {
+		return nil, fmt.Errorf("jurisdiction name is required")
+	}
+
+	jurisdiction, err := s.repo.CreateTaxJurisdiction(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tax jurisdiction: %w", err)
+	}
+
+	return jurisdiction, nil
+}
+
+func (s *Service) GetJurisdictions(ctx context.Context) ([]models.TaxJurisdiction, error) {
+	jurisdictions, err := s.repo.GetTaxJurisdictions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tax jurisdictions: %w", err)
+	}
+	return jurisdictions, nil
+}
+
+func (s *Service) CreateRate(ctx context.Context, req models.CreateTaxRateRequest) (*models.TaxRate, error) {
+	if req.Name == "" {
+		return nil, fmt.Errorf("tax rate name is required")
+	}
+
+	if req.Rate < 0 || req.Rate >= 100 {
+		return nil, fmt.Errorf("tax rate must be between 0 and 100")
+	}
+
+	if _, err := s.repo.GetTaxJurisdictionByID(ctx, req.JurisdictionID); err != nil {
+		return nil, fmt.Errorf("tax jurisdiction not found")
+	}
+
+	if req.TaxClassID != nil {
+		if _, err := s.repo.GetTaxClassByID(ctx, *req.TaxClassID); err != nil {
+			return nil, fmt.Errorf("tax class not found")
+		}
+	}
+
+	rate := models.TaxRate{
+		JurisdictionID: req.JurisdictionID,
+		TaxClassID:     req.TaxClassID,
+		Name:           req.Name,
+		Rate:           req.Rate,
+	}
+
+	effectiveFrom, err := time.Parse(dateLayout, req.EffectiveFrom)
+	if err != nil {
+		return nil, fmt.Errorf("effective_from must be a date (YYYY-MM-DD)")
+	}
+	rate.EffectiveFrom = effectiveFrom
+
+	if req.EffectiveTo != "" {
+		effectiveTo, err := time.Parse(dateLayout, req.EffectiveTo)
+		if err != nil {
+			return nil, fmt.Errorf("effective_to must be a date (YYYY-MM-DD)")
+		}
+		if !effectiveTo.After(effectiveFrom) {
+			return nil, fmt.Errorf("effective_to must be after effective_from")
+		}
+		rate.EffectiveTo = &effectiveTo
+	}
+
+	created, err := s.repo.CreateTaxRate(ctx, rate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tax rate: %w", err)
+	}
+
+	return created, nil
+}
+
+func (s *Service) GetRates(ctx context.Context, jurisdictionID int) ([]models.TaxRate, error) {
+	rates, err := s.repo.GetTaxRates(ctx, jurisdictionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tax rates: %w", err)
+	}
+	return rates, nil
+}
@@ -23,12 +23,20 @@ func (h *handler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.CartToken == "" {
+		req.CartToken = auth.CartTokenFromRequest(r)
+	}
+
 	resp, err := h.service.Register(r.Context(), req)
 	if err != nil {
 		json.WriteError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
+	if req.CartToken != "" {
+		auth.ClearCartToken(w)
+	}
+
 	json.Write(w, http.StatusCreated, resp)
 }
 
@@ -39,12 +47,20 @@ func (h *handler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.CartToken == "" {
+		req.CartToken = auth.CartTokenFromRequest(r)
+	}
+
 	resp, err := h.service.Login(r.Context(), req)
 	if err != nil {
 		json.WriteError(w, http.StatusUnauthorized, err.Error())
 		return
 	}
 
+	if req.CartToken != "" {
+		auth.ClearCartToken(w)
+	}
+
 	json.Write(w, http.StatusOK, resp)
 }
 
@@ -3,6 +3,7 @@ package users
 import (
 	"context"
 	"fmt"
+	"log/slog"
 
 	"github.com/VishalHilal/e-commerce-api/internal/auth"
 	"github.com/VishalHilal/e-commerce-api/internal/models"
@@ -17,15 +18,22 @@ type Repository interface {
 	DeleteUser(ctx context.Context, id int) error
 }
 
+// CartMerger moves a guest cart into a user's cart once they sign in.
+type CartMerger interface {
+	MergeGuestCart(ctx context.Context, guestToken string, userID int) error
+}
+
 type Service struct {
 	repo   Repository
 	jwtSvc *auth.JWTService
+	carts  CartMerger
 }
 
-func NewService(repo Repository, jwtSvc *auth.JWTService) *Service {
+func NewService(repo Repository, jwtSvc *auth.JWTService, carts CartMerger) *Service {
 	return &Service{
 		repo:   repo,
 		jwtSvc: jwtSvc,
+		carts:  carts,
 	}
 }
 
@@ -57,6 +65,8 @@ func (s *Service) Register(ctx context.Context, req models.CreateUserRequest) (*
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
+	s.mergeGuestCart(ctx, req.CartToken, user.ID)
+
 	token, err := s.jwtSvc.GenerateToken(user.ID, user.Email, user.Role)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate token: %w", err)
@@ -81,6 +91,8 @@ func (s *Service) Login(ctx context.Context, req models.LoginRequest) (*models.L
 		return nil, fmt.Errorf("invalid credentials")
 	}
 
+	s.mergeGuestCart(ctx, req.CartToken, user.ID)
+
 	token, err := s.jwtSvc.GenerateToken(user.ID, user.Email, user.Role)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate token: %w", err)
@@ -124,3 +136,21 @@ func (s *Service) UpdateProfile(ctx context.Context, userID int, req models.User
 
 	return s.repo.UpdateUser(ctx, userID, *user)
 }
+
+// mergeGuestCart folds the signed guest cart into the user's cart. A missing
+// or invalid token is ignored and merge failures are only logged, so they
+// never block signing in.
+func (s *Service) mergeGuestCart(ctx context.Context, cartToken string, userID int) {
+	if cartToken == "" || s.carts == nil {
+		return
+	}
+
+	claims, err := s.jwtSvc.ValidateCartToken(cartToken)
+	if err != nil {
+		return
+	}
+
+	if err := s.carts.MergeGuestCart(ctx, claims.CartID, userID); err != nil {
+		slog.Error("failed to merge guest cart", "user_id", userID, "error", err)
+	}
+}
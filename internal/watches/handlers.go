@@ -0,0 +1,95 @@
+package watches
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/VishalHilal/e-commerce-api/internal/auth"
+	"github.com/VishalHilal/e-commerce-api/internal/json"
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+	"github.com/go-chi/chi/v5"
+)
+
+type handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *handler {
+	return &handler{service: service}
+}
+
+func (h *handler) CreateWatch(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		json.WriteError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var req models.CreateWatchRequest
+	if err := json.Read(r, &req); err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	watch, err := h.service.CreateWatch(r.Context(), claims.UserID, req)
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusCreated, watch)
+}
+
+func (h *handler) GetWatches(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		json.WriteError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	watches, err := h.service.GetUserWatches(r.Context(), claims.UserID)
+	if err != nil {
+		json.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, map[string]interface{}{
+		"watches": watches,
+		"count":   len(watches),
+	})
+}
+
+func (h *handler) DeleteWatch(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		json.WriteError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	watchID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, "Invalid watch ID")
+		return
+	}
+
+	if err := h.service.DeleteWatch(r.Context(), watchID, claims.UserID); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrWatchNotFound) {
+			status = http.StatusNotFound
+		}
+		json.WriteError(w, status, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, map[string]string{"message": "Watch deleted successfully"})
+}
+
+func (h *handler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.Unsubscribe(r.Context(), chi.URLParam(r, "token")); err != nil {
+		json.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	json.Write(w, http.StatusOK, map[string]string{"message": "Unsubscribed successfully"})
+}
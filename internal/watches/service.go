@@ -0,0 +1,169 @@
+package watches
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/VishalHilal/e-commerce-api/internal/email"
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+	"github.com/google/uuid"
+)
+
+// ErrWatchNotFound is returned when a user deletes a watch they do not have.
+var ErrWatchNotFound = errors.New("watch not found")
+
+type Repository interface {
+	CreateWatch(ctx context.Context, userID int, req models.CreateWatchRequest, unsubscribeToken string) (*models.ProductWatch, error)
+	GetWatchesByUserID(ctx context.Context, userID int) ([]models.ProductWatch, error)
+	GetPendingWatchesByProductID(ctx context.Context, productID int) ([]models.ProductWatch, error)
+	ClaimWatch(ctx context.Context, id int) (bool, error)
+	ReleaseWatch(ctx context.Context, id int) error
+	DeleteWatch(ctx context.Context, id, userID int) (bool, error)
+	DeleteWatchByToken(ctx context.Context, unsubscribeToken string) (bool, error)
+	GetProductByID(ctx context.Context, id int) (*models.Product, error)
+	GetUserByID(ctx context.Context, id int) (*models.User, error)
+}
+
+type Service struct {
+	repo     Repository
+	emailSvc *email.EmailService
+}
+
+func NewService(repo Repository, emailSvc *email.EmailService) *Service {
+	return &Service{
+		repo:     repo,
+		emailSvc: emailSvc,
+	}
+}
+
+func (s *Service) CreateWatch(ctx context.Context, userID int, req models.CreateWatchRequest) (*models.ProductWatch, error) {
+	if !req.BackInStock && req.TargetPrice == nil {
+		return nil, fmt.Errorf("either back_in_stock or target_price is required")
+	}
+
+	if req.TargetPrice != nil && *req.TargetPrice <= 0 {
+		return nil, fmt.Errorf("target price must be greater than 0")
+	}
+
+	product, err := s.repo.GetProductByID(ctx, req.ProductID)
+	if err != nil {
+		return nil, fmt.Errorf("product not found: %w", err)
+	}
+
+	watch := models.ProductWatch{BackInStock: req.BackInStock, TargetPrice: req.TargetPrice}
+	if isSatisfied(&watch, product) {
+		return nil, fmt.Errorf("product already matches the requested conditions")
+	}
+
+	created, err := s.repo.CreateWatch(ctx, userID, req, uuid.New().String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create watch: %w", err)
+	}
+
+	created.Product = product
+	return created, nil
+}
+
+func (s *Service) GetUserWatches(ctx context.Context, userID int) ([]models.ProductWatch, error) {
+	watches, err := s.repo.GetWatchesByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get watches: %w", err)
+	}
+	return watches, nil
+}
+
+func (s *Service) DeleteWatch(ctx context.Context, watchID, userID int) error {
+	deleted, err := s.repo.DeleteWatch(ctx, watchID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete watch: %w", err)
+	}
+
+	if !deleted {
+		return ErrWatchNotFound
+	}
+	return nil
+}
+
+func (s *Service) Unsubscribe(ctx context.Context, token string) error {
+	deleted, err := s.repo.DeleteWatchByToken(ctx, token)
+	if err != nil {
+		return fmt.Errorf("failed to unsubscribe: %w", err)
+	}
+
+	if !deleted {
+		return fmt.Errorf("subscription not found")
+	}
+	return nil
+}
+
+// ProductUpdated sends a notification for every pending watch on the product
+// whose conditions are now met. The emails go out in the background so the
+// update that triggered them does not wait on the mail server. Each watch is
+// claimed before the email goes out, so it fires at most once even if updates
+// race.
+func (s *Service) ProductUpdated(ctx context.Context, product *models.Product) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		s.notifyWatches(ctx, product)
+	}()
+}
+
+func (s *Service) notifyWatches(ctx context.Context, product *models.Product) {
+	watches, err := s.repo.GetPendingWatchesByProductID(ctx, product.ID)
+	if err != nil {
+		slog.Error("failed to load product watches", "product_id", product.ID, "error", err)
+		return
+	}
+
+	for i := range watches {
+		watch := &watches[i]
+		if !isSatisfied(watch, product) {
+			continue
+		}
+
+		claimed, err := s.repo.ClaimWatch(ctx, watch.ID)
+		if err != nil || !claimed {
+			continue
+		}
+
+		if err := s.notify(ctx, watch, product); err != nil {
+			slog.Error("failed to send watch notification", "watch_id", watch.ID, "error", err)
+			if err := s.repo.ReleaseWatch(ctx, watch.ID); err != nil {
+				slog.Error("failed to release watch", "watch_id", watch.ID, "error", err)
+			}
+		}
+	}
+}
+
+func (s *Service) notify(ctx context.Context, watch *models.ProductWatch, product *models.Product) error {
+	user, err := s.repo.GetUserByID(ctx, watch.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return s.emailSvc.SendProductWatchEmail(user, product, watch)
+}
+
+// isSatisfied reports whether every condition set on the watch holds for the
+// product. A target price is met only once the price drops below it, and a
+// watch with both conditions waits for an in-stock price drop.
+func isSatisfied(watch *models.ProductWatch, product *models.Product) bool {
+	if !product.IsActive {
+		return false
+	}
+
+	if watch.BackInStock && product.StockQuantity <= 0 {
+		return false
+	}
+
+	if watch.TargetPrice != nil && product.Price >= *watch.TargetPrice {
+		return false
+	}
+
+	return true
+}
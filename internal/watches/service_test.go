@@ -0,0 +1,34 @@
+package watches
+
+import (
+	"testing"
+
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+)
+
+func TestIsSatisfied(t *testing.T) {
+	target := 20.0
+
+	tests := []struct {
+		name    string
+		watch   models.ProductWatch
+		product models.Product
+		want    bool
+	}{
+		{"back in stock", models.ProductWatch{BackInStock: true}, models.Product{IsActive: true, StockQuantity: 1}, true},
+		{"still out of stock", models.ProductWatch{BackInStock: true}, models.Product{IsActive: true}, false},
+		{"price below target", models.ProductWatch{TargetPrice: &target}, models.Product{IsActive: true, Price: 19.99}, true},
+		{"price at target", models.ProductWatch{TargetPrice: &target}, models.Product{IsActive: true, Price: 20}, false},
+		{"price above target", models.ProductWatch{TargetPrice: &target}, models.Product{IsActive: true, Price: 25}, false},
+		{"price drop while out of stock", models.ProductWatch{BackInStock: true, TargetPrice: &target}, models.Product{IsActive: true, Price: 15}, false},
+		{"inactive product", models.ProductWatch{BackInStock: true}, models.Product{StockQuantity: 1}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSatisfied(&tt.watch, &tt.product); got != tt.want {
+				t.Errorf("isSatisfied = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
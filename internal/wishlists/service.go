@@ -0,0 +1,191 @@
+package wishlists
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/VishalHilal/e-commerce-api/internal/cart"
+	"github.com/VishalHilal/e-commerce-api/internal/models"
+	"github.com/google/uuid"
+)
+
+type Repository interface {
+	CreateWishlist(ctx context.Context, userID int, name, shareToken string) (*models.Wishlist, error)
+	GetWishlistsByUserID(ctx context.Context, userID int) ([]models.Wishlist, error)
+	GetWishlistByID(ctx context.Context, id int) (*models.Wishlist, error)
+	GetWishlistByShareToken(ctx context.Context, shareToken string) (*models.Wishlist, error)
+	UpdateWishlist(ctx context.Context, id int, name string) error
+	DeleteWishlist(ctx context.Context, id int) error
+	AddWishlistItem(ctx context.Context, wishlistID, productID int) (*models.WishlistItem, error)
+	GetWishlistItem(ctx context.Context, wishlistID, productID int) (*models.WishlistItem, error)
+	GetWishlistItems(ctx context.Context, wishlistID int) ([]models.WishlistItem, error)
+	RemoveWishlistItem(ctx context.Context, wishlistID, productID int) error
+	GetProductByID(ctx context.Context, id int) (*models.Product, error)
+}
+
+type Service struct {
+	repo    Repository
+	cartSvc *cart.Service
+}
+
+func NewService(repo Repository, cartSvc *cart.Service) *Service {
+	return &Service{
+		repo:    repo,
+		cartSvc: cartSvc,
+	}
+}
+
+func (s *Service) CreateWishlist(ctx context.Context, userID int, req models.CreateWishlistRequest) (*models.Wishlist, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("wishlist name is required")
+	}
+
+	wishlist, err := s.repo.CreateWishlist(ctx, userID, name, uuid.New().String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create wishlist: %w", err)
+	}
+
+	return wishlist, nil
+}
+
+func (s *Service) GetUserWishlists(ctx context.Context, userID int) ([]models.Wishlist, error) {
+	wishlists, err := s.repo.GetWishlistsByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wishlists: %w", err)
+	}
+	return wishlists, nil
+}
+
+func (s *Service) GetWishlist(ctx context.Context, wishlistID, userID int) (*models.Wishlist, error) {
+	wishlist, err := s.getOwnedWishlist(ctx, wishlistID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.withItems(ctx, wishlist)
+}
+
+func (s *Service) GetSharedWishlist(ctx context.Context, shareToken string) (*models.Wishlist, error) {
+	wishlist, err := s.repo.GetWishlistByShareToken(ctx, shareToken)
+	if err != nil {
+		return nil, fmt.Errorf("wishlist not found")
+	}
+
+	return s.withItems(ctx, wishlist)
+}
+
+func (s *Service) RenameWishlist(ctx context.Context, wishlistID, userID int, req models.UpdateWishlistRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return fmt.Errorf("wishlist name is required")
+	}
+
+	if _, err := s.getOwnedWishlist(ctx, wishlistID, userID); err != nil {
+		return err
+	}
+
+	if err := s.repo.UpdateWishlist(ctx, wishlistID, name); err != nil {
+		return fmt.Errorf("failed to update wishlist: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) DeleteWishlist(ctx context.Context, wishlistID, userID int) error {
+	if _, err := s.getOwnedWishlist(ctx, wishlistID, userID); err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteWishlist(ctx, wishlistID); err != nil {
+		return fmt.Errorf("failed to delete wishlist: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) AddItem(ctx context.Context, wishlistID, userID int, req models.AddToWishlistRequest) (*models.WishlistItem, error) {
+	if _, err := s.getOwnedWishlist(ctx, wishlistID, userID); err != nil {
+		return nil, err
+	}
+
+	product, err := s.repo.GetProductByID(ctx, req.ProductID)
+	if err != nil {
+		return nil, fmt.Errorf("product not found: %w", err)
+	}
+
+	if !product.IsActive {
+		return nil, fmt.Errorf("product is not available")
+	}
+
+	item, err := s.repo.AddWishlistItem(ctx, wishlistID, req.ProductID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add to wishlist: %w", err)
+	}
+
+	return item, nil
+}
+
+func (s *Service) RemoveItem(ctx context.Context, wishlistID, userID, productID int) error {
+	if _, err := s.getOwnedWishlist(ctx, wishlistID, userID); err != nil {
+		return err
+	}
+
+	if err := s.repo.RemoveWishlistItem(ctx, wishlistID, productID); err != nil {
+		return fmt.Errorf("failed to remove from wishlist: %w", err)
+	}
+	return nil
+}
+
+// MoveToCart adds a wishlist item to the user's cart and removes it from the
+// wishlist once the cart accepted it.
+func (s *Service) MoveToCart(ctx context.Context, wishlistID, userID, productID int, req models.MoveToCartRequest) (*models.CartItem, error) {
+	if _, err := s.getOwnedWishlist(ctx, wishlistID, userID); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repo.GetWishlistItem(ctx, wishlistID, productID); err != nil {
+		return nil, fmt.Errorf("product is not in this wishlist")
+	}
+
+	quantity := req.Quantity
+	if quantity <= 0 {
+		quantity = 1
+	}
+
+	cartItem, err := s.cartSvc.AddToCart(ctx, userID, models.AddToCartRequest{
+		ProductID: productID,
+		Quantity:  quantity,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.RemoveWishlistItem(ctx, wishlistID, productID); err != nil {
+		return nil, fmt.Errorf("failed to remove from wishlist: %w", err)
+	}
+
+	return cartItem, nil
+}
+
+func (s *Service) getOwnedWishlist(ctx context.Context, wishlistID, userID int) (*models.Wishlist, error) {
+	wishlist, err := s.repo.GetWishlistByID(ctx, wishlistID)
+	if err != nil {
+		return nil, fmt.Errorf("wishlist not found")
+	}
+
+	if wishlist.UserID != userID {
+		return nil, fmt.Errorf("unauthorized access to wishlist")
+	}
+
+	return wishlist, nil
+}
+
+func (s *Service) withItems(ctx context.Context, wishlist *models.Wishlist) (*models.Wishlist, error) {
+	items, err := s.repo.GetWishlistItems(ctx, wishlist.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wishlist items: %w", err)
+	}
+
+	wishlist.Items = items
+	return wishlist, nil
+}
@@ -0,0 +1,16 @@
+-- Back-in-stock and price-drop watches
+
+CREATE TABLE product_watches (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    product_id INTEGER REFERENCES products(id) ON DELETE CASCADE,
+    back_in_stock BOOLEAN NOT NULL DEFAULT false,
+    target_price DECIMAL(10,2),
+    unsubscribe_token VARCHAR(64) UNIQUE NOT NULL,
+    notified_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (back_in_stock OR target_price IS NOT NULL)
+);
+
+CREATE INDEX idx_product_watches_user_id ON product_watches(user_id);
+CREATE INDEX idx_product_watches_pending ON product_watches(product_id) WHERE notified_at IS NULL;
@@ -0,0 +1,22 @@
+-- Product views and co-purchase statistics for recommendations
+
+-- One row per user and product, bumped on every view
+CREATE TABLE product_views (
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    product_id INTEGER REFERENCES products(id) ON DELETE CASCADE,
+    view_count INTEGER NOT NULL DEFAULT 1,
+    last_viewed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, product_id)
+);
+
+-- "Customers who bought X also bought Y", rebuilt by a periodic job
+CREATE TABLE product_co_purchases (
+    product_id INTEGER REFERENCES products(id) ON DELETE CASCADE,
+    related_product_id INTEGER REFERENCES products(id) ON DELETE CASCADE,
+    order_count INTEGER NOT NULL,
+    computed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (product_id, related_product_id)
+);
+
+CREATE INDEX idx_product_views_user_recent ON product_views(user_id, last_viewed_at DESC);
+CREATE INDEX idx_order_items_product_id ON order_items(product_id);
@@ -0,0 +1,11 @@
+-- Product attributes used for related-product matching
+
+CREATE TABLE product_attributes (
+    product_id INTEGER REFERENCES products(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    value VARCHAR(255) NOT NULL,
+    PRIMARY KEY (product_id, name)
+);
+
+CREATE INDEX idx_product_attributes_name_value ON product_attributes(name, value);
+CREATE INDEX idx_products_category_price ON products(category_id, price);
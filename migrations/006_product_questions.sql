@@ -0,0 +1,38 @@
+-- Product questions and answers
+
+CREATE TABLE product_questions (
+    id SERIAL PRIMARY KEY,
+    product_id INTEGER REFERENCES products(id) ON DELETE CASCADE,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    status VARCHAR(20) DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE product_answers (
+    id SERIAL PRIMARY KEY,
+    question_id INTEGER REFERENCES product_questions(id) ON DELETE CASCADE,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    is_admin BOOLEAN NOT NULL DEFAULT false,
+    is_verified_buyer BOOLEAN NOT NULL DEFAULT false,
+    upvotes INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- One upvote per user and answer
+CREATE TABLE product_answer_votes (
+    answer_id INTEGER REFERENCES product_answers(id) ON DELETE CASCADE,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (answer_id, user_id)
+);
+
+CREATE INDEX idx_product_questions_product_status ON product_questions(product_id, status);
+CREATE INDEX idx_product_questions_status ON product_questions(status);
+CREATE INDEX idx_product_answers_question_id ON product_answers(question_id);
+
+CREATE TRIGGER update_product_questions_updated_at BEFORE UPDATE ON product_questions FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+CREATE TRIGGER update_product_answers_updated_at BEFORE UPDATE ON product_answers FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,7 @@
+-- Anonymous guest carts identified by a signed cart token
+
+ALTER TABLE cart_items ADD COLUMN guest_token VARCHAR(64);
+ALTER TABLE cart_items ADD CONSTRAINT cart_items_guest_token_product_id_key UNIQUE (guest_token, product_id);
+ALTER TABLE cart_items ADD CONSTRAINT cart_items_owner_check CHECK ((user_id IS NULL) <> (guest_token IS NULL));
+
+CREATE INDEX idx_cart_items_guest_token ON cart_items(guest_token) WHERE guest_token IS NOT NULL;
@@ -0,0 +1,7 @@
+-- Price of each cart item at the time it was added, used to detect price changes at checkout
+
+ALTER TABLE cart_items ADD COLUMN added_price DECIMAL(10,2);
+
+UPDATE cart_items ci SET added_price = p.price FROM products p WHERE ci.product_id = p.id;
+
+ALTER TABLE cart_items ALTER COLUMN added_price SET NOT NULL;
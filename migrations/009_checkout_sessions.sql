@@ -0,0 +1,27 @@
+-- Multi-step checkout sessions and the shipping/discount breakdown on orders
+
+ALTER TABLE orders ADD COLUMN shipping_method VARCHAR(50) NOT NULL DEFAULT '';
+ALTER TABLE orders ADD COLUMN shipping_cost DECIMAL(10,2) NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN discount_amount DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+CREATE TABLE checkout_sessions (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'addressed', 'shipping_selected', 'processing', 'completed', 'expired')),
+    shipping_address TEXT NOT NULL DEFAULT '',
+    billing_address TEXT NOT NULL DEFAULT '',
+    shipping_method VARCHAR(50) NOT NULL DEFAULT '',
+    discount_code VARCHAR(50) NOT NULL DEFAULT '',
+    subtotal DECIMAL(10,2) NOT NULL DEFAULT 0,
+    shipping_cost DECIMAL(10,2) NOT NULL DEFAULT 0,
+    discount_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    total_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    order_id INTEGER REFERENCES orders(id),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_checkout_sessions_user_id ON checkout_sessions(user_id);
+
+CREATE TRIGGER update_checkout_sessions_updated_at BEFORE UPDATE ON checkout_sessions FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
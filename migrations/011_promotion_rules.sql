@@ -0,0 +1,40 @@
+-- Automatic promotion rules and the discounts applied to each order
+
+CREATE TABLE promotion_rules (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    rule_type VARCHAR(30) NOT NULL CHECK (rule_type IN ('buy_x_get_y', 'tiered_percentage', 'bundle', 'free_shipping')),
+    priority INTEGER NOT NULL DEFAULT 0,
+    stackable BOOLEAN NOT NULL DEFAULT true,
+    product_ids INTEGER[] NOT NULL DEFAULT '{}',
+    category_ids INTEGER[] NOT NULL DEFAULT '{}',
+    min_subtotal DECIMAL(10,2) NOT NULL DEFAULT 0,
+    buy_quantity INTEGER NOT NULL DEFAULT 0,
+    get_quantity INTEGER NOT NULL DEFAULT 0,
+    discount_value DECIMAL(10,2) NOT NULL DEFAULT 0,
+    tiers JSONB NOT NULL DEFAULT '[]',
+    starts_at TIMESTAMP WITH TIME ZONE,
+    ends_at TIMESTAMP WITH TIME ZONE,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_promotion_rules_active ON promotion_rules(priority DESC) WHERE is_active = true;
+
+CREATE TABLE order_discounts (
+    id SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    source VARCHAR(20) NOT NULL CHECK (source IN ('coupon', 'promotion')),
+    code VARCHAR(50) NOT NULL DEFAULT '',
+    coupon_id INTEGER REFERENCES coupons(id) ON DELETE SET NULL,
+    rule_id INTEGER REFERENCES promotion_rules(id) ON DELETE SET NULL,
+    description TEXT NOT NULL,
+    amount DECIMAL(10,2) NOT NULL,
+    free_shipping BOOLEAN NOT NULL DEFAULT false
+);
+
+CREATE INDEX idx_order_discounts_order_id ON order_discounts(order_id);
+
+CREATE TRIGGER update_promotion_rules_updated_at BEFORE UPDATE ON promotion_rules FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,62 @@
+-- Customer groups, per-group price lists and quantity price breaks
+
+CREATE TABLE customer_groups (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) UNIQUE NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE users ADD COLUMN customer_group_id INTEGER REFERENCES customer_groups(id) ON DELETE SET NULL;
+
+CREATE TABLE price_list_items (
+    customer_group_id INTEGER NOT NULL REFERENCES customer_groups(id) ON DELETE CASCADE,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    price DECIMAL(10,2) NOT NULL CHECK (price >= 0),
+    PRIMARY KEY (customer_group_id, product_id)
+);
+
+-- A break with no customer group applies to everyone.
+CREATE TABLE product_price_breaks (
+    id SERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    customer_group_id INTEGER REFERENCES customer_groups(id) ON DELETE CASCADE,
+    min_quantity INTEGER NOT NULL CHECK (min_quantity > 1),
+    discount_percent DECIMAL(5,2) NOT NULL CHECK (discount_percent > 0 AND discount_percent < 100)
+);
+
+CREATE INDEX idx_product_price_breaks_product_id ON product_price_breaks(product_id);
+
+ALTER TABLE order_items ADD COLUMN list_price DECIMAL(10,2);
+UPDATE order_items SET list_price = unit_price;
+ALTER TABLE order_items ALTER COLUMN list_price SET NOT NULL;
+
+-- Best unit price for a user buying a quantity of a product: the lowest of
+-- the list price, the user's group price list entry and the list price less
+-- the largest price break the quantity reaches. p_user_id may be NULL for
+-- guests, who only get breaks that are not tied to a group.
+CREATE OR REPLACE FUNCTION resolve_unit_price(p_user_id INTEGER, p_product_id INTEGER, p_quantity INTEGER)
+RETURNS DECIMAL(10,2) AS $$
+    SELECT ROUND(LEAST(
+        p.price,
+        COALESCE((
+            SELECT pli.price
+            FROM price_list_items pli
+            JOIN users u ON u.customer_group_id = pli.customer_group_id
+            WHERE u.id = p_user_id AND pli.product_id = p.id
+        ), p.price),
+        COALESCE((
+            SELECT p.price * (1 - MAX(pb.discount_percent) / 100)
+            FROM product_price_breaks pb
+            WHERE pb.product_id = p.id
+              AND pb.min_quantity <= p_quantity
+              AND (pb.customer_group_id IS NULL
+                   OR pb.customer_group_id = (SELECT customer_group_id FROM users WHERE id = p_user_id))
+        ), p.price)
+    ), 2)
+    FROM products p
+    WHERE p.id = p_product_id
+$$ LANGUAGE sql STABLE;
+
+CREATE TRIGGER update_customer_groups_updated_at BEFORE UPDATE ON customer_groups FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,85 @@
+-- Structured addresses, tax classes, jurisdictions, effective-dated rates and
+-- the taxes applied to each order
+
+-- The single-line addresses of existing orders are kept as they were and
+-- copied into line1 of the structured ones.
+ALTER TABLE orders RENAME COLUMN shipping_address TO legacy_shipping_address;
+ALTER TABLE orders RENAME COLUMN billing_address TO legacy_billing_address;
+ALTER TABLE orders ALTER COLUMN legacy_shipping_address DROP NOT NULL;
+ALTER TABLE orders ALTER COLUMN legacy_billing_address DROP NOT NULL;
+ALTER TABLE orders ADD COLUMN shipping_address JSONB;
+ALTER TABLE orders ADD COLUMN billing_address JSONB;
+UPDATE orders
+SET shipping_address = jsonb_build_object('line1', legacy_shipping_address),
+    billing_address = jsonb_build_object('line1', legacy_billing_address);
+ALTER TABLE orders ALTER COLUMN shipping_address SET NOT NULL;
+ALTER TABLE orders ALTER COLUMN billing_address SET NOT NULL;
+
+ALTER TABLE checkout_sessions ALTER COLUMN shipping_address DROP DEFAULT;
+ALTER TABLE checkout_sessions ALTER COLUMN shipping_address TYPE JSONB USING CASE WHEN shipping_address = '' THEN '{}'::jsonb ELSE jsonb_build_object('line1', shipping_address) END;
+ALTER TABLE checkout_sessions ALTER COLUMN shipping_address SET DEFAULT '{}';
+ALTER TABLE checkout_sessions ALTER COLUMN billing_address DROP DEFAULT;
+ALTER TABLE checkout_sessions ALTER COLUMN billing_address TYPE JSONB USING CASE WHEN billing_address = '' THEN '{}'::jsonb ELSE jsonb_build_object('line1', billing_address) END;
+ALTER TABLE checkout_sessions ALTER COLUMN billing_address SET DEFAULT '{}';
+ALTER TABLE checkout_sessions ADD COLUMN tax_amount DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+CREATE TABLE tax_classes (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) UNIQUE NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Products in a category without a tax class are taxed at the rates that
+-- have no tax class.
+ALTER TABLE categories ADD COLUMN tax_class_id INTEGER REFERENCES tax_classes(id) ON DELETE SET NULL;
+
+-- A jurisdiction with an empty region covers the whole country; regional
+-- jurisdictions apply on top of it.
+CREATE TABLE tax_jurisdictions (
+    id SERIAL PRIMARY KEY,
+    country CHAR(2) NOT NULL,
+    region VARCHAR(100) NOT NULL DEFAULT '',
+    name VARCHAR(100) NOT NULL,
+    prices_include_tax BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(country, region)
+);
+
+CREATE TABLE tax_rates (
+    id SERIAL PRIMARY KEY,
+    jurisdiction_id INTEGER NOT NULL REFERENCES tax_jurisdictions(id) ON DELETE CASCADE,
+    tax_class_id INTEGER REFERENCES tax_classes(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    rate DECIMAL(6,3) NOT NULL CHECK (rate >= 0 AND rate < 100),
+    effective_from DATE NOT NULL,
+    effective_to DATE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (effective_to IS NULL OR effective_to > effective_from)
+);
+
+CREATE INDEX idx_tax_rates_jurisdiction_id ON tax_rates(jurisdiction_id);
+
+ALTER TABLE orders ADD COLUMN tax_amount DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+ALTER TABLE order_items ADD COLUMN tax_rate DECIMAL(6,3) NOT NULL DEFAULT 0;
+ALTER TABLE order_items ADD COLUMN tax_amount DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+CREATE TABLE order_taxes (
+    id SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    tax_rate_id INTEGER REFERENCES tax_rates(id) ON DELETE SET NULL,
+    name VARCHAR(100) NOT NULL,
+    jurisdiction VARCHAR(100) NOT NULL,
+    rate DECIMAL(6,3) NOT NULL,
+    inclusive BOOLEAN NOT NULL DEFAULT false,
+    amount DECIMAL(10,2) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_order_taxes_order_id ON order_taxes(order_id);
+
+CREATE TRIGGER update_tax_classes_updated_at BEFORE UPDATE ON tax_classes FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+CREATE TRIGGER update_tax_jurisdictions_updated_at BEFORE UPDATE ON tax_jurisdictions FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
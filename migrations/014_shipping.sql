@@ -0,0 +1,44 @@
+-- Product weight and dimensions, shipping zones and shipping methods
+
+ALTER TABLE products ADD COLUMN weight_kg DECIMAL(10,3) NOT NULL DEFAULT 0;
+ALTER TABLE products ADD COLUMN length_cm DECIMAL(10,2) NOT NULL DEFAULT 0;
+ALTER TABLE products ADD COLUMN width_cm DECIMAL(10,2) NOT NULL DEFAULT 0;
+ALTER TABLE products ADD COLUMN height_cm DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+-- locations holds {country, postcode_pattern} entries. A zone without
+-- locations serves every address no other zone covers.
+CREATE TABLE shipping_zones (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    locations JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE shipping_methods (
+    id SERIAL PRIMARY KEY,
+    zone_id INTEGER NOT NULL REFERENCES shipping_zones(id) ON DELETE CASCADE,
+    code VARCHAR(50) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    rate_type VARCHAR(30) NOT NULL CHECK (rate_type IN ('flat', 'weight_tiered', 'free_over_threshold')),
+    rate DECIMAL(10,2) NOT NULL DEFAULT 0,
+    weight_tiers JSONB NOT NULL DEFAULT '[]',
+    free_over DECIMAL(10,2) NOT NULL DEFAULT 0,
+    volumetric_divisor INTEGER NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(zone_id, code)
+);
+
+CREATE INDEX idx_shipping_methods_zone_id ON shipping_methods(zone_id);
+
+CREATE TRIGGER update_shipping_zones_updated_at BEFORE UPDATE ON shipping_zones FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+CREATE TRIGGER update_shipping_methods_updated_at BEFORE UPDATE ON shipping_methods FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Keep the methods checkout offered before zones existed.
+INSERT INTO shipping_zones (name) VALUES ('Rest of world');
+INSERT INTO shipping_methods (zone_id, code, name, rate_type, rate)
+SELECT id, 'standard', 'Standard shipping', 'flat', 5.99 FROM shipping_zones WHERE name = 'Rest of world';
+INSERT INTO shipping_methods (zone_id, code, name, rate_type, rate)
+SELECT id, 'express', 'Express shipping', 'flat', 14.99 FROM shipping_zones WHERE name = 'Rest of world';
@@ -0,0 +1,23 @@
+-- Abandoned carts, the reminders sent for them and the orders that recovered them
+
+-- One row per abandonment: a user's cart that saw no update after
+-- last_activity_at. A cart that is updated and left again is a new row.
+CREATE TABLE abandoned_carts (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    last_activity_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    item_count INTEGER NOT NULL,
+    cart_value DECIMAL(10,2) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'skipped')),
+    skip_reason VARCHAR(50) NOT NULL DEFAULT '',
+    reminder_sent_at TIMESTAMP WITH TIME ZONE,
+    coupon_id INTEGER REFERENCES coupons(id) ON DELETE SET NULL,
+    recovered_order_id INTEGER REFERENCES orders(id) ON DELETE SET NULL,
+    recovered_at TIMESTAMP WITH TIME ZONE,
+    detected_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(user_id, last_activity_at)
+);
+
+CREATE INDEX idx_abandoned_carts_status ON abandoned_carts(status) WHERE status = 'pending';
+CREATE INDEX idx_abandoned_carts_user_reminder ON abandoned_carts(user_id, reminder_sent_at);
+CREATE INDEX idx_abandoned_carts_detected_at ON abandoned_carts(detected_at);
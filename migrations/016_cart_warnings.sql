@@ -0,0 +1,3 @@
+-- Quantity a cart item had before it was reduced to the stock left, until the customer acknowledges it
+
+ALTER TABLE cart_items ADD COLUMN quantity_reduced_from INTEGER;
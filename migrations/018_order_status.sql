@@ -0,0 +1,3 @@
+-- Tracking number recorded when an order ships
+
+ALTER TABLE orders ADD COLUMN tracking_number VARCHAR(100) NOT NULL DEFAULT '';
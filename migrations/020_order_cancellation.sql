@@ -0,0 +1,5 @@
+-- Reason code given when an order is cancelled
+
+ALTER TABLE orders ADD COLUMN cancellation_reason VARCHAR(30) NOT NULL DEFAULT '';
+
+CREATE INDEX idx_orders_cancellation_reason ON orders(cancellation_reason) WHERE status = 'cancelled';
@@ -0,0 +1,39 @@
+-- Return merchandise authorisations against delivered order items
+
+CREATE TABLE returns (
+    id SERIAL PRIMARY KEY,
+    rma_number VARCHAR(20) UNIQUE NOT NULL,
+    order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'requested' CHECK (status IN ('requested', 'approved', 'rejected', 'received', 'refunded', 'exchanged')),
+    resolution VARCHAR(20) NOT NULL CHECK (resolution IN ('refund', 'exchange')),
+    label_reference VARCHAR(64) NOT NULL DEFAULT '',
+    admin_note TEXT NOT NULL DEFAULT '',
+    refund_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    exchange_order_id INTEGER REFERENCES orders(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- condition is set when the goods are received: resellable items go back in
+-- stock, damaged ones are written off.
+CREATE TABLE return_items (
+    id SERIAL PRIMARY KEY,
+    return_id INTEGER NOT NULL REFERENCES returns(id) ON DELETE CASCADE,
+    order_item_id INTEGER NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    reason VARCHAR(30) NOT NULL,
+    condition VARCHAR(20) NOT NULL DEFAULT '' CHECK (condition IN ('', 'resellable', 'damaged')),
+    restocked BOOLEAN NOT NULL DEFAULT false,
+    UNIQUE(return_id, order_item_id)
+);
+
+CREATE INDEX idx_returns_order_id ON returns(order_id);
+CREATE INDEX idx_returns_user_id ON returns(user_id);
+CREATE INDEX idx_returns_status ON returns(status);
+CREATE INDEX idx_return_items_order_item_id ON return_items(order_item_id);
+
+ALTER TABLE order_events DROP CONSTRAINT order_events_event_type_check;
+ALTER TABLE order_events ADD CONSTRAINT order_events_event_type_check CHECK (event_type IN ('status_changed', 'payment', 'note', 'return'));
+
+CREATE TRIGGER update_returns_updated_at BEFORE UPDATE ON returns FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,39 @@
+-- Full and partial refunds against captured payments
+
+-- A refund is recorded as pending before the payment provider is asked to pay
+-- it out, so concurrent refunds can never add up to more than was captured.
+CREATE TABLE refunds (
+    id SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    payment_id INTEGER NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    amount DECIMAL(10,2) NOT NULL CHECK (amount > 0),
+    reason TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'completed', 'failed')),
+    provider_reference VARCHAR(255) NOT NULL DEFAULT '',
+    failure_reason TEXT NOT NULL DEFAULT '',
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE refund_items (
+    id SERIAL PRIMARY KEY,
+    refund_id INTEGER NOT NULL REFERENCES refunds(id) ON DELETE CASCADE,
+    order_item_id INTEGER NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    amount DECIMAL(10,2) NOT NULL
+);
+
+CREATE INDEX idx_refunds_order_id ON refunds(order_id);
+CREATE INDEX idx_refunds_payment_id ON refunds(payment_id);
+CREATE INDEX idx_refund_items_order_item_id ON refund_items(order_item_id);
+
+ALTER TABLE returns ADD COLUMN refund_id INTEGER REFERENCES refunds(id) ON DELETE SET NULL;
+
+-- What the customer has paid for the order, less what has been refunded.
+ALTER TABLE orders ADD COLUMN net_paid_amount DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+UPDATE orders o
+SET net_paid_amount = COALESCE((SELECT SUM(amount) FROM payments WHERE order_id = o.id AND payment_status = 'completed'), 0);
+
+CREATE TRIGGER update_refunds_updated_at BEFORE UPDATE ON refunds FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,47 @@
+-- Shipments: an order leaves in one or more boxes, each with its own items,
+-- carrier and tracking number
+
+CREATE TABLE shipments (
+    id SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'shipped', 'delivered')),
+    carrier VARCHAR(50) NOT NULL DEFAULT '',
+    tracking_number VARCHAR(100) NOT NULL DEFAULT '',
+    shipped_at TIMESTAMP WITH TIME ZONE,
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE shipment_items (
+    id SERIAL PRIMARY KEY,
+    shipment_id INTEGER NOT NULL REFERENCES shipments(id) ON DELETE CASCADE,
+    order_item_id INTEGER NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    UNIQUE(shipment_id, order_item_id)
+);
+
+CREATE INDEX idx_shipments_order_id ON shipments(order_id);
+CREATE INDEX idx_shipment_items_order_item_id ON shipment_items(order_item_id);
+
+-- An order's status now follows its shipments.
+ALTER TABLE orders DROP CONSTRAINT orders_status_check;
+ALTER TABLE orders ADD CONSTRAINT orders_status_check CHECK (status IN ('pending', 'confirmed', 'partially_shipped', 'shipped', 'delivered', 'cancelled'));
+
+-- Orders shipped before shipments existed went out in one box.
+INSERT INTO shipments (order_id, status, tracking_number, shipped_at, delivered_at)
+SELECT id, status, tracking_number, updated_at, CASE WHEN status = 'delivered' THEN updated_at END
+FROM orders
+WHERE status IN ('shipped', 'delivered');
+
+INSERT INTO shipment_items (shipment_id, order_item_id, quantity)
+SELECT s.id, oi.id, oi.quantity
+FROM shipments s
+JOIN order_items oi ON oi.order_id = s.order_id;
+
+ALTER TABLE orders DROP COLUMN tracking_number;
+
+ALTER TABLE order_events DROP CONSTRAINT order_events_event_type_check;
+ALTER TABLE order_events ADD CONSTRAINT order_events_event_type_check CHECK (event_type IN ('status_changed', 'payment', 'note', 'return', 'shipment'));
+
+CREATE TRIGGER update_shipments_updated_at BEFORE UPDATE ON shipments FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
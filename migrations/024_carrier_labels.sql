@@ -0,0 +1,32 @@
+-- Carrier labels bought for shipments and the tracking events carriers report
+
+-- A shipment has at most one label. The label's carrier and tracking number
+-- are copied onto the shipment.
+CREATE TABLE shipment_labels (
+    id SERIAL PRIMARY KEY,
+    shipment_id INTEGER NOT NULL UNIQUE REFERENCES shipments(id) ON DELETE CASCADE,
+    carrier VARCHAR(50) NOT NULL,
+    service VARCHAR(50) NOT NULL,
+    tracking_number VARCHAR(100) NOT NULL,
+    format VARCHAR(10) NOT NULL CHECK (format IN ('pdf', 'zpl')),
+    cost DECIMAL(10,2) NOT NULL,
+    data BYTEA NOT NULL,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Carriers resend webhooks, so each of their events is kept once per
+-- shipment by the carrier's own event ID.
+CREATE TABLE shipment_tracking_events (
+    id SERIAL PRIMARY KEY,
+    shipment_id INTEGER NOT NULL REFERENCES shipments(id) ON DELETE CASCADE,
+    event_id VARCHAR(100) NOT NULL,
+    status VARCHAR(30) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    location VARCHAR(255) NOT NULL DEFAULT '',
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(shipment_id, event_id)
+);
+
+CREATE INDEX idx_shipments_tracking_number ON shipments(carrier, tracking_number);
@@ -0,0 +1,48 @@
+-- Invoices for paid orders and credit notes for their refunds
+
+-- Each series numbers its documents from 1 every year. The counter row is
+-- locked from the number being taken until the document is saved, so a
+-- failed save gives the number back and the numbering has no gaps.
+CREATE TABLE document_sequences (
+    series VARCHAR(20) NOT NULL,
+    year INTEGER NOT NULL,
+    last_number INTEGER NOT NULL,
+    PRIMARY KEY (series, year)
+);
+
+-- Invoices and credit notes are frozen copies of what was sold and
+-- credited: the seller, buyer and lines are stored as they were issued.
+-- Issued documents are kept, so an invoiced order cannot be deleted.
+CREATE TABLE invoices (
+    id SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL UNIQUE REFERENCES orders(id) ON DELETE RESTRICT,
+    invoice_number VARCHAR(30) NOT NULL UNIQUE,
+    order_number VARCHAR(50) NOT NULL,
+    seller JSONB NOT NULL,
+    buyer JSONB NOT NULL,
+    lines JSONB NOT NULL,
+    tax_lines JSONB NOT NULL DEFAULT '[]',
+    subtotal DECIMAL(10,2) NOT NULL,
+    discount_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    shipping_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    tax_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    total_amount DECIMAL(10,2) NOT NULL,
+    pdf BYTEA,
+    rendered_at TIMESTAMP WITH TIME ZONE,
+    issued_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE credit_notes (
+    id SERIAL PRIMARY KEY,
+    invoice_id INTEGER NOT NULL REFERENCES invoices(id) ON DELETE RESTRICT,
+    refund_id INTEGER NOT NULL UNIQUE REFERENCES refunds(id) ON DELETE RESTRICT,
+    credit_note_number VARCHAR(30) NOT NULL UNIQUE,
+    lines JSONB NOT NULL,
+    amount DECIMAL(10,2) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    pdf BYTEA,
+    rendered_at TIMESTAMP WITH TIME ZONE,
+    issued_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_credit_notes_invoice_id ON credit_notes(invoice_id);
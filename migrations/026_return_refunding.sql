@@ -0,0 +1,5 @@
+-- A refund return is claimed as refunding while its refund is paid out, so
+-- it can only ever be refunded once.
+
+ALTER TABLE returns DROP CONSTRAINT returns_status_check;
+ALTER TABLE returns ADD CONSTRAINT returns_status_check CHECK (status IN ('requested', 'approved', 'rejected', 'received', 'refunding', 'refunded', 'exchanged'));
@@ -0,0 +1,3 @@
+-- Cart quantities are no longer lowered when the cart is read, only when the customer acknowledges the shortfall
+
+ALTER TABLE cart_items DROP COLUMN quantity_reduced_from;
@@ -0,0 +1,4 @@
+-- Unit prices are resolved by the pricing service from the list price, price
+-- lists and price breaks; the database only stores them.
+
+DROP FUNCTION resolve_unit_price(INTEGER, INTEGER, INTEGER);
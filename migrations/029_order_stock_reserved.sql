@@ -0,0 +1,6 @@
+-- Only orders placed from the cart reserve stock, so cancelling puts stock back only for orders that took it.
+-- Orders placed before this migration all reserved their stock.
+
+ALTER TABLE orders ADD COLUMN stock_reserved BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE orders SET stock_reserved = TRUE;